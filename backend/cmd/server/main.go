@@ -8,9 +8,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Edwin9301/Zen/backend/internal/alerts"
 	"github.com/Edwin9301/Zen/backend/internal/handlers"
+	"github.com/Edwin9301/Zen/backend/internal/ingest"
+	"github.com/Edwin9301/Zen/backend/internal/jobs"
 	"github.com/Edwin9301/Zen/backend/internal/postgres"
 	"github.com/Edwin9301/Zen/backend/internal/reports"
+	"github.com/Edwin9301/Zen/backend/internal/storage"
+	"github.com/Edwin9301/Zen/backend/internal/stream"
 	"github.com/Edwin9301/Zen/backend/pkg"
 )
 
@@ -38,10 +43,45 @@ func main() {
 	// initialize repository
 	postgresRepo := postgres.NewPostgresRepo(store)
 
+	emailSender := pkg.NewGmailSender(config.EMAIL_SENDER_NAME, config.EMAIL_SENDER_ADDRESS, config.EMAIL_SENDER_PASSWORD)
+
+	fileStorage, err := storage.NewFileStorage(config)
+	if err != nil {
+		log.Fatalf("Error opening file storage: %v", err)
+	}
+
 	report := reports.NewReportService(postgresRepo)
+	alertService := alerts.NewAlertService(postgresRepo, emailSender)
+	ingestService := ingest.NewIngestService(postgresRepo, alertService)
+
+	// background jobs, stopped on shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+
+	// fan out new readings to live stream subscribers
+	hub := stream.NewHub()
+	go postgresRepo.DeviceRepository.ListenReadings(jobsCtx, hub.HasSubscribers, hub.Publish)
+
+	go jobs.RunConnectivityChecker(jobsCtx, config, postgresRepo.DeviceRepository)
+	go jobs.RunPartitionMaintenance(jobsCtx, config, postgresRepo.DeviceRepository)
+	go jobs.RunReadingRetention(jobsCtx, config, postgresRepo.RetentionRepository)
+	go jobs.RunReportJobs(jobsCtx, config, postgresRepo.ReportJobRepository, report, emailSender)
+	go jobs.RunReportSchedules(jobsCtx, config, postgresRepo.ReportScheduleRepository, report, emailSender)
+
+	// optional mqtt ingestion
+	var mqttBridge *ingest.MQTTBridge
+	if config.MQTT_ENABLED {
+		mqttBridge, err = ingest.NewMQTTBridge(config, ingestService)
+		if err != nil {
+			log.Fatalf("Error creating mqtt bridge: %v", err)
+		}
+
+		if err := mqttBridge.Start(); err != nil {
+			log.Fatalf("Error starting mqtt bridge: %v", err)
+		}
+	}
 
 	// start server
-	server := handlers.NewServer(config, tokenMaker, postgresRepo, report)
+	server := handlers.NewServer(config, tokenMaker, postgresRepo, report, ingestService, fileStorage, hub)
 	log.Println("starting server at address: ", config.SERVER_ADDRESS)
 	if err := server.Start(); err != nil {
 		log.Fatalf("Error starting server: %v", err)
@@ -59,6 +99,11 @@ func main() {
 		log.Fatalf("Error stopping server: %v", err)
 	}
 
+	if mqttBridge != nil {
+		mqttBridge.Stop()
+	}
+
+	stopJobs()
 	store.CloseDB()
 
 	log.Println("Server shutdown ...")
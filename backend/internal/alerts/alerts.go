@@ -0,0 +1,201 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+var _ services.AlertService = (*AlertService)(nil)
+
+var comparatorSymbols = map[string]string{
+	repository.ComparatorGreaterThan:      ">",
+	repository.ComparatorGreaterThanEqual: ">=",
+	repository.ComparatorLessThan:         "<",
+	repository.ComparatorLessThanEqual:    "<=",
+	repository.ComparatorEqual:            "=",
+	repository.ComparatorNotEqual:         "!=",
+}
+
+type AlertService struct {
+	store *postgres.PostgresRepo
+	email pkg.EmailSender
+}
+
+// notification is an incident change to email once its transaction commits.
+type notification struct {
+	rule     *repository.AlertRule
+	value    float64
+	at       time.Time
+	resolved bool
+}
+
+func NewAlertService(store *postgres.PostgresRepo, email pkg.EmailSender) *AlertService {
+	return &AlertService{
+		store: store,
+		email: email,
+	}
+}
+
+// EvaluateReading checks a stored reading against the enabled rules of its
+// device. A rule opens an incident once its breach has lasted for the rule
+// duration, and resolves the active incident once the value is back in range.
+// The rules of the device stay locked while the reading is evaluated, and
+// emails go out only once the incident changes are committed.
+func (s *AlertService) EvaluateReading(ctx context.Context, reading *repository.Reading) error {
+	payload, ok := reading.Payload.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var notifications []notification
+	err := s.store.AlertRepository.EvaluateDeviceAlertRules(ctx, reading.DeviceID, func(rules []*repository.AlertRule, tx repository.AlertEvaluationTx) error {
+		for _, rule := range rules {
+			value, ok := payload[rule.Key].(float64)
+			if !ok {
+				continue
+			}
+
+			n, err := evaluateRule(ctx, tx, rule, value, reading.Timestamp)
+			if err != nil {
+				return err
+			}
+			if n != nil {
+				notifications = append(notifications, *n)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, n := range notifications {
+		s.notify(n.rule, n.value, n.at, n.resolved)
+	}
+
+	return nil
+}
+
+// evaluateRule updates the breach state of a rule for one value, returning
+// the notification to send when it opens or resolves an incident.
+func evaluateRule(ctx context.Context, tx repository.AlertEvaluationTx, rule *repository.AlertRule, value float64, at time.Time) (*notification, error) {
+	if !rule.Breached(value) {
+		if rule.BreachStartedAt != nil {
+			if err := tx.SetAlertRuleBreachStartedAt(ctx, rule.ID, nil); err != nil {
+				return nil, err
+			}
+		}
+
+		incident, err := tx.GetActiveAlertIncident(ctx, rule.ID)
+		if err != nil || incident == nil {
+			return nil, err
+		}
+
+		if _, err := tx.ResolveAlertIncident(ctx, incident.ID, at); err != nil {
+			return nil, err
+		}
+
+		return &notification{rule: rule, value: value, at: at, resolved: true}, nil
+	}
+
+	breachStartedAt := at
+	if rule.BreachStartedAt != nil {
+		breachStartedAt = *rule.BreachStartedAt
+	} else if err := tx.SetAlertRuleBreachStartedAt(ctx, rule.ID, &at); err != nil {
+		return nil, err
+	}
+
+	if at.Sub(breachStartedAt) < time.Duration(rule.DurationSeconds)*time.Second {
+		return nil, nil
+	}
+
+	incident, err := tx.GetActiveAlertIncident(ctx, rule.ID)
+	if err != nil || incident != nil {
+		return nil, err
+	}
+
+	if _, err := tx.OpenAlertIncident(ctx, rule, value, at); err != nil {
+		return nil, err
+	}
+
+	return &notification{rule: rule, value: value, at: at, resolved: false}, nil
+}
+
+// notify emails the rule recipients, falling back to every active admin when
+// the rule has none. Sending happens in the background so a slow mail server
+// does not hold up ingestion.
+func (s *AlertService) notify(rule *repository.AlertRule, value float64, at time.Time, resolved bool) {
+	title := fmt.Sprintf("[%s] %s triggered", strings.ToUpper(rule.Severity), rule.Name)
+	if resolved {
+		title = fmt.Sprintf("[RESOLVED] %s", rule.Name)
+	}
+
+	emailBody, err := pkg.GenerateText("alert_incident", pkg.AlertIncidentTemplate, map[string]any{
+		"Title":      title,
+		"Resolved":   resolved,
+		"RuleName":   rule.Name,
+		"DeviceID":   rule.DeviceID,
+		"Severity":   rule.Severity,
+		"Key":        rule.Key,
+		"Comparator": comparatorSymbols[rule.Comparator],
+		"Threshold":  rule.Threshold,
+		"Value":      value,
+		"Time":       at.Format(time.RFC1123),
+	})
+	if err != nil {
+		log.Printf("failed to render alert email for rule %d: %v", rule.ID, err)
+		return
+	}
+
+	go func(recipients []string) {
+		if len(recipients) == 0 {
+			var err error
+			recipients, err = s.adminEmails(context.Background())
+			if err != nil {
+				log.Printf("failed to load alert recipients for rule %d: %v", rule.ID, err)
+				return
+			}
+		}
+
+		if len(recipients) == 0 {
+			return
+		}
+
+		if err := s.email.SendMail(title, emailBody, "text/html", recipients, nil, nil, nil, nil); err != nil {
+			log.Printf("failed to send alert email for rule %d: %v", rule.ID, err)
+		}
+	}(rule.NotifyEmails)
+}
+
+func (s *AlertService) adminEmails(ctx context.Context) ([]string, error) {
+	role := "admin"
+	isActive := true
+
+	users, _, err := s.store.UserRepository.ListUsers(ctx, &repository.FilterUsers{
+		Pagination: &pkg.Pagination{
+			Page:     1,
+			PageSize: 100,
+		},
+		Role:     &role,
+		IsActive: &isActive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]string, 0, len(users))
+	for _, user := range users {
+		emails = append(emails, user.Email)
+	}
+
+	return emails, nil
+}
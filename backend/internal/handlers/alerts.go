@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+type alertRuleRequest struct {
+	DeviceID        uint32   `json:"deviceId" binding:"required"`
+	Name            string   `json:"name" binding:"required"`
+	Key             string   `json:"key" binding:"required"`
+	Comparator      string   `json:"comparator" binding:"required,oneof=gt gte lt lte eq neq"`
+	Threshold       *float64 `json:"threshold" binding:"required"`
+	DurationSeconds int32    `json:"durationSeconds" binding:"min=0"`
+	Severity        string   `json:"severity" binding:"required,oneof=info warning critical"`
+	NotifyEmails    []string `json:"notifyEmails" binding:"omitempty,dive,email"`
+	Enabled         *bool    `json:"enabled"`
+}
+
+func (req *alertRuleRequest) toAlertRule() *repository.AlertRule {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &repository.AlertRule{
+		DeviceID:        req.DeviceID,
+		Name:            req.Name,
+		Key:             req.Key,
+		Comparator:      req.Comparator,
+		Threshold:       *req.Threshold,
+		DurationSeconds: req.DurationSeconds,
+		Severity:        req.Severity,
+		NotifyEmails:    req.NotifyEmails,
+		Enabled:         enabled,
+	}
+}
+
+func (s *Server) createAlertRuleHandler(ctx *gin.Context) {
+	var req alertRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	rule, err := s.repo.AlertRepository.CreateAlertRule(ctx, req.toAlertRule())
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": rule})
+}
+
+func (s *Server) getAlertRuleHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid alert rule ID")))
+		return
+	}
+
+	rule, err := s.repo.AlertRepository.GetAlertRuleByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": rule})
+}
+
+func (s *Server) listAlertRulesHandler(ctx *gin.Context) {
+	var deviceID *uint32
+	if deviceIDStr := ctx.Query("device_id"); deviceIDStr != "" {
+		id, err := pkg.StrToUint32(deviceIDStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+			return
+		}
+		deviceID = &id
+	}
+
+	rules, err := s.repo.AlertRepository.ListAlertRules(ctx, deviceID)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+func (s *Server) updateAlertRuleHandler(ctx *gin.Context) {
+	var req alertRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid alert rule ID")))
+		return
+	}
+
+	existing, err := s.repo.AlertRepository.GetAlertRuleByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	if existing.DeviceID != req.DeviceID {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "the device of an alert rule cannot be changed")))
+		return
+	}
+
+	rule := req.toAlertRule()
+	rule.ID = id
+
+	updatedRule, err := s.repo.AlertRepository.UpdateAlertRule(ctx, rule)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": updatedRule})
+}
+
+func (s *Server) deleteAlertRuleHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid alert rule ID")))
+		return
+	}
+
+	if err := s.repo.AlertRepository.DeleteAlertRule(ctx, id); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "alert rule deleted successfully"})
+}
+
+func (s *Server) listAlertIncidentsHandler(ctx *gin.Context) {
+	pageNo, err := pkg.StrToUint32(ctx.DefaultQuery("page", "1"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	pageSize, err := pkg.StrToUint32(ctx.DefaultQuery("limit", "10"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	filter := &repository.FilterAlertIncidents{
+		Pagination: &pkg.Pagination{
+			Page:     pageNo,
+			PageSize: pageSize,
+		},
+	}
+
+	if status := ctx.Query("status"); status != "" {
+		switch status {
+		case repository.IncidentStatusOpen, repository.IncidentStatusAcknowledged, repository.IncidentStatusResolved:
+			filter.Status = &status
+		default:
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid incident status %q", status)))
+			return
+		}
+	}
+
+	if deviceIDStr := ctx.Query("device_id"); deviceIDStr != "" {
+		deviceID, err := pkg.StrToUint32(deviceIDStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+			return
+		}
+		filter.DeviceID = &deviceID
+	}
+
+	incidents, pagination, err := s.repo.AlertRepository.ListAlertIncidents(ctx, filter)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": incidents, "pagination": pagination})
+}
+
+func (s *Server) getAlertIncidentHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid alert incident ID")))
+		return
+	}
+
+	incident, err := s.repo.AlertRepository.GetAlertIncidentByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": incident})
+}
+
+func (s *Server) acknowledgeAlertIncidentHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid alert incident ID")))
+		return
+	}
+
+	payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Invalid auth payload")))
+		return
+	}
+
+	incident, err := s.repo.AlertRepository.AcknowledgeAlertIncident(ctx, id, payload.UserID)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": incident})
+}
+
+func (s *Server) resolveAlertIncidentHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid alert incident ID")))
+		return
+	}
+
+	incident, err := s.repo.AlertRepository.ResolveAlertIncident(ctx, id, time.Now())
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": incident})
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+type deviceChannelRequest struct {
+	Name     string   `json:"name" binding:"required"`
+	Unit     string   `json:"unit"`
+	DataType string   `json:"dataType" binding:"required,oneof=float integer boolean"`
+	Min      *float64 `json:"min"`
+	Max      *float64 `json:"max"`
+	Required bool     `json:"required"`
+	Position int32    `json:"position"`
+}
+
+func (req *deviceChannelRequest) toDeviceChannel(deviceID uint32) (*repository.DeviceChannel, error) {
+	if req.Min != nil && req.Max != nil && *req.Min > *req.Max {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "min cannot be greater than max")
+	}
+
+	if req.DataType == repository.ChannelTypeBoolean && (req.Min != nil || req.Max != nil) {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "boolean channels cannot have a range")
+	}
+
+	if repository.ReadingMetadataKeys[req.Name] {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "%s is a reserved payload key", req.Name)
+	}
+
+	return &repository.DeviceChannel{
+		DeviceID: deviceID,
+		Name:     req.Name,
+		Unit:     req.Unit,
+		DataType: req.DataType,
+		Min:      req.Min,
+		Max:      req.Max,
+		Required: req.Required,
+		Position: req.Position,
+	}, nil
+}
+
+func (s *Server) createDeviceChannelHandler(ctx *gin.Context) {
+	var req deviceChannelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	channel, err := req.toDeviceChannel(id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	createdChannel, err := s.repo.DeviceRepository.CreateDeviceChannel(ctx, channel)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": createdChannel})
+}
+
+func (s *Server) listDeviceChannelsHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	channels, err := s.repo.DeviceRepository.ListDeviceChannels(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": channels})
+}
+
+func (s *Server) updateDeviceChannelHandler(ctx *gin.Context) {
+	var req deviceChannelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	channelID, err := pkg.StrToUint32(ctx.Param("channelId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid channel ID")))
+		return
+	}
+
+	channel, err := req.toDeviceChannel(id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+	channel.ID = channelID
+
+	updatedChannel, err := s.repo.DeviceRepository.UpdateDeviceChannel(ctx, channel)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": updatedChannel})
+}
+
+func (s *Server) deleteDeviceChannelHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	channelID, err := pkg.StrToUint32(ctx.Param("channelId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid channel ID")))
+		return
+	}
+
+	if err := s.repo.DeviceRepository.DeleteDeviceChannel(ctx, id, channelID); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -9,11 +9,16 @@ import (
 )
 
 type createDeviceRequest struct {
-	ReactorID uint32 `json:"reactor_id"`
-	Name      string `json:"name" binding:"required"`
-	Status    bool   `json:"status"`
+	ReactorID               uint32 `json:"reactor_id"`
+	Name                    string `json:"name" binding:"required"`
+	Status                  bool   `json:"status"`
+	ExpectedIntervalSeconds int32  `json:"expectedIntervalSeconds" binding:"omitempty,min=1"`
 }
 
+// defaultExpectedIntervalSeconds matches the column default for devices that
+// do not declare how often they report.
+const defaultExpectedIntervalSeconds = 60
+
 func (s *Server) createDeviceHandler(ctx *gin.Context) {
 	var req createDeviceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -25,6 +30,11 @@ func (s *Server) createDeviceHandler(ctx *gin.Context) {
 		ReactorID: req.ReactorID,
 		Name:      req.Name,
 		Status:    req.Status,
+
+		ExpectedIntervalSeconds: req.ExpectedIntervalSeconds,
+	}
+	if device.ExpectedIntervalSeconds == 0 {
+		device.ExpectedIntervalSeconds = defaultExpectedIntervalSeconds
 	}
 
 	createdDevice, err := s.repo.DeviceRepository.CreateDevice(ctx, device)
@@ -109,3 +119,88 @@ func (s *Server) getDeviceStatsHandler(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": stats})
 }
+
+type createDeviceAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func (s *Server) createDeviceAPIKeyHandler(ctx *gin.Context) {
+	var req createDeviceAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	apiKey, err := s.repo.DeviceRepository.CreateDeviceAPIKey(ctx, id, req.Name)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": apiKey})
+}
+
+func (s *Server) listDeviceAPIKeysHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	apiKeys, err := s.repo.DeviceRepository.ListDeviceAPIKeys(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": apiKeys})
+}
+
+func (s *Server) rotateDeviceAPIKeyHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	keyID, err := pkg.StrToUint32(ctx.Param("keyId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid api key ID")))
+		return
+	}
+
+	apiKey, err := s.repo.DeviceRepository.RotateDeviceAPIKey(ctx, id, keyID)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": apiKey})
+}
+
+func (s *Server) revokeDeviceAPIKeyHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	keyID, err := pkg.StrToUint32(ctx.Param("keyId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid api key ID")))
+		return
+	}
+
+	if err := s.repo.DeviceRepository.RevokeDeviceAPIKey(ctx, id, keyID); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": true})
+}
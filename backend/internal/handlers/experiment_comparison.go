@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// maxComparedExperiments bounds how many runs are compared at once.
+const maxComparedExperiments = 10
+
+// experimentComparisonOptions reads the comma separated experiment ids and
+// optional curve keys of a comparison.
+func experimentComparisonOptions(ctx *gin.Context) (services.ExperimentComparisonOptions, error) {
+	var options services.ExperimentComparisonOptions
+
+	ids := splitQueryList(ctx.Query("ids"))
+	if len(ids) < 2 || len(ids) > maxComparedExperiments {
+		return options, pkg.Errorf(pkg.INVALID_ERROR, "ids must list between 2 and %d experiment IDs", maxComparedExperiments)
+	}
+
+	seen := map[uint32]bool{}
+	for _, idStr := range ids {
+		id, err := pkg.StrToUint32(idStr)
+		if err != nil {
+			return options, pkg.Errorf(pkg.INVALID_ERROR, "invalid experiment ID %q", idStr)
+		}
+		if seen[id] {
+			return options, pkg.Errorf(pkg.INVALID_ERROR, "experiment %d is listed twice", id)
+		}
+		seen[id] = true
+		options.ExperimentIDs = append(options.ExperimentIDs, id)
+	}
+	options.Keys = splitQueryList(ctx.Query("keys"))
+
+	return options, nil
+}
+
+// compareExperimentsHandler returns the parameters and analytical tests of
+// the experiments lined up, with their sensor curves resampled on a shared
+// time since start axis.
+func (s *Server) compareExperimentsHandler(ctx *gin.Context) {
+	options, err := experimentComparisonOptions(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	// the curves are built from every raw reading of the runs
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Now().Add(reportWriteTimeout)); err != nil {
+		log.Printf("failed to extend comparison write deadline: %v", err)
+	}
+
+	comparison, err := s.report.CompareExperiments(ctx.Request.Context(), options)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": comparison})
+}
+
+func (s *Server) experimentComparisonReportHandler(ctx *gin.Context) {
+	options, err := experimentComparisonOptions(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Now().Add(reportWriteTimeout)); err != nil {
+		log.Printf("failed to extend report write deadline: %v", err)
+	}
+
+	excelData, err := s.report.GenerateExperimentComparisonReport(ctx.Request.Context(), options)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=experiment_comparison_%s.xlsx", time.Now().UTC().Format("2006-01-02")))
+	ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelData)
+}
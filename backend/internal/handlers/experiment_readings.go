@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRawExperimentWindow bounds the runs whose readings are returned raw.
+// Longer runs must be bucketed, as every raw reading of every device would
+// otherwise be loaded into one response.
+const maxRawExperimentWindow = 24 * time.Hour
+
+// experimentDeviceReadings holds the readings of one device over its stay on
+// the reactor during an experiment, raw or aggregated into buckets.
+type experimentDeviceReadings struct {
+	*repository.ExperimentDevice
+	Readings []*repository.Reading       `json:"readings,omitempty"`
+	Buckets  []*repository.ReadingBucket `json:"buckets,omitempty"`
+}
+
+// experimentReadingsHandler returns the readings recorded during an
+// experiment by the devices attached to its reactor. Readings are raw unless
+// a bucket is given, in which case fns and keys work as for the aggregate
+// endpoint. A bucket is required for runs longer than maxRawExperimentWindow,
+// and the run may span at most maxAggregateBuckets of it.
+func (s *Server) experimentReadingsHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid experiment ID")))
+		return
+	}
+
+	var aggregate *repository.AggregateFilter
+	bucketStr := ctx.Query("bucket")
+	if bucketStr != "" {
+		bucket, ok := repository.AggregateBuckets[bucketStr]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid bucket %q, must be one of 1m, 5m, 1h, 1d", bucketStr)))
+			return
+		}
+
+		functions, err := parseAggregateFunctions(ctx.DefaultQuery("fns", repository.AggregateAvg))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		aggregate = &repository.AggregateFilter{
+			Bucket:    bucket,
+			Keys:      splitQueryList(ctx.Query("keys")),
+			Functions: functions,
+		}
+	}
+
+	experiment, err := s.repo.ExperimentRepository.GetExperimentByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	start, end, err := experiment.Window()
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	if aggregate == nil && end.Sub(start) > maxRawExperimentWindow {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "experiment ran for more than %d hours, a bucket is required", int(maxRawExperimentWindow.Hours()))))
+		return
+	}
+
+	// each device stays within the run, so bounding the run bounds them all
+	if aggregate != nil && end.Sub(start)/aggregate.Bucket > maxAggregateBuckets {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "experiment spans more than %d %s buckets, use a larger bucket", maxAggregateBuckets, bucketStr)))
+		return
+	}
+
+	devices, err := s.repo.ExperimentRepository.ListExperimentDevices(ctx, experiment)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	results := make([]*experimentDeviceReadings, 0, len(devices))
+	for _, device := range devices {
+		result := &experimentDeviceReadings{ExperimentDevice: device}
+
+		if aggregate != nil {
+			filter := *aggregate
+			filter.DeviceID = device.DeviceID
+			filter.Start = device.Start
+			filter.End = device.End
+
+			result.Buckets, err = s.repo.DeviceRepository.AggregateReadings(ctx, &filter)
+		} else {
+			result.Readings, err = s.repo.DeviceRepository.ListReadingByTimeRange(ctx, &repository.ReadingFilter{
+				DeviceID: device.DeviceID,
+				Start:    &device.Start,
+				End:      &device.End,
+			})
+		}
+		if err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+			return
+		}
+
+		results = append(results, result)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"experimentId": experiment.ID,
+		"start":        start,
+		"end":          end,
+		"devices":      results,
+	}})
+}
+
+// experimentStatsHandler returns the run statistics of an experiment.
+// Statistics are stored once the experiment is stopped; refresh=true
+// recomputes them, for instance after late readings arrived.
+func (s *Server) experimentStatsHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid experiment ID")))
+		return
+	}
+
+	refresh := ctx.Query("refresh") == "true"
+
+	stats, err := s.report.ExperimentRunStats(ctx.Request.Context(), id, refresh)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": stats})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// experimentTemplateReq defines a template from scratch with the same
+// flattened conditions as createExperimentReq.
+type experimentTemplateReq struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+
+	experimentConditionsReq
+}
+
+func (req *experimentTemplateReq) toExperimentTemplate() *repository.ExperimentTemplate {
+	return &repository.ExperimentTemplate{
+		Name:               req.Name,
+		Description:        req.Description,
+		MaterialFeedstock:  req.materialFeedstock(),
+		ExposureConditions: req.exposureConditions(),
+	}
+}
+
+// saveExperimentTemplateReq names a template saved from an experiment, which
+// supplies its conditions.
+type saveExperimentTemplateReq struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+func (s *Server) createExperimentTemplateHandler(ctx *gin.Context) {
+	var req experimentTemplateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Invalid auth payload")))
+		return
+	}
+
+	template := req.toExperimentTemplate()
+	template.CreatedBy = payload.UserID
+
+	template, err := s.repo.ExperimentTemplateRepository.CreateExperimentTemplate(ctx, template)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": template})
+}
+
+// saveExperimentTemplateHandler saves the feedstock and exposure conditions
+// of an experiment as a template.
+func (s *Server) saveExperimentTemplateHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	var req saveExperimentTemplateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Invalid auth payload")))
+		return
+	}
+
+	experiment, err := s.repo.ExperimentRepository.GetExperimentByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	template, err := s.repo.ExperimentTemplateRepository.CreateExperimentTemplate(ctx, &repository.ExperimentTemplate{
+		Name:               req.Name,
+		Description:        req.Description,
+		MaterialFeedstock:  experiment.MaterialFeedstock,
+		ExposureConditions: experiment.ExposureConditions,
+		SourceExperimentID: &experiment.ID,
+		CreatedBy:          payload.UserID,
+	})
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": template})
+}
+
+func (s *Server) listExperimentTemplatesHandler(ctx *gin.Context) {
+	var search *string
+	if value := ctx.Query("search"); value != "" {
+		search = &value
+	}
+
+	templates, err := s.repo.ExperimentTemplateRepository.ListExperimentTemplates(ctx, search)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+func (s *Server) getExperimentTemplateHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid experiment template ID")))
+		return
+	}
+
+	template, err := s.repo.ExperimentTemplateRepository.GetExperimentTemplateByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+func (s *Server) updateExperimentTemplateHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid experiment template ID")))
+		return
+	}
+
+	var req experimentTemplateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	template := req.toExperimentTemplate()
+	template.ID = id
+
+	template, err = s.repo.ExperimentTemplateRepository.UpdateExperimentTemplate(ctx, template)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+func (s *Server) deleteExperimentTemplateHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid experiment template ID")))
+		return
+	}
+
+	if err := s.repo.ExperimentTemplateRepository.DeleteExperimentTemplate(ctx, id); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "experiment template deleted successfully"})
+}
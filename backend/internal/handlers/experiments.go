@@ -19,31 +19,113 @@ type createExperimentReq struct {
 	TimeStart string `json:"timeStart" binding:"required"`
 	TimeEnd   string `json:"timeEnd" binding:"required"`
 
-	// Material Feedstock (flattened)
-	MixDesign        string `json:"mixDesign"`
-	Cement           string `json:"cement"`
-	FineAggregate    string `json:"fineAggregate"`
-	CoarseAggregate  string `json:"coarseAggregate"`
-	Water            string `json:"water"`
-	WaterCementRatio string `json:"waterCementRatio"`
-	BlockSizeLength  string `json:"blockSizeLength"`
-	BlockSizeWidth   string `json:"blockSizeWidth"`
-	BlockSizeHeight  string `json:"blockSizeHeight"`
-
-	// Exposure Conditions (flattened)
-	Co2Form           string `json:"co2Form"`
-	Co2Mass           string `json:"co2Mass"`
-	InjectionPressure string `json:"injectionPressure"`
-	HeadSpace         string `json:"headSpace"`
-	ReactionTime      string `json:"reactionTime"`
+	// TemplateID pre-fills the conditions left out from an experiment
+	// template; those that are set win. It is only read on create.
+	TemplateID *uint32 `json:"templateId"`
+	experimentConditionsReq
 
 	// Analytical tests can stay optional for now
-	AnalyticalTests []struct {
-		Name     string `json:"name" binding:"required"`
-		SampleID string `json:"sampleId" binding:"required"`
-		Date     string `json:"date" binding:"required"`
-		PdfUrl   string `json:"pdfUrl" binding:"required"`
-	} `json:"analyticalTests"`
+	AnalyticalTests []analyticalTestReq `json:"analyticalTests"`
+}
+
+// experimentConditionsReq holds the flattened feedstock and exposure
+// conditions shared by experiments and experiment templates. Quantities take
+// {"value", "unit"}, a number in the default unit or a string such as "5 kg".
+type experimentConditionsReq struct {
+	// Material Feedstock
+	MixDesign        string               `json:"mixDesign"`
+	Cement           *repository.Quantity `json:"cement"`
+	FineAggregate    *repository.Quantity `json:"fineAggregate"`
+	CoarseAggregate  *repository.Quantity `json:"coarseAggregate"`
+	Water            *repository.Quantity `json:"water"`
+	WaterCementRatio *repository.Quantity `json:"waterCementRatio"`
+	BlockSizeLength  *repository.Quantity `json:"blockSizeLength"`
+	BlockSizeWidth   *repository.Quantity `json:"blockSizeWidth"`
+	BlockSizeHeight  *repository.Quantity `json:"blockSizeHeight"`
+
+	// Exposure Conditions
+	Co2Form           string               `json:"co2Form"`
+	Co2Mass           *repository.Quantity `json:"co2Mass"`
+	InjectionPressure *repository.Quantity `json:"injectionPressure"`
+	HeadSpace         *repository.Quantity `json:"headSpace"`
+	ReactionTime      *repository.Quantity `json:"reactionTime"`
+}
+
+func (req *experimentConditionsReq) materialFeedstock() repository.MaterialFeedstock {
+	return repository.MaterialFeedstock{
+		MixDesign:        req.MixDesign,
+		Cement:           req.Cement,
+		FineAggregate:    req.FineAggregate,
+		CoarseAggregate:  req.CoarseAggregate,
+		Water:            req.Water,
+		WaterCementRatio: req.WaterCementRatio,
+		BlockSizeLength:  req.BlockSizeLength,
+		BlockSizeWidth:   req.BlockSizeWidth,
+		BlockSizeHeight:  req.BlockSizeHeight,
+	}
+}
+
+func (req *experimentConditionsReq) exposureConditions() repository.ExposureConditions {
+	return repository.ExposureConditions{
+		Co2Form:           req.Co2Form,
+		Co2Mass:           req.Co2Mass,
+		InjectionPressure: req.InjectionPressure,
+		HeadSpace:         req.HeadSpace,
+		ReactionTime:      req.ReactionTime,
+	}
+}
+
+// analyticalTestReq references its report by the ID of an uploaded PDF, see
+// uploadFileHandler. PdfUrl is only accepted back unchanged for a test that
+// still has a legacy report link; leaving it out drops the link.
+type analyticalTestReq struct {
+	Name     string  `json:"name" binding:"required"`
+	SampleID string  `json:"sampleId" binding:"required"`
+	Date     string  `json:"date" binding:"required"`
+	FileID   *uint32 `json:"fileId"`
+	PdfUrl   string  `json:"pdfUrl"`
+}
+
+// analyticalTests checks the tests of a request against the uploaded files
+// and the legacy links already recorded on the experiment.
+func (s *Server) analyticalTests(ctx *gin.Context, tests []analyticalTestReq, existing []repository.AnalyticalTests) ([]repository.AnalyticalTests, error) {
+	legacyUrls := map[string]bool{}
+	for _, test := range existing {
+		if test.PdfUrl != "" {
+			legacyUrls[test.PdfUrl] = true
+		}
+	}
+
+	analyticalTests := make([]repository.AnalyticalTests, len(tests))
+	for i, at := range tests {
+		atDate, err := pkg.StrToDate(at.Date)
+		if err != nil {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid analytical test date format: %v should be 2006-01-02", err)
+		}
+
+		test := repository.AnalyticalTests{
+			Name:     at.Name,
+			SampleID: at.SampleID,
+			Date:     atDate,
+			FileID:   at.FileID,
+		}
+
+		switch {
+		case at.FileID != nil:
+			if err := s.attachedFile(ctx, *at.FileID); err != nil {
+				return nil, err
+			}
+		case at.PdfUrl != "":
+			if !legacyUrls[at.PdfUrl] {
+				return nil, pkg.Errorf(pkg.INVALID_ERROR, "analytical test %q must reference an uploaded file by fileId", at.Name)
+			}
+			test.PdfUrl = at.PdfUrl
+		}
+
+		analyticalTests[i] = test
+	}
+
+	return analyticalTests, nil
 }
 
 func (s *Server) createExperiment(ctx *gin.Context) {
@@ -61,46 +143,35 @@ func (s *Server) createExperiment(ctx *gin.Context) {
 
 	// Build Experiment object
 	experiment := &repository.Experiment{
-		BatchID:   req.BatchID,
-		ReactorID: req.ReactorID,
-		Operator:  req.Operator,
-		Date:      date,
-		BlockID:   req.BlockID,
-		TimeStart: req.TimeStart,
-		TimeEnd:   req.TimeEnd,
-		MaterialFeedstock: repository.MaterialFeedstock{
-			MixDesign:        req.MixDesign,
-			Cement:           req.Cement,
-			FineAggregate:    req.FineAggregate,
-			CoarseAggregate:  req.CoarseAggregate,
-			Water:            req.Water,
-			WaterCementRatio: req.WaterCementRatio,
-			BlockSizeLength:  req.BlockSizeLength,
-			BlockSizeWidth:   req.BlockSizeWidth,
-			BlockSizeHeight:  req.BlockSizeHeight,
-		},
-		ExposureConditions: repository.ExposureConditions{
-			Co2Form:           req.Co2Form,
-			Co2Mass:           req.Co2Mass,
-			InjectionPressure: req.InjectionPressure,
-			HeadSpace:         req.HeadSpace,
-			ReactionTime:      req.ReactionTime,
-		},
-		AnalyticalTests: make([]repository.AnalyticalTests, len(req.AnalyticalTests)),
+		BatchID:            req.BatchID,
+		ReactorID:          req.ReactorID,
+		Operator:           req.Operator,
+		Date:               date,
+		BlockID:            req.BlockID,
+		TimeStart:          req.TimeStart,
+		TimeEnd:            req.TimeEnd,
+		MaterialFeedstock:  req.materialFeedstock(),
+		ExposureConditions: req.exposureConditions(),
 	}
 
-	for i, at := range req.AnalyticalTests {
-		atDate, err := pkg.StrToDate(at.Date)
+	if req.TemplateID != nil {
+		template, err := s.repo.ExperimentTemplateRepository.GetExperimentTemplateByID(ctx, *req.TemplateID)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid analytical test date format: %v should be 2006-01-02", err)))
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
 			return
 		}
-		experiment.AnalyticalTests[i] = repository.AnalyticalTests{
-			Name:     at.Name,
-			SampleID: at.SampleID,
-			Date:     atDate,
-			PdfUrl:   at.PdfUrl,
-		}
+		experiment.Prefill(template.MaterialFeedstock, template.ExposureConditions)
+	}
+
+	if _, err := experiment.NormaliseParameters(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	experiment.AnalyticalTests, err = s.analyticalTests(ctx, req.AnalyticalTests, nil)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
 	}
 
 	createdExperiment, err := s.repo.ExperimentRepository.CreateExperiment(ctx, experiment)
@@ -149,47 +220,33 @@ func (s *Server) updateExperiment(ctx *gin.Context) {
 
 	// Build Experiment object
 	experiment := &repository.Experiment{
-		ID:        id,
-		BatchID:   req.BatchID,
-		ReactorID: req.ReactorID,
-		Operator:  req.Operator,
-		Date:      date,
-		BlockID:   req.BlockID,
-		TimeStart: req.TimeStart,
-		TimeEnd:   req.TimeEnd,
-		MaterialFeedstock: repository.MaterialFeedstock{
-			MixDesign:        req.MixDesign,
-			Cement:           req.Cement,
-			FineAggregate:    req.FineAggregate,
-			CoarseAggregate:  req.CoarseAggregate,
-			Water:            req.Water,
-			WaterCementRatio: req.WaterCementRatio,
-			BlockSizeLength:  req.BlockSizeLength,
-			BlockSizeWidth:   req.BlockSizeWidth,
-			BlockSizeHeight:  req.BlockSizeHeight,
-		},
-		ExposureConditions: repository.ExposureConditions{
-			Co2Form:           req.Co2Form,
-			Co2Mass:           req.Co2Mass,
-			InjectionPressure: req.InjectionPressure,
-			HeadSpace:         req.HeadSpace,
-			ReactionTime:      req.ReactionTime,
-		},
-		AnalyticalTests: make([]repository.AnalyticalTests, len(req.AnalyticalTests)),
+		ID:                 id,
+		BatchID:            req.BatchID,
+		ReactorID:          req.ReactorID,
+		Operator:           req.Operator,
+		Date:               date,
+		BlockID:            req.BlockID,
+		TimeStart:          req.TimeStart,
+		TimeEnd:            req.TimeEnd,
+		MaterialFeedstock:  req.materialFeedstock(),
+		ExposureConditions: req.exposureConditions(),
 	}
 
-	for i, at := range req.AnalyticalTests {
-		atDate, err := pkg.StrToDate(at.Date)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid analytical test date format: %v should be 2006-01-02", err)))
-			return
-		}
-		experiment.AnalyticalTests[i] = repository.AnalyticalTests{
-			Name:     at.Name,
-			SampleID: at.SampleID,
-			Date:     atDate,
-			PdfUrl:   at.PdfUrl,
-		}
+	if _, err := experiment.NormaliseParameters(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	existing, err := s.repo.ExperimentRepository.GetExperimentByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	experiment.AnalyticalTests, err = s.analyticalTests(ctx, req.AnalyticalTests, existing.AnalyticalTests)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
 	}
 
 	err = s.repo.ExperimentRepository.UpdateExperiment(ctx, experiment)
@@ -241,6 +298,7 @@ func (s *Server) listExperiments(ctx *gin.Context) {
 		Search:    nil,
 		ReactorID: nil,
 		Date:      nil,
+		Status:    nil,
 	}
 	if search := ctx.Query("search"); search != "" {
 		filter.Search = &search
@@ -264,6 +322,26 @@ func (s *Server) listExperiments(ctx *gin.Context) {
 		filter.Date = &date
 	}
 
+	if status := ctx.Query("status"); status != "" {
+		if !repository.IsExperimentStatus(status) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid status %q, must be one of planned, running, completed, aborted", status)))
+
+			return
+		}
+		filter.Status = &status
+	}
+
+	// filter=co2Mass>=5kg, repeated for several ranges
+	for _, expression := range ctx.QueryArray("filter") {
+		parameter, err := repository.ParseParameterFilter(expression)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+
+			return
+		}
+		filter.Parameters = append(filter.Parameters, parameter)
+	}
+
 	experiments, pagination, err := s.repo.ExperimentRepository.ListExperiments(ctx, &filter)
 	if err != nil {
 		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
@@ -272,3 +350,142 @@ func (s *Server) listExperiments(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": experiments, "pagination": pagination})
 }
+
+func (s *Server) startExperiment(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	experiment, err := s.repo.ExperimentRepository.StartExperiment(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": experiment})
+}
+
+// stopExperimentReq sets how a running experiment ends, completed unless
+// aborted is set.
+type stopExperimentReq struct {
+	Aborted bool `json:"aborted"`
+}
+
+func (s *Server) stopExperiment(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	// the body is optional
+	var req stopExperimentReq
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+			return
+		}
+	}
+
+	status := repository.ExperimentStatusCompleted
+	if req.Aborted {
+		status = repository.ExperimentStatusAborted
+	}
+
+	experiment, err := s.repo.ExperimentRepository.StopExperiment(ctx, id, status)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": experiment})
+}
+
+// cloneExperimentReq creates a planned copy of an experiment under new batch
+// and block IDs. Fields left out keep the values of the cloned experiment,
+// except Date which defaults to today. Analytical tests and run data belong
+// to the original run and are not copied.
+type cloneExperimentReq struct {
+	BatchID   string  `json:"batchId" binding:"required"`
+	BlockID   string  `json:"blockId" binding:"required"`
+	ReactorID *uint32 `json:"reactorId"`
+	Operator  string  `json:"operator"`
+	Date      string  `json:"date"`
+	TimeStart string  `json:"timeStart"`
+	TimeEnd   string  `json:"timeEnd"`
+
+	experimentConditionsReq
+}
+
+func (s *Server) cloneExperiment(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	var req cloneExperimentReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	source, err := s.repo.ExperimentRepository.GetExperimentByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	if req.BatchID == source.BatchID && req.BlockID == source.BlockID {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "a clone needs a new batch or block ID")))
+		return
+	}
+
+	// an empty date is today
+	date, err := pkg.StrToDate(req.Date)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid date format: %v should be 2006-01-02", err)))
+		return
+	}
+
+	experiment := &repository.Experiment{
+		BatchID:            req.BatchID,
+		ReactorID:          source.ReactorID,
+		Operator:           source.Operator,
+		Date:               date,
+		BlockID:            req.BlockID,
+		TimeStart:          source.TimeStart,
+		TimeEnd:            source.TimeEnd,
+		MaterialFeedstock:  req.materialFeedstock(),
+		ExposureConditions: req.exposureConditions(),
+		AnalyticalTests:    []repository.AnalyticalTests{},
+	}
+	if req.ReactorID != nil {
+		experiment.ReactorID = *req.ReactorID
+	}
+	if req.Operator != "" {
+		experiment.Operator = req.Operator
+	}
+	if req.TimeStart != "" {
+		experiment.TimeStart = req.TimeStart
+	}
+	if req.TimeEnd != "" {
+		experiment.TimeEnd = req.TimeEnd
+	}
+	experiment.Prefill(source.MaterialFeedstock, source.ExposureConditions)
+
+	if _, err := experiment.NormaliseParameters(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	createdExperiment, err := s.repo.ExperimentRepository.CreateExperiment(ctx, experiment)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": createdExperiment})
+}
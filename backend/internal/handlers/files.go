@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/storage"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// multipartOverhead allows for the multipart headers and other form
+	// fields on top of the file itself.
+	multipartOverhead = 1 << 20
+
+	// sniffBytes is what http.DetectContentType looks at.
+	sniffBytes = 512
+
+	// fileWriteTimeout replaces the server write timeout while a file is
+	// streamed, so large files reach clients on slow links.
+	fileWriteTimeout = 5 * time.Minute
+)
+
+// uploadFileHandler stores the "file" field of a multipart form. The content
+// type is sniffed from the content rather than trusted from the client, and a
+// hex SHA-256 in the optional "checksum" field is checked against the upload.
+func (s *Server) uploadFileHandler(ctx *gin.Context) {
+	payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Invalid auth payload")))
+		return
+	}
+
+	maxSize := s.config.STORAGE_MAX_UPLOAD_SIZE
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+multipartOverhead)
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "file cannot be larger than %d bytes", maxSize)))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "file is required: %v", err)))
+		return
+	}
+	if header.Size > maxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "file cannot be larger than %d bytes", maxSize)))
+		return
+	}
+	if header.Size == 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "file cannot be empty")))
+		return
+	}
+
+	content, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to read upload: %v", err)))
+		return
+	}
+	defer content.Close()
+
+	head := make([]byte, sniffBytes)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to read upload: %v", err)))
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if !slices.Contains(s.config.STORAGE_ALLOWED_CONTENT_TYPES, contentType) {
+		ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "file type %s is not allowed, must be one of %s", contentType, strings.Join(s.config.STORAGE_ALLOWED_CONTENT_TYPES, ", "))))
+		return
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to read upload: %v", err)))
+		return
+	}
+
+	key, err := storage.NewKey(time.Now())
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	hash := sha256.New()
+	if err := s.storage.Put(ctx, key, io.TeeReader(content, hash), header.Size, contentType); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	if expected := ctx.PostForm("checksum"); expected != "" && !strings.EqualFold(expected, checksum) {
+		s.deleteStoredFile(ctx, key)
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "checksum mismatch, upload has sha256 %s", checksum)))
+		return
+	}
+
+	file, err := s.repo.FileRepository.CreateFile(ctx, &repository.File{
+		StorageKey:  key,
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		Checksum:    checksum,
+		CreatedBy:   payload.UserID,
+	})
+	if err != nil {
+		s.deleteStoredFile(ctx, key)
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/api/v1/files/%d", file.ID))
+	ctx.JSON(http.StatusCreated, gin.H{"data": file})
+}
+
+func (s *Server) getFileHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid file ID")))
+		return
+	}
+
+	file, err := s.repo.FileRepository.GetFileByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": file})
+}
+
+// downloadFileHandler streams a file to an authenticated user.
+func (s *Server) downloadFileHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid file ID")))
+		return
+	}
+
+	s.serveFile(ctx, id)
+}
+
+// fileURLHandler returns a signed link to a file, for use where the session
+// cannot be sent along such as links opened in a new tab or embedded in
+// emails.
+func (s *Server) fileURLHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid file ID")))
+		return
+	}
+
+	file, err := s.repo.FileRepository.GetFileByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	expiry := s.config.STORAGE_SIGNED_URL_TTL
+	url, err := s.storage.SignedURL(ctx, file, expiry)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"url":       url,
+		"expiresAt": time.Now().Add(expiry).UTC(),
+	}})
+}
+
+// signedDownloadFileHandler serves the signed links of the local backend. It
+// is public: the signature authorises the download until it expires.
+func (s *Server) signedDownloadFileHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid file ID")))
+		return
+	}
+
+	expires, err := strconv.ParseInt(ctx.Query("expires"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "expires query parameter is required")))
+		return
+	}
+
+	if err := storage.VerifyDownload(s.config.TOKEN_SYMMETRIC_KEY, id, expires, ctx.Query("signature")); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	s.serveFile(ctx, id)
+}
+
+func (s *Server) serveFile(ctx *gin.Context, id uint32) {
+	file, err := s.repo.FileRepository.GetFileByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	content, err := s.storage.Get(ctx, file.StorageKey)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+	defer content.Close()
+
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Now().Add(fileWriteTimeout)); err != nil {
+		log.Printf("failed to extend file write deadline: %v", err)
+	}
+
+	ctx.DataFromReader(http.StatusOK, file.Size, file.ContentType, content, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("attachment; filename=%q", file.FileName),
+		"X-Content-Sha256":       file.Checksum,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// deleteStoredFile removes an upload that could not be recorded; a failure is
+// only logged as the request has already failed.
+func (s *Server) deleteStoredFile(ctx *gin.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		log.Printf("failed to delete stored file %s: %v", key, err)
+	}
+}
+
+// attachedFile checks that a file referenced by a reactor or experiment
+// exists and is a PDF.
+func (s *Server) attachedFile(ctx *gin.Context, id uint32) error {
+	file, err := s.repo.FileRepository.GetFileByID(ctx, id)
+	if err != nil {
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			return pkg.Errorf(pkg.INVALID_ERROR, "file with id %d not found", id)
+		}
+		return err
+	}
+
+	if file.ContentType != repository.FileContentTypePDF {
+		return pkg.Errorf(pkg.INVALID_ERROR, "file %d is %s, expected a PDF", id, file.ContentType)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Edwin9301/Zen/backend/internal/repository"
 	"github.com/Edwin9301/Zen/backend/pkg"
 	"github.com/gin-gonic/gin"
 )
@@ -12,6 +13,9 @@ const (
 	authorizationHeaderKey        = "Authorization"
 	authorizationHeaderBearerType = "bearer"
 	authorizationPayloadKey       = "payload"
+
+	deviceAPIKeyHeaderKey = "X-API-Key"
+	deviceIDPayloadKey    = "device_id"
 )
 
 func authMiddleware(maker pkg.JWTMaker) gin.HandlerFunc {
@@ -68,6 +72,31 @@ func authMiddleware(maker pkg.JWTMaker) gin.HandlerFunc {
 	}
 }
 
+func deviceAPIKeyMiddleware(repo repository.DeviceRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		apiKey := ctx.GetHeader(deviceAPIKeyHeaderKey)
+		if apiKey == "" {
+			ctx.AbortWithStatusJSON(
+				http.StatusUnauthorized,
+				errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "No API key was passed")),
+			)
+
+			return
+		}
+
+		deviceID, err := repo.AuthenticateDeviceAPIKey(ctx, apiKey)
+		if err != nil {
+			ctx.AbortWithStatusJSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+
+			return
+		}
+
+		ctx.Set(deviceIDPayloadKey, deviceID)
+
+		ctx.Next()
+	}
+}
+
 func adminOnlyMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		payload, exists := ctx.Get(authorizationPayloadKey)
@@ -112,7 +141,7 @@ func CORSmiddleware(frontendUrls []string) gin.HandlerFunc {
 		}
 
 		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "GET, PUT, POST, PATCH, DELETE, OPTIONS")
-		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-API-Key")
 		ctx.Writer.Header().Set("Access-Control-Allow-Credentials", "false")
 		ctx.Writer.Header().Set("Access-Control-Max-Age", "86400")
 		ctx.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
@@ -12,7 +12,8 @@ type createReactorReq struct {
 	Name    string `json:"name" binding:"required"`
 	Status  string `json:"status" binding:"required,oneof=active inactive maintenance"`
 	Pathway string `json:"pathway"`
-	PdfUrl  string `json:"pdfUrl"`
+	// PdfFileID is an uploaded PDF, see uploadFileHandler
+	PdfFileID *uint32 `json:"pdfFileId"`
 }
 
 func (s *Server) createReactor(ctx *gin.Context) {
@@ -22,11 +23,18 @@ func (s *Server) createReactor(ctx *gin.Context) {
 		return
 	}
 
+	if req.PdfFileID != nil {
+		if err := s.attachedFile(ctx, *req.PdfFileID); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+			return
+		}
+	}
+
 	reactor := &repository.Reactor{
-		Name:    req.Name,
-		Status:  req.Status,
-		Pathway: req.Pathway,
-		PdfUrl:  req.PdfUrl,
+		Name:      req.Name,
+		Status:    req.Status,
+		Pathway:   req.Pathway,
+		PdfFileID: req.PdfFileID,
 	}
 
 	createdReactor, err := s.repo.ReactorRepository.CreateReactor(ctx, reactor)
@@ -68,6 +76,13 @@ func (s *Server) updateReactor(ctx *gin.Context) {
 	}
 	req.ID = id
 
+	if req.PdfFileID != nil && *req.PdfFileID != 0 {
+		if err := s.attachedFile(ctx, *req.PdfFileID); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+			return
+		}
+	}
+
 	if err := s.repo.ReactorRepository.UpdateReactor(ctx, &req); err != nil {
 		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
 		return
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Edwin9301/Zen/backend/internal/ingest"
 	"github.com/Edwin9301/Zen/backend/internal/repository"
 	"github.com/Edwin9301/Zen/backend/pkg"
 	"github.com/gin-gonic/gin"
@@ -22,20 +28,162 @@ func (s *Server) createSensorReadingHandler(ctx *gin.Context) {
 		return
 	}
 
-	reading := &repository.Reading{
-		DeviceID: id,
-		Payload:  req,
+	if !authorizeDevice(ctx, id) {
+		return
 	}
 
-	createdReading, err := s.repo.DeviceRepository.AddReading(ctx, reading)
+	createdReading, err := s.ingest.IngestReading(ctx, id, req)
 	if err != nil {
 		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
 		return
 	}
 
+	if createdReading.Duplicate {
+		ctx.JSON(http.StatusOK, gin.H{"data": createdReading})
+		return
+	}
+
 	ctx.JSON(http.StatusCreated, gin.H{"data": createdReading})
 }
 
+const (
+	maxReadingBatchSize  = 5000
+	maxReadingBatchBytes = 16 << 20
+
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// createSensorReadingsBatchHandler ingests readings buffered by a gateway. The
+// body is either a JSON array of payloads or NDJSON with one payload per line,
+// each carrying its own device-supplied "timestamp" and optional "message_id".
+func (s *Server) createSensorReadingsBatchHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	if !authorizeDevice(ctx, id) {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxReadingBatchBytes))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "failed to read request body: %s", err.Error())))
+		return
+	}
+
+	items, err := splitReadingBatch(body, strings.HasPrefix(ctx.ContentType(), ndjsonContentType))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if len(items) == 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "batch must contain at least one reading")))
+		return
+	}
+
+	if len(items) > maxReadingBatchSize {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "batch cannot contain more than %d readings", maxReadingBatchSize)))
+		return
+	}
+
+	rejections := []repository.ReadingRejection{}
+	readings := make([]*repository.Reading, 0, len(items))
+	// batch index of every reading passed on to the repository
+	indexes := make([]int, 0, len(items))
+
+	for idx, item := range items {
+		var payload map[string]any
+		if err := json.Unmarshal(item, &payload); err != nil || payload == nil {
+			rejections = append(rejections, repository.ReadingRejection{Index: idx, Reason: "reading must be a JSON object"})
+			continue
+		}
+
+		timestamp, err := ingest.ReadingTimestamp(payload)
+		if err != nil {
+			rejections = append(rejections, repository.ReadingRejection{Index: idx, Reason: pkg.ErrorMessage(err)})
+			continue
+		}
+
+		messageID, err := ingest.ReadingMessageID(payload)
+		if err != nil {
+			rejections = append(rejections, repository.ReadingRejection{Index: idx, Reason: pkg.ErrorMessage(err)})
+			continue
+		}
+
+		readings = append(readings, &repository.Reading{
+			DeviceID:  id,
+			Payload:   payload,
+			Timestamp: timestamp,
+			MessageID: messageID,
+		})
+		indexes = append(indexes, idx)
+	}
+
+	result := &repository.ReadingBatchResult{Rejections: []repository.ReadingRejection{}}
+	if len(readings) > 0 {
+		result, err = s.ingest.IngestReadings(ctx, id, readings)
+		if err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+			return
+		}
+
+		for i := range result.Rejections {
+			result.Rejections[i].Index = indexes[result.Rejections[i].Index]
+		}
+	}
+
+	result.Rejections = append(rejections, result.Rejections...)
+	result.Rejected = len(result.Rejections)
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": result})
+}
+
+// splitReadingBatch splits a batch body into the raw JSON of each reading.
+func splitReadingBatch(body []byte, ndjson bool) ([]json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(body)
+
+	if !ndjson && len(trimmed) > 0 && trimmed[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid JSON array: %s", err.Error())
+		}
+
+		return items, nil
+	}
+
+	items := []json.RawMessage{}
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxReadingBatchBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		items = append(items, json.RawMessage(bytes.Clone(line)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid NDJSON body: %s", err.Error())
+	}
+
+	return items, nil
+}
+
+// authorizeDevice checks that the API key used for the request was issued for
+// the device in the path, writing a forbidden response when it was not.
+func authorizeDevice(ctx *gin.Context, deviceID uint32) bool {
+	authDeviceID, ok := ctx.Get(deviceIDPayloadKey)
+	if !ok || authDeviceID.(uint32) != deviceID {
+		ctx.JSON(http.StatusForbidden, errorResponse(pkg.Errorf(pkg.FORBIDDEN_ERROR, "api key is not valid for device %d", deviceID)))
+		return false
+	}
+
+	return true
+}
+
 func (s *Server) getSensorReadingByIDHandler(ctx *gin.Context) {
 	id, err := pkg.StrToUint32(ctx.Param("id"))
 	if err != nil {
@@ -63,37 +211,47 @@ func (s *Server) listSensorReadingsHandler(ctx *gin.Context) {
 
 	switch listBy {
 	case "device":
-		pageNoStr := ctx.DefaultQuery("page", "1")
-		pageNo, err := pkg.StrToInt64(pageNoStr)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
-
+		// device readings used to be paged by number and listed under
+		// "pagination"; fail loudly rather than return the first page again
+		if _, ok := ctx.GetQuery("page"); ok {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "page is no longer supported when listing by device, follow the page.next and page.prev cursors instead")))
 			return
 		}
 
-		pageSizeStr := ctx.DefaultQuery("limit", "10")
-		pageSize, err := pkg.StrToInt64(pageSizeStr)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, err.Error())))
-
+		limit, err := pkg.StrToInt64(ctx.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > maxReadingPageSize {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "limit must be between 1 and %d", maxReadingPageSize)))
 			return
 		}
 
 		filter := &repository.ReadingFilter{
 			DeviceID: deviceId,
-			Pagination: &pkg.Pagination{
-				Page:     uint32(pageNo),
-				PageSize: uint32(pageSize),
-			},
+			Limit:    uint32(limit),
+			Count:    ctx.DefaultQuery("count", repository.CountNone),
+		}
+
+		switch filter.Count {
+		case repository.CountNone, repository.CountEstimate, repository.CountExact:
+		default:
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "count must be one of none, estimate, exact")))
+			return
+		}
+
+		if cursorStr := ctx.Query("cursor"); cursorStr != "" {
+			filter.Cursor, err = pkg.DecodeCursor(cursorStr)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, errorResponse(err))
+				return
+			}
 		}
 
-		readings, pagination, err := s.repo.DeviceRepository.ListReadingByDevice(ctx, filter)
+		readings, page, err := s.repo.DeviceRepository.ListReadingByDevice(ctx, filter)
 		if err != nil {
 			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
 			return
 		}
 
-		ctx.JSON(http.StatusOK, gin.H{"data": readings, "pagination": pagination})
+		ctx.JSON(http.StatusOK, gin.H{"data": readings, "page": page})
 
 		return
 	case "timeslot":
@@ -116,6 +274,7 @@ func (s *Server) listSensorReadingsHandler(ctx *gin.Context) {
 			return
 		}
 
+		endTime = inclusiveEnd(endTime)
 		filter := &repository.ReadingFilter{
 			DeviceID: deviceId,
 			Start:    &startTime,
@@ -180,3 +339,105 @@ func (s *Server) listSensorReadingsHandler(ctx *gin.Context) {
 		return
 	}
 }
+
+// inclusiveEnd turns the end of a range that an endpoint has always included
+// into the exclusive end the repository takes. Timestamps are stored to the
+// microsecond, so nothing falls between the two.
+func inclusiveEnd(end time.Time) time.Time {
+	return end.Add(time.Microsecond)
+}
+
+// maxReadingPageSize bounds the limit of one page of device readings.
+const maxReadingPageSize = 1000
+
+// maxAggregateBuckets bounds how many buckets one aggregate request can span.
+const maxAggregateBuckets = 10000
+
+func (s *Server) aggregateSensorReadingsHandler(ctx *gin.Context) {
+	deviceId, err := pkg.StrToUint32(ctx.Query("device_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "device_id query parameter is required")))
+		return
+	}
+
+	startStr := ctx.Query("start")
+	endStr := ctx.Query("end")
+	if startStr == "" || endStr == "" {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "start and end query parameters are required")))
+		return
+	}
+
+	startTime, err := pkg.StrToTime(startStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid start time format")))
+		return
+	}
+
+	endTime, err := pkg.StrToTime(endStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid end time format")))
+		return
+	}
+
+	bucketStr := ctx.DefaultQuery("bucket", "5m")
+	bucket, ok := repository.AggregateBuckets[bucketStr]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid bucket %q, must be one of 1m, 5m, 1h, 1d", bucketStr)))
+		return
+	}
+
+	if endTime.Sub(startTime)/bucket > maxAggregateBuckets {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "time range spans more than %d %s buckets, use a larger bucket", maxAggregateBuckets, bucketStr)))
+		return
+	}
+
+	functions, err := parseAggregateFunctions(ctx.DefaultQuery("fns", repository.AggregateAvg))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	filter := &repository.AggregateFilter{
+		DeviceID:  deviceId,
+		Start:     startTime,
+		End:       endTime,
+		Bucket:    bucket,
+		Keys:      splitQueryList(ctx.Query("keys")),
+		Functions: functions,
+	}
+
+	buckets, err := s.repo.DeviceRepository.AggregateReadings(ctx, filter)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": buckets})
+}
+
+// parseAggregateFunctions splits and validates a comma separated list of
+// aggregate functions.
+func parseAggregateFunctions(value string) ([]string, error) {
+	functions := splitQueryList(value)
+	for _, function := range functions {
+		switch function {
+		case repository.AggregateAvg, repository.AggregateMin, repository.AggregateMax, repository.AggregateCount, repository.AggregateLast:
+		default:
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid aggregate function %q, must be one of avg, min, max, count, last", function)
+		}
+	}
+
+	return functions, nil
+}
+
+// splitQueryList splits a comma separated query value, dropping empty entries.
+func splitQueryList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// downloadTokenBytes is the entropy of report download tokens.
+	downloadTokenBytes = 32
+
+	// maxListedReportJobs bounds the list of a user's recent jobs.
+	maxListedReportJobs = 50
+)
+
+// createReadingReportJobHandler queues a readings workbook. The body is the
+// same as for the synchronous report.
+func (s *Server) createReadingReportJobHandler(ctx *gin.Context) {
+	var req generateReadingReportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	options, err := req.options()
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	s.createReportJob(ctx, repository.ReportJobKindReadingsXLSX, options)
+}
+
+func (s *Server) createExperimentReportJobHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	if _, err := s.repo.ExperimentRepository.GetExperimentByID(ctx, id); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	s.createReportJob(ctx, repository.ReportJobKindExperimentPDF, services.ExperimentReportOptions{ExperimentID: id})
+}
+
+// createReportJob stores a queued job for the current user, who is emailed
+// when it finishes.
+func (s *Server) createReportJob(ctx *gin.Context, kind string, options any) {
+	payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Invalid auth payload")))
+		return
+	}
+
+	params, err := json.Marshal(options)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to encode report params: %v", err)))
+		return
+	}
+
+	token, err := pkg.GenerateToken(downloadTokenBytes)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	job, err := s.repo.ReportJobRepository.CreateReportJob(ctx, &repository.ReportJob{
+		Kind:          kind,
+		Params:        params,
+		CreatedBy:     payload.UserID,
+		NotifyEmail:   payload.Email,
+		DownloadToken: token,
+	})
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/api/v1/report-jobs/%d", job.ID))
+	ctx.JSON(http.StatusAccepted, gin.H{"data": job})
+}
+
+func (s *Server) listReportJobsHandler(ctx *gin.Context) {
+	payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Invalid auth payload")))
+		return
+	}
+
+	jobs, err := s.repo.ReportJobRepository.ListReportJobsByUser(ctx, payload.UserID, maxListedReportJobs)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	for _, job := range jobs {
+		s.setReportJobDownloadURL(job)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": jobs})
+}
+
+// getReportJobHandler shows the status and progress of a job, with the
+// download link once it has succeeded. Only its creator and admins see it.
+func (s *Server) getReportJobHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Invalid auth payload")))
+		return
+	}
+
+	job, err := s.repo.ReportJobRepository.GetReportJobByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	if job.CreatedBy != payload.UserID && strings.ToLower(payload.Role) != "admin" {
+		ctx.JSON(http.StatusNotFound, errorResponse(pkg.Errorf(pkg.NOT_FOUND_ERROR, "report job with id %d not found", id)))
+		return
+	}
+
+	s.setReportJobDownloadURL(job)
+
+	ctx.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// downloadReportJobHandler serves the file of a finished job. It is public:
+// the token from the job or the email authorises the download.
+func (s *Server) downloadReportJobHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "token query parameter is required")))
+		return
+	}
+
+	file, err := s.repo.ReportJobRepository.GetReportJobFile(ctx, id, token)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Now().Add(fileWriteTimeout)); err != nil {
+		log.Printf("failed to extend report job write deadline: %v", err)
+	}
+
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	ctx.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+func (s *Server) setReportJobDownloadURL(job *repository.ReportJob) {
+	if job.Status == repository.ReportJobStatusSucceeded {
+		job.DownloadURL = services.ReportJobDownloadURL(s.config.REPORT_DOWNLOAD_BASE_URL, job)
+	}
+}
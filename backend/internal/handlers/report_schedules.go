@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// reportScheduleRequest defines a scheduled report. CronExpression uses the
+// standard five fields and is evaluated in Timezone (UTC by default); each
+// run covers WindowSeconds up to its scheduled time. Without a reactor or
+// devices every device is included.
+type reportScheduleRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	CronExpression string   `json:"cronExpression" binding:"required"`
+	Timezone       string   `json:"timezone"`
+	Kind           string   `json:"kind" binding:"required"`
+	ReactorID      *uint32  `json:"reactorId"`
+	DeviceIDs      []uint32 `json:"deviceIds"`
+	WindowSeconds  int64    `json:"windowSeconds" binding:"required"`
+	Recipients     []string `json:"recipients" binding:"required"`
+	Enabled        *bool    `json:"enabled"`
+}
+
+func (req *reportScheduleRequest) toReportSchedule() *repository.ReportSchedule {
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &repository.ReportSchedule{
+		Name:           req.Name,
+		CronExpression: req.CronExpression,
+		Timezone:       timezone,
+		Kind:           req.Kind,
+		ReactorID:      req.ReactorID,
+		DeviceIDs:      req.DeviceIDs,
+		WindowSeconds:  req.WindowSeconds,
+		Recipients:     req.Recipients,
+		Enabled:        enabled,
+	}
+}
+
+func (s *Server) createReportScheduleHandler(ctx *gin.Context) {
+	var req reportScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	payload, ok := ctx.MustGet(authorizationPayloadKey).(*pkg.Payload)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(pkg.Errorf(pkg.AUTHENTICATION_ERROR, "Invalid auth payload")))
+		return
+	}
+
+	schedule := req.toReportSchedule()
+	schedule.CreatedBy = payload.UserID
+
+	schedule, err := s.repo.ReportScheduleRepository.CreateReportSchedule(ctx, schedule)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": schedule})
+}
+
+func (s *Server) listReportSchedulesHandler(ctx *gin.Context) {
+	schedules, err := s.repo.ReportScheduleRepository.ListReportSchedules(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": schedules})
+}
+
+func (s *Server) getReportScheduleHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid report schedule ID")))
+		return
+	}
+
+	schedule, err := s.repo.ReportScheduleRepository.GetReportScheduleByID(ctx, id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+func (s *Server) updateReportScheduleHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid report schedule ID")))
+		return
+	}
+
+	var req reportScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	schedule := req.toReportSchedule()
+	schedule.ID = id
+
+	schedule, err = s.repo.ReportScheduleRepository.UpdateReportSchedule(ctx, schedule)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+func (s *Server) deleteReportScheduleHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid report schedule ID")))
+		return
+	}
+
+	if err := s.repo.ReportScheduleRepository.DeleteReportSchedule(ctx, id); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "report schedule deleted successfully"})
+}
@@ -2,16 +2,90 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"time"
+	_ "time/tzdata" // export timezones must resolve without system zoneinfo
 
+	"github.com/Edwin9301/Zen/backend/internal/services"
 	"github.com/Edwin9301/Zen/backend/pkg"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// maxReportDevices bounds how many device sheets one workbook can hold.
+	maxReportDevices = 50
+
+	// maxChartChannels bounds how many channels are plotted per device.
+	maxChartChannels = 12
+
+	// reportWriteTimeout replaces the server write timeout while a workbook
+	// is built, which takes longer than a regular request for a reactor.
+	reportWriteTimeout = 5 * time.Minute
+)
+
+// generateReadingReportRequest takes a reactor or a list of devices. DeviceID
+// is still accepted for single device reports. ChartChannels lists the
+// payload keys to plot, on one shared axis when SharedAxis is set. Readings
+// at the end time are included.
 type generateReadingReportRequest struct {
-	DeviceID  uint32 `json:"deviceId" binding:"required"`
-	StartDate string `json:"start" binding:"required"`
-	EndDate   string `json:"end" binding:"required"`
+	DeviceID      uint32   `json:"deviceId"`
+	DeviceIDs     []uint32 `json:"deviceIds"`
+	ReactorID     *uint32  `json:"reactorId"`
+	StartDate     string   `json:"start" binding:"required"`
+	EndDate       string   `json:"end" binding:"required"`
+	ChartChannels []string `json:"chartChannels"`
+	SharedAxis    bool     `json:"sharedAxis"`
+}
+
+// options validates the request and turns it into report options.
+func (req *generateReadingReportRequest) options() (services.ReadingReportOptions, error) {
+	deviceIDs := req.DeviceIDs
+	if req.DeviceID != 0 {
+		deviceIDs = append(deviceIDs, req.DeviceID)
+	}
+
+	if req.ReactorID == nil && len(deviceIDs) == 0 {
+		return services.ReadingReportOptions{}, pkg.Errorf(pkg.INVALID_ERROR, "reactorId or deviceIds is required")
+	}
+
+	if req.ReactorID != nil && len(deviceIDs) > 0 {
+		return services.ReadingReportOptions{}, pkg.Errorf(pkg.INVALID_ERROR, "use either reactorId or deviceIds, not both")
+	}
+
+	if len(deviceIDs) > maxReportDevices {
+		return services.ReadingReportOptions{}, pkg.Errorf(pkg.INVALID_ERROR, "at most %d devices can be in one report", maxReportDevices)
+	}
+
+	if len(req.ChartChannels) > maxChartChannels {
+		return services.ReadingReportOptions{}, pkg.Errorf(pkg.INVALID_ERROR, "at most %d channels can be charted", maxChartChannels)
+	}
+
+	startDate, err := pkg.StrToTime(req.StartDate)
+	if err != nil {
+		return services.ReadingReportOptions{}, pkg.Errorf(pkg.INVALID_ERROR, "invalid start date format")
+	}
+
+	endDate, err := pkg.StrToTime(req.EndDate)
+	if err != nil {
+		return services.ReadingReportOptions{}, pkg.Errorf(pkg.INVALID_ERROR, "invalid end date format")
+	}
+
+	var charts *services.ReadingChartOptions
+	if len(req.ChartChannels) > 0 {
+		charts = &services.ReadingChartOptions{
+			Channels:   req.ChartChannels,
+			SharedAxis: req.SharedAxis,
+		}
+	}
+
+	return services.ReadingReportOptions{
+		ReactorID: req.ReactorID,
+		DeviceIDs: deviceIDs,
+		Start:     startDate,
+		End:       inclusiveEnd(endDate),
+		Charts:    charts,
+	}, nil
 }
 
 func (s *Server) generateReadingReportHandler(ctx *gin.Context) {
@@ -21,21 +95,19 @@ func (s *Server) generateReadingReportHandler(ctx *gin.Context) {
 		return
 	}
 
-	startDate, err := pkg.StrToTime(req.StartDate)
+	options, err := req.options()
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid start date format")))
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
 		return
 	}
 
-	endDate, err := pkg.StrToTime(req.EndDate)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid end date format")))
-		return
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Now().Add(reportWriteTimeout)); err != nil {
+		log.Printf("failed to extend report write deadline: %v", err)
 	}
 
-	excelData, err := s.report.GenerateReadingsReport(ctx.Request.Context(), req.DeviceID, startDate, endDate)
+	excelData, err := s.report.GenerateReadingsReport(ctx.Request.Context(), options)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
 		return
 	}
 
@@ -44,3 +116,105 @@ func (s *Server) generateReadingReportHandler(ctx *gin.Context) {
 	ctx.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 	ctx.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelData)
 }
+
+func (s *Server) exportReadingsCSVHandler(ctx *gin.Context) {
+	s.exportReadings(ctx, services.ExportFormatCSV, "text/csv; charset=utf-8")
+}
+
+func (s *Server) exportReadingsNDJSONHandler(ctx *gin.Context) {
+	s.exportReadings(ctx, services.ExportFormatNDJSON, "application/x-ndjson")
+}
+
+// exportReadings streams readings of one device as a chunked download. Query
+// parameters: device_id, start, end, optional columns (comma separated,
+// nested payload keys joined with dots) and tz (IANA name, default UTC).
+func (s *Server) exportReadings(ctx *gin.Context, format, contentType string) {
+	deviceID, err := pkg.StrToUint32(ctx.Query("device_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "device_id query parameter is required")))
+		return
+	}
+
+	startStr, endStr := ctx.Query("start"), ctx.Query("end")
+	if startStr == "" || endStr == "" {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "start and end query parameters are required")))
+		return
+	}
+
+	startDate, err := pkg.StrToTime(startStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid start date format")))
+		return
+	}
+
+	endDate, err := pkg.StrToTime(endStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid end date format")))
+		return
+	}
+
+	if endDate.Before(startDate) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "end date must not be before start date")))
+		return
+	}
+
+	location := time.UTC
+	if tz := ctx.Query("tz"); tz != "" {
+		location, err = time.LoadLocation(tz)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "unknown timezone %q", tz)))
+			return
+		}
+	}
+
+	if _, err := s.repo.DeviceRepository.GetDeviceByID(ctx, deviceID); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	// long exports would otherwise be cut off by the server write timeout
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "streaming not supported: %s", err.Error())))
+		return
+	}
+
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=readings_%d_%s-%s.%s", deviceID, startStr, endStr, format))
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	err = s.report.ExportReadings(ctx.Request.Context(), ctx.Writer, services.ReadingExportOptions{
+		DeviceID: deviceID,
+		Start:    startDate,
+		End:      endDate,
+		Format:   format,
+		Columns:  splitQueryList(ctx.Query("columns")),
+		Location: location,
+	})
+	if err != nil {
+		// the status line is already sent, so the client only sees a truncated file
+		log.Printf("readings export for device %d failed: %v", deviceID, err)
+	}
+}
+
+func (s *Server) experimentReportHandler(ctx *gin.Context) {
+	id, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Now().Add(reportWriteTimeout)); err != nil {
+		log.Printf("failed to extend report write deadline: %v", err)
+	}
+
+	pdfData, err := s.report.GenerateExperimentReport(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=experiment_%d_report.pdf", id))
+	ctx.Data(http.StatusOK, "application/pdf", pdfData)
+}
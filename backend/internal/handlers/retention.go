@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// retentionPolicyRequest sets how many days each tier is kept; a missing or
+// null value keeps that tier forever.
+type retentionPolicyRequest struct {
+	RawRetentionDays          *int32 `json:"rawRetentionDays"`
+	MinuteRollupRetentionDays *int32 `json:"minuteRollupRetentionDays"`
+	HourRollupRetentionDays   *int32 `json:"hourRollupRetentionDays"`
+}
+
+func (req *retentionPolicyRequest) toRetentionPolicy() *repository.RetentionPolicy {
+	return &repository.RetentionPolicy{
+		RawRetentionDays:          req.RawRetentionDays,
+		MinuteRollupRetentionDays: req.MinuteRollupRetentionDays,
+		HourRollupRetentionDays:   req.HourRollupRetentionDays,
+	}
+}
+
+func (s *Server) listRetentionPoliciesHandler(ctx *gin.Context) {
+	policies, err := s.repo.RetentionRepository.ListRetentionPolicies(ctx)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": policies})
+}
+
+func (s *Server) updateGlobalRetentionPolicyHandler(ctx *gin.Context) {
+	var req retentionPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	policy, err := s.repo.RetentionRepository.UpdateGlobalRetentionPolicy(ctx, req.toRetentionPolicy())
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+func (s *Server) updateDeviceRetentionPolicyHandler(ctx *gin.Context) {
+	var req retentionPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "%s", err.Error())))
+		return
+	}
+
+	deviceID, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	policy := req.toRetentionPolicy()
+	policy.DeviceID = &deviceID
+
+	policy, err = s.repo.RetentionRepository.UpsertDeviceRetentionPolicy(ctx, policy)
+	if err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+func (s *Server) deleteDeviceRetentionPolicyHandler(ctx *gin.Context) {
+	deviceID, err := pkg.StrToUint32(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device ID")))
+		return
+	}
+
+	if err := s.repo.RetentionRepository.DeleteDeviceRetentionPolicy(ctx, deviceID); err != nil {
+		ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": "device retention policy deleted successfully"})
+}
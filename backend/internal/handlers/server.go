@@ -9,6 +9,7 @@ import (
 
 	"github.com/Edwin9301/Zen/backend/internal/postgres"
 	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/internal/stream"
 	"github.com/Edwin9301/Zen/backend/pkg"
 	"github.com/gin-gonic/gin"
 )
@@ -24,10 +25,14 @@ type Server struct {
 
 	email pkg.EmailSender
 
-	report services.ReportService
+	report  services.ReportService
+	ingest  services.IngestService
+	storage services.FileStorage
+
+	hub *stream.Hub
 }
 
-func NewServer(config pkg.Config, tokenMaker pkg.JWTMaker, repo *postgres.PostgresRepo, report services.ReportService) *Server {
+func NewServer(config pkg.Config, tokenMaker pkg.JWTMaker, repo *postgres.PostgresRepo, report services.ReportService, ingest services.IngestService, storage services.FileStorage, hub *stream.Hub) *Server {
 	if config.ENVIRONMENT == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -46,7 +51,11 @@ func NewServer(config pkg.Config, tokenMaker pkg.JWTMaker, repo *postgres.Postgr
 
 		email: emailSender,
 
-		report: report,
+		report:  report,
+		ingest:  ingest,
+		storage: storage,
+
+		hub: hub,
 	}
 
 	s.setUpRoutes()
@@ -89,8 +98,24 @@ func (s *Server) setUpRoutes() {
 	adminGroup.POST("/experiments", s.createExperiment)
 	authGroup.GET("/experiments/:id", s.getExperiment)
 	authGroup.GET("/experiments", s.listExperiments)
+	authGroup.GET("/experiments/compare", s.compareExperimentsHandler)
+	authGroup.GET("/experiments/compare.xlsx", s.experimentComparisonReportHandler)
 	adminGroup.PUT("/experiments/:id", s.updateExperiment)
 	adminGroup.DELETE("/experiments/:id", s.deleteExperiment)
+	adminGroup.POST("/experiments/:id/start", s.startExperiment)
+	adminGroup.POST("/experiments/:id/stop", s.stopExperiment)
+	authGroup.GET("/experiments/:id/report.pdf", s.experimentReportHandler)
+	authGroup.GET("/experiments/:id/readings", s.experimentReadingsHandler)
+	authGroup.GET("/experiments/:id/stats", s.experimentStatsHandler)
+	adminGroup.POST("/experiments/:id/clone", s.cloneExperiment)
+	adminGroup.POST("/experiments/:id/template", s.saveExperimentTemplateHandler)
+
+	// experiment template routes
+	adminGroup.POST("/experiment-templates", s.createExperimentTemplateHandler)
+	authGroup.GET("/experiment-templates", s.listExperimentTemplatesHandler)
+	authGroup.GET("/experiment-templates/:id", s.getExperimentTemplateHandler)
+	adminGroup.PUT("/experiment-templates/:id", s.updateExperimentTemplateHandler)
+	adminGroup.DELETE("/experiment-templates/:id", s.deleteExperimentTemplateHandler)
 
 	// reactor routes
 	adminGroup.POST("/reactors", s.createReactor)
@@ -106,14 +131,65 @@ func (s *Server) setUpRoutes() {
 	adminGroup.PUT("/devices/:id", s.updateDeviceHandler)
 	adminGroup.DELETE("/devices/:id", s.deleteDeviceHandler)
 	authGroup.GET("/devices/stats", s.getDeviceStatsHandler)
+	adminGroup.POST("/devices/:id/channels", s.createDeviceChannelHandler)
+	authGroup.GET("/devices/:id/channels", s.listDeviceChannelsHandler)
+	adminGroup.PUT("/devices/:id/channels/:channelId", s.updateDeviceChannelHandler)
+	adminGroup.DELETE("/devices/:id/channels/:channelId", s.deleteDeviceChannelHandler)
+	adminGroup.POST("/devices/:id/keys", s.createDeviceAPIKeyHandler)
+	adminGroup.GET("/devices/:id/keys", s.listDeviceAPIKeysHandler)
+	adminGroup.POST("/devices/:id/keys/:keyId/rotate", s.rotateDeviceAPIKeyHandler)
+	adminGroup.DELETE("/devices/:id/keys/:keyId", s.revokeDeviceAPIKeyHandler)
+	adminGroup.PUT("/devices/:id/retention-policy", s.updateDeviceRetentionPolicyHandler)
+	adminGroup.DELETE("/devices/:id/retention-policy", s.deleteDeviceRetentionPolicyHandler)
 
 	// sensor readings routes
-	v1.POST("/readings/:id", s.createSensorReadingHandler)
+	v1.POST("/readings/:id", deviceAPIKeyMiddleware(s.repo.DeviceRepository), s.createSensorReadingHandler)
+	v1.POST("/readings/:id/batch", deviceAPIKeyMiddleware(s.repo.DeviceRepository), s.createSensorReadingsBatchHandler)
 	v1.GET("/readings/:id", s.getSensorReadingByIDHandler)
 	v1.GET("/readings", s.listSensorReadingsHandler)
+	v1.GET("/readings/aggregate", s.aggregateSensorReadingsHandler)
+	authGroup.GET("/readings/stream", s.streamSensorReadingsHandler)
+
+	// alert routes
+	adminGroup.POST("/alerts/rules", s.createAlertRuleHandler)
+	authGroup.GET("/alerts/rules", s.listAlertRulesHandler)
+	authGroup.GET("/alerts/rules/:id", s.getAlertRuleHandler)
+	adminGroup.PUT("/alerts/rules/:id", s.updateAlertRuleHandler)
+	adminGroup.DELETE("/alerts/rules/:id", s.deleteAlertRuleHandler)
+	authGroup.GET("/alerts/incidents", s.listAlertIncidentsHandler)
+	authGroup.GET("/alerts/incidents/:id", s.getAlertIncidentHandler)
+	authGroup.POST("/alerts/incidents/:id/acknowledge", s.acknowledgeAlertIncidentHandler)
+	authGroup.POST("/alerts/incidents/:id/resolve", s.resolveAlertIncidentHandler)
+
+	// retention routes
+	authGroup.GET("/retention-policies", s.listRetentionPoliciesHandler)
+	adminGroup.PUT("/retention-policies/global", s.updateGlobalRetentionPolicyHandler)
 
 	// reports routes
 	authGroup.POST("/reports/readings", s.generateReadingReportHandler)
+	authGroup.GET("/reports/readings/csv", s.exportReadingsCSVHandler)
+	authGroup.GET("/reports/readings/ndjson", s.exportReadingsNDJSONHandler)
+
+	// background report job routes
+	authGroup.POST("/report-jobs/readings", s.createReadingReportJobHandler)
+	authGroup.POST("/experiments/:id/report-jobs", s.createExperimentReportJobHandler)
+	authGroup.GET("/report-jobs", s.listReportJobsHandler)
+	authGroup.GET("/report-jobs/:id", s.getReportJobHandler)
+	v1.GET("/report-jobs/:id/download", s.downloadReportJobHandler)
+
+	// report schedule routes
+	adminGroup.POST("/report-schedules", s.createReportScheduleHandler)
+	adminGroup.GET("/report-schedules", s.listReportSchedulesHandler)
+	adminGroup.GET("/report-schedules/:id", s.getReportScheduleHandler)
+	adminGroup.PUT("/report-schedules/:id", s.updateReportScheduleHandler)
+	adminGroup.DELETE("/report-schedules/:id", s.deleteReportScheduleHandler)
+
+	// file routes
+	authGroup.POST("/files", s.uploadFileHandler)
+	authGroup.GET("/files/:id", s.getFileHandler)
+	authGroup.GET("/files/:id/download", s.downloadFileHandler)
+	authGroup.GET("/files/:id/url", s.fileURLHandler)
+	v1.GET("/files/:id/signed-download", s.signedDownloadFileHandler)
 
 	// helpers routes
 	authGroup.GET("/dashboard/stats", s.getDashboardStatsHandler)
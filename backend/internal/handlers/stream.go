@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxStreamDevices    = 50
+	streamPingInterval  = 15 * time.Second
+	readingStreamEvent  = "reading"
+	streamClosedEvent   = "closed"
+	streamClosedMessage = "subscriber fell behind, reconnect to resume"
+)
+
+// streamSensorReadingsHandler pushes new readings of the requested devices to
+// the client as Server-Sent Events until the client disconnects.
+func (s *Server) streamSensorReadingsHandler(ctx *gin.Context) {
+	deviceIDs := []uint32{}
+	for _, idStr := range splitQueryList(ctx.Query("device_ids")) {
+		deviceID, err := pkg.StrToUint32(idStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "invalid device id %q", idStr)))
+			return
+		}
+
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+
+	if len(deviceIDs) == 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "device_ids query parameter is required")))
+		return
+	}
+
+	if len(deviceIDs) > maxStreamDevices {
+		ctx.JSON(http.StatusBadRequest, errorResponse(pkg.Errorf(pkg.INVALID_ERROR, "at most %d devices can be streamed at once", maxStreamDevices)))
+		return
+	}
+
+	for _, deviceID := range deviceIDs {
+		if _, err := s.repo.DeviceRepository.GetDeviceByID(ctx, deviceID); err != nil {
+			ctx.JSON(pkg.ErrorToStatusCode(err), errorResponse(err))
+			return
+		}
+	}
+
+	// the server write timeout would otherwise cut the stream after a few seconds
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(pkg.Errorf(pkg.INTERNAL_ERROR, "streaming not supported: %s", err.Error())))
+		return
+	}
+
+	sub := s.hub.Subscribe(deviceIDs)
+	defer s.hub.Unsubscribe(sub)
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-ping.C:
+			ctx.SSEvent("ping", time.Now().Unix())
+			return true
+		case reading, ok := <-sub.C:
+			if !ok {
+				ctx.SSEvent(streamClosedEvent, streamClosedMessage)
+				return false
+			}
+
+			ctx.SSEvent(readingStreamEvent, reading)
+			return true
+		}
+	})
+}
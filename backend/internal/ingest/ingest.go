@@ -0,0 +1,163 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+var _ services.IngestService = (*IngestService)(nil)
+
+// IngestService is the single write path for device readings, shared by the
+// HTTP handlers and the MQTT bridge.
+type IngestService struct {
+	store  *postgres.PostgresRepo
+	alerts services.AlertService
+}
+
+func NewIngestService(store *postgres.PostgresRepo, alerts services.AlertService) *IngestService {
+	return &IngestService{
+		store:  store,
+		alerts: alerts,
+	}
+}
+
+// IngestReading stores one reading for a device and evaluates the device alert
+// rules against it. A reading carrying a message id that is already stored is
+// returned as is with Duplicate set.
+func (s *IngestService) IngestReading(ctx context.Context, deviceID uint32, payload any) (*repository.Reading, error) {
+	reading := &repository.Reading{
+		DeviceID: deviceID,
+		Payload:  payload,
+	}
+
+	if fields, ok := payload.(map[string]any); ok {
+		timestamp, err := ReadingTimestamp(fields)
+		if err != nil {
+			return nil, err
+		}
+		reading.Timestamp = timestamp
+
+		reading.MessageID, err = ReadingMessageID(fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	createdReading, err := s.store.DeviceRepository.AddReading(ctx, reading)
+	if err != nil {
+		return nil, err
+	}
+
+	// a retried reading was already evaluated when it was first stored
+	if createdReading.Duplicate {
+		return createdReading, nil
+	}
+
+	// the reading is stored either way, so evaluation errors are only logged
+	if err := s.alerts.EvaluateReading(ctx, createdReading); err != nil {
+		log.Printf("failed to evaluate alert rules for reading %d: %v", createdReading.ID, err)
+	}
+
+	return createdReading, nil
+}
+
+// IngestReadings stores a batch of readings for a device with one COPY and
+// then evaluates the device alert rules against each stored reading, oldest
+// first, so breach durations are measured in the order the readings were
+// taken. Duplicates and rejected readings are not evaluated.
+func (s *IngestService) IngestReadings(ctx context.Context, deviceID uint32, readings []*repository.Reading) (*repository.ReadingBatchResult, error) {
+	result, err := s.store.DeviceRepository.AddReadings(ctx, deviceID, readings)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := slices.Clone(result.Stored)
+	slices.SortStableFunc(stored, func(a, b *repository.Reading) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	// the readings are stored either way, so evaluation errors are only logged
+	for _, reading := range stored {
+		if err := s.alerts.EvaluateReading(ctx, reading); err != nil {
+			log.Printf("failed to evaluate alert rules for a batch reading of device %d: %v", deviceID, err)
+		}
+	}
+
+	return result, nil
+}
+
+const maxMessageIDLength = 100
+
+// ReadingMessageID takes the optional idempotency key out of a payload. Devices
+// may send a string id or an integer sequence number. The key is removed from
+// the payload so it is not stored or charted as a channel.
+func ReadingMessageID(payload map[string]any) (string, error) {
+	raw, ok := payload[repository.MessageIDKey]
+	if !ok {
+		return "", nil
+	}
+	delete(payload, repository.MessageIDKey)
+
+	switch v := raw.(type) {
+	case nil:
+		return "", nil
+	case string:
+		if len(v) > maxMessageIDLength {
+			return "", pkg.Errorf(pkg.INVALID_ERROR, "message_id cannot be longer than %d characters", maxMessageIDLength)
+		}
+
+		return v, nil
+	case float64:
+		if v < 0 || v != math.Trunc(v) {
+			return "", pkg.Errorf(pkg.INVALID_ERROR, "invalid message_id %v: sequence numbers must be non-negative integers", v)
+		}
+
+		return strconv.FormatFloat(v, 'f', 0, 64), nil
+	default:
+		return "", pkg.Errorf(pkg.INVALID_ERROR, "invalid message_id type %T", raw)
+	}
+}
+
+// ReadingTimestamp extracts the device-supplied "timestamp" from a payload. It
+// accepts RFC 3339 strings or unix epochs in seconds or milliseconds. The zero
+// time is returned when the device did not send one.
+func ReadingTimestamp(payload map[string]any) (time.Time, error) {
+	raw, ok := payload["timestamp"]
+	if !ok || raw == nil {
+		return time.Time{}, nil
+	}
+
+	switch v := raw.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "invalid timestamp %q: should be RFC 3339", v)
+		}
+
+		return t, nil
+	case float64:
+		if v <= 0 {
+			return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "invalid timestamp %v", v)
+		}
+
+		// anything past year 33658 in seconds is treated as milliseconds
+		if v >= 1e12 {
+			return time.UnixMilli(int64(v)), nil
+		}
+
+		sec, frac := math.Modf(v)
+
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	default:
+		return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "invalid timestamp type %T", raw)
+	}
+}
@@ -0,0 +1,285 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	deviceIDPlaceholder = "{id}"
+	mqttConnectTimeout  = 30 * time.Second
+	mqttHandleTimeout   = 10 * time.Second
+)
+
+// MQTTBridge subscribes to reading topics on an MQTT broker and writes each
+// message through the same IngestService as the HTTP API.
+//
+// Messages are acknowledged only once they are stored (or rejected as
+// invalid), and the client keeps a persistent session, so the broker redelivers
+// anything in flight after a crash or reconnect. Redeliveries are dropped by a
+// short-lived dedup cache keyed on the payload "message_id" or, failing that,
+// a hash of the topic and payload when the payload carries a device
+// timestamp; readings with a message id are also deduplicated by the
+// database. Payloads with neither are never deduplicated, since a device
+// repeating the same value is not a redelivery. Device authentication is left to the broker
+// ACLs; a device can only publish on its own topic.
+type MQTTBridge struct {
+	config pkg.Config
+	ingest services.IngestService
+
+	topic  *topicPattern
+	dedup  *dedupCache
+	client mqtt.Client
+}
+
+func NewMQTTBridge(config pkg.Config, ingest services.IngestService) (*MQTTBridge, error) {
+	topic, err := parseTopicPattern(config.MQTT_TOPIC_PATTERN)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &MQTTBridge{
+		config: config,
+		ingest: ingest,
+		topic:  topic,
+		dedup:  newDedupCache(config.MQTT_DEDUP_TTL),
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(config.MQTT_BROKER_URL).
+		SetClientID(config.MQTT_CLIENT_ID).
+		SetUsername(config.MQTT_USERNAME).
+		SetPassword(config.MQTT_PASSWORD).
+		SetCleanSession(false).
+		SetAutoAckDisabled(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetDefaultPublishHandler(b.onMessage).
+		SetOnConnectHandler(b.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("mqtt connection lost: %v", err)
+		})
+
+	b.client = mqtt.NewClient(opts)
+
+	return b, nil
+}
+
+// Start connects to the broker. Subscribing happens on every (re)connect.
+func (b *MQTTBridge) Start() error {
+	token := b.client.Connect()
+	if !token.WaitTimeout(mqttConnectTimeout) {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "timed out connecting to mqtt broker %s", b.config.MQTT_BROKER_URL)
+	}
+	if err := token.Error(); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to connect to mqtt broker: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (b *MQTTBridge) Stop() {
+	b.client.Disconnect(250)
+	log.Println("Shutting down mqtt bridge...")
+}
+
+// onConnect subscribes with no callback of its own, so messages go to the
+// default handler. The broker redelivers what was in flight as soon as the
+// session resumes, which can be before the subscription is made again.
+func (b *MQTTBridge) onConnect(client mqtt.Client) {
+	subscription := b.topic.subscription()
+
+	token := client.Subscribe(subscription, b.config.MQTT_QOS, nil)
+	if token.WaitTimeout(mqttConnectTimeout) && token.Error() != nil {
+		log.Printf("failed to subscribe to %s: %v", subscription, token.Error())
+		return
+	}
+
+	log.Printf("mqtt bridge subscribed to %s", subscription)
+}
+
+func (b *MQTTBridge) onMessage(_ mqtt.Client, msg mqtt.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), mqttHandleTimeout)
+	defer cancel()
+
+	if err := b.HandleMessage(ctx, msg.Topic(), msg.Payload()); err != nil {
+		// leave it unacknowledged so the broker delivers it again
+		log.Printf("failed to ingest mqtt message on %s: %v", msg.Topic(), err)
+		return
+	}
+
+	msg.Ack()
+}
+
+// HandleMessage ingests one message. It only returns an error when the message
+// should be delivered again; invalid messages are logged and dropped.
+func (b *MQTTBridge) HandleMessage(ctx context.Context, topic string, payload []byte) error {
+	deviceID, err := b.topic.deviceID(topic)
+	if err != nil {
+		log.Printf("dropping mqtt message: %v", err)
+		return nil
+	}
+
+	var body any
+	if err := json.Unmarshal(payload, &body); err != nil {
+		log.Printf("dropping mqtt message on %s: invalid JSON: %v", topic, err)
+		return nil
+	}
+
+	key, dedup := messageKey(topic, payload, body)
+	if dedup && b.dedup.seen(key) {
+		return nil
+	}
+
+	if _, err := b.ingest.IngestReading(ctx, deviceID, body); err != nil {
+		switch pkg.ErrorCode(err) {
+		// a message id stored before but already past retention is also a
+		// redelivery, and storing it again would never succeed
+		case pkg.INVALID_ERROR, pkg.NOT_FOUND_ERROR, pkg.ALREADY_EXISTS_ERROR:
+			log.Printf("dropping mqtt message on %s: %s", topic, pkg.ErrorMessage(err))
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if dedup {
+		b.dedup.add(key)
+	}
+
+	return nil
+}
+
+// messageKey identifies a message across redeliveries. Only a message id or
+// a device timestamp tells a redelivery apart from a new reading of the same
+// value, so without either it returns false.
+func messageKey(topic string, payload []byte, body any) (string, bool) {
+	fields, ok := body.(map[string]any)
+	if !ok {
+		return "", false
+	}
+
+	if id, ok := fields[repository.MessageIDKey]; ok && id != nil {
+		return fmt.Sprintf("%s|%v", topic, id), true
+	}
+
+	if timestamp, ok := fields["timestamp"]; !ok || timestamp == nil {
+		return "", false
+	}
+
+	sum := sha256.Sum256(append([]byte(topic+"|"), payload...))
+
+	return hex.EncodeToString(sum[:]), true
+}
+
+// topicPattern is an MQTT topic with a {id} segment for the device ID, such
+// as zen/devices/{id}/readings.
+type topicPattern struct {
+	segments []string
+	idIndex  int
+}
+
+func parseTopicPattern(pattern string) (*topicPattern, error) {
+	segments := strings.Split(pattern, "/")
+
+	idIndex := -1
+	for idx, segment := range segments {
+		if segment == deviceIDPlaceholder {
+			if idIndex != -1 {
+				return nil, pkg.Errorf(pkg.INVALID_ERROR, "mqtt topic pattern %q has more than one %s segment", pattern, deviceIDPlaceholder)
+			}
+			idIndex = idx
+			continue
+		}
+
+		if segment == "+" || segment == "#" {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "mqtt topic pattern %q cannot contain wildcards", pattern)
+		}
+	}
+
+	if idIndex == -1 {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "mqtt topic pattern %q needs a %s segment", pattern, deviceIDPlaceholder)
+	}
+
+	return &topicPattern{segments: segments, idIndex: idIndex}, nil
+}
+
+// subscription returns the topic filter to subscribe to.
+func (p *topicPattern) subscription() string {
+	segments := make([]string, len(p.segments))
+	copy(segments, p.segments)
+	segments[p.idIndex] = "+"
+
+	return strings.Join(segments, "/")
+}
+
+func (p *topicPattern) deviceID(topic string) (uint32, error) {
+	segments := strings.Split(topic, "/")
+	if len(segments) != len(p.segments) {
+		return 0, pkg.Errorf(pkg.INVALID_ERROR, "topic %s does not match the reading topic pattern", topic)
+	}
+
+	deviceID, err := pkg.StrToUint32(segments[p.idIndex])
+	if err != nil {
+		return 0, pkg.Errorf(pkg.INVALID_ERROR, "topic %s has an invalid device id", topic)
+	}
+
+	return deviceID, nil
+}
+
+// dedupCache remembers message keys for ttl.
+type dedupCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]time.Time
+	lastSweep time.Time
+}
+
+func newDedupCache(ttl time.Duration) *dedupCache {
+	return &dedupCache{
+		ttl:       ttl,
+		entries:   map[string]time.Time{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *dedupCache) seen(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt, ok := c.entries[key]
+
+	return ok && time.Now().Before(expiresAt)
+}
+
+func (c *dedupCache) add(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.entries[key] = now.Add(c.ttl)
+
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+
+	for k, expiresAt := range c.entries {
+		if now.After(expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.lastSweep = now
+}
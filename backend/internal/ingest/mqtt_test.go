@@ -0,0 +1,471 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// fakeIngest records the readings handed to it and fails with err when set.
+// stored counts the readings that did not fail, by device.
+type fakeIngest struct {
+	mu       sync.Mutex
+	payloads []any
+	stored   map[uint32]int
+	err      error
+}
+
+func (f *fakeIngest) IngestReading(ctx context.Context, deviceID uint32, payload any) (*repository.Reading, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.payloads = append(f.payloads, payload)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if f.stored == nil {
+		f.stored = map[uint32]int{}
+	}
+	f.stored[deviceID]++
+
+	return &repository.Reading{DeviceID: deviceID, Payload: payload}, nil
+}
+
+func (f *fakeIngest) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+}
+
+func (f *fakeIngest) storedFor(deviceID uint32) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.stored[deviceID]
+}
+
+func (f *fakeIngest) IngestReadings(ctx context.Context, deviceID uint32, readings []*repository.Reading) (*repository.ReadingBatchResult, error) {
+	return &repository.ReadingBatchResult{}, nil
+}
+
+func newTestBridge(t *testing.T, ingest *fakeIngest) *MQTTBridge {
+	t.Helper()
+
+	topic, err := parseTopicPattern("zen/devices/{id}/readings")
+	if err != nil {
+		t.Fatalf("parseTopicPattern: %v", err)
+	}
+
+	return &MQTTBridge{
+		ingest: ingest,
+		topic:  topic,
+		dedup:  newDedupCache(time.Minute),
+	}
+}
+
+func TestHandleMessageDropsRedeliveries(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    string
+		second   string
+		ingested int
+	}{
+		{
+			name:     "same message id",
+			first:    `{"message_id":"m-1","temperature":21.5}`,
+			second:   `{"message_id":"m-1","temperature":21.5}`,
+			ingested: 1,
+		},
+		{
+			name:     "same message id with another payload",
+			first:    `{"message_id":"m-1","temperature":21.5}`,
+			second:   `{"message_id":"m-1","temperature":22}`,
+			ingested: 1,
+		},
+		{
+			name:     "same payload with a device timestamp",
+			first:    `{"timestamp":"2026-10-18T10:00:00Z","temperature":21.5}`,
+			second:   `{"timestamp":"2026-10-18T10:00:00Z","temperature":21.5}`,
+			ingested: 1,
+		},
+		{
+			name:     "same payload without message id",
+			first:    `{"temperature":21.5}`,
+			second:   `{"temperature":21.5}`,
+			ingested: 2,
+		},
+		{
+			name:     "different device timestamps",
+			first:    `{"timestamp":"2026-10-18T10:00:00Z","temperature":21.5}`,
+			second:   `{"timestamp":"2026-10-18T10:01:00Z","temperature":21.5}`,
+			ingested: 2,
+		},
+		{
+			name:     "different message ids",
+			first:    `{"message_id":"m-1","temperature":21.5}`,
+			second:   `{"message_id":"m-2","temperature":21.5}`,
+			ingested: 2,
+		},
+		{
+			name:     "different payloads without message id",
+			first:    `{"temperature":21.5}`,
+			second:   `{"temperature":22}`,
+			ingested: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingest := &fakeIngest{}
+			bridge := newTestBridge(t, ingest)
+
+			for _, payload := range []string{tt.first, tt.second} {
+				if err := bridge.HandleMessage(context.Background(), "zen/devices/7/readings", []byte(payload)); err != nil {
+					t.Fatalf("HandleMessage(%s) = %v, want nil", payload, err)
+				}
+			}
+
+			if len(ingest.payloads) != tt.ingested {
+				t.Errorf("ingested %d messages, want %d", len(ingest.payloads), tt.ingested)
+			}
+		})
+	}
+}
+
+func TestHandleMessageDedupIsPerTopic(t *testing.T) {
+	ingest := &fakeIngest{}
+	bridge := newTestBridge(t, ingest)
+
+	payload := []byte(`{"message_id":"m-1","temperature":21.5}`)
+	for _, topic := range []string{"zen/devices/7/readings", "zen/devices/8/readings"} {
+		if err := bridge.HandleMessage(context.Background(), topic, payload); err != nil {
+			t.Fatalf("HandleMessage(%s) = %v, want nil", topic, err)
+		}
+	}
+
+	if len(ingest.payloads) != 2 {
+		t.Errorf("ingested %d messages, want one per device", len(ingest.payloads))
+	}
+}
+
+func TestHandleMessageRedeliverOrDrop(t *testing.T) {
+	tests := []struct {
+		name      string
+		topic     string
+		payload   string
+		err       error
+		redeliver bool
+		ingested  int
+	}{
+		{
+			name:     "stored",
+			topic:    "zen/devices/7/readings",
+			payload:  `{"temperature":21.5}`,
+			ingested: 1,
+		},
+		{
+			name:    "topic outside the pattern",
+			topic:   "zen/devices/7",
+			payload: `{"temperature":21.5}`,
+		},
+		{
+			name:    "invalid device id",
+			topic:   "zen/devices/abc/readings",
+			payload: `{"temperature":21.5}`,
+		},
+		{
+			name:    "invalid JSON",
+			topic:   "zen/devices/7/readings",
+			payload: `{"temperature":`,
+		},
+		{
+			name:     "invalid reading",
+			topic:    "zen/devices/7/readings",
+			payload:  `{"timestamp":"yesterday"}`,
+			err:      pkg.Errorf(pkg.INVALID_ERROR, "invalid timestamp"),
+			ingested: 1,
+		},
+		{
+			name:     "unknown device",
+			topic:    "zen/devices/7/readings",
+			payload:  `{"temperature":21.5}`,
+			err:      pkg.Errorf(pkg.NOT_FOUND_ERROR, "device with id 7 not found"),
+			ingested: 1,
+		},
+		{
+			name:     "message id past retention",
+			topic:    "zen/devices/7/readings",
+			payload:  `{"message_id":"m-1","temperature":21.5}`,
+			err:      pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "reading with message id m-1 was already stored and is past retention"),
+			ingested: 1,
+		},
+		{
+			name:      "database unavailable",
+			topic:     "zen/devices/7/readings",
+			payload:   `{"temperature":21.5}`,
+			err:       pkg.Errorf(pkg.INTERNAL_ERROR, "failed to add reading"),
+			redeliver: true,
+			ingested:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingest := &fakeIngest{err: tt.err}
+			bridge := newTestBridge(t, ingest)
+
+			err := bridge.HandleMessage(context.Background(), tt.topic, []byte(tt.payload))
+			if redeliver := err != nil; redeliver != tt.redeliver {
+				t.Errorf("HandleMessage() = %v, want redeliver %v", err, tt.redeliver)
+			}
+
+			if len(ingest.payloads) != tt.ingested {
+				t.Errorf("ingested %d messages, want %d", len(ingest.payloads), tt.ingested)
+			}
+		})
+	}
+}
+
+func TestHandleMessageRetriesFailedMessage(t *testing.T) {
+	ingest := &fakeIngest{err: pkg.Errorf(pkg.INTERNAL_ERROR, "failed to add reading")}
+	bridge := newTestBridge(t, ingest)
+
+	payload := []byte(`{"message_id":"m-1","temperature":21.5}`)
+	if err := bridge.HandleMessage(context.Background(), "zen/devices/7/readings", payload); err == nil {
+		t.Fatal("HandleMessage() = nil, want an error so the message is redelivered")
+	}
+
+	// a failed message is not remembered, so its redelivery is stored
+	ingest.err = nil
+	if err := bridge.HandleMessage(context.Background(), "zen/devices/7/readings", payload); err != nil {
+		t.Fatalf("HandleMessage() on redelivery = %v, want nil", err)
+	}
+
+	if len(ingest.payloads) != 2 {
+		t.Errorf("ingested %d messages, want the redelivery to be ingested again", len(ingest.payloads))
+	}
+}
+
+func TestDedupCacheExpires(t *testing.T) {
+	cache := newDedupCache(10 * time.Millisecond)
+
+	cache.add("key")
+	if !cache.seen("key") {
+		t.Fatal("seen() = false right after add")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if cache.seen("key") {
+		t.Error("seen() = true after the ttl")
+	}
+}
+
+func TestParseTopicPattern(t *testing.T) {
+	tests := []struct {
+		pattern      string
+		subscription string
+		wantErr      bool
+	}{
+		{pattern: "zen/devices/{id}/readings", subscription: "zen/devices/+/readings"},
+		{pattern: "{id}", subscription: "+"},
+		{pattern: "zen/devices/readings", wantErr: true},
+		{pattern: "zen/{id}/{id}", wantErr: true},
+		{pattern: "zen/+/{id}", wantErr: true},
+		{pattern: "zen/{id}/#", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			topic, err := parseTopicPattern(tt.pattern)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseTopicPattern(%q) = nil error, want one", tt.pattern)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTopicPattern(%q) = %v", tt.pattern, err)
+			}
+
+			if got := topic.subscription(); got != tt.subscription {
+				t.Errorf("subscription() = %q, want %q", got, tt.subscription)
+			}
+		})
+	}
+}
+
+// newTestBrokerConfig points a bridge at the broker at
+// ZEN_TEST_MQTT_BROKER_URL and skips the test when it is not set; make
+// createMqtt starts one and make mqttTest runs these tests against it. Each
+// test gets its own topics and client id.
+func newTestBrokerConfig(t *testing.T) pkg.Config {
+	t.Helper()
+
+	brokerURL := os.Getenv("ZEN_TEST_MQTT_BROKER_URL")
+	if brokerURL == "" {
+		t.Skip("ZEN_TEST_MQTT_BROKER_URL is not set")
+	}
+
+	run := time.Now().UnixNano()
+
+	return pkg.Config{
+		MQTT_BROKER_URL:    brokerURL,
+		MQTT_CLIENT_ID:     fmt.Sprintf("zen-test-%d", run),
+		MQTT_TOPIC_PATTERN: fmt.Sprintf("zen-test/%d/devices/{id}/readings", run),
+		MQTT_QOS:           1,
+		MQTT_DEDUP_TTL:     time.Minute,
+	}
+}
+
+func startTestBrokerBridge(t *testing.T, config pkg.Config, ingest *fakeIngest) *MQTTBridge {
+	t.Helper()
+
+	bridge, err := NewMQTTBridge(config, ingest)
+	if err != nil {
+		t.Fatalf("NewMQTTBridge: %v", err)
+	}
+	if err := bridge.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	return bridge
+}
+
+// testPublisher publishes readings for the devices of a bridge config.
+type testPublisher struct {
+	t      *testing.T
+	config pkg.Config
+	client mqtt.Client
+}
+
+func newTestPublisher(t *testing.T, config pkg.Config) *testPublisher {
+	t.Helper()
+
+	client := mqtt.NewClient(mqtt.NewClientOptions().
+		AddBroker(config.MQTT_BROKER_URL).
+		SetClientID(config.MQTT_CLIENT_ID + "-publisher"))
+	if token := client.Connect(); !token.WaitTimeout(mqttConnectTimeout) || token.Error() != nil {
+		t.Fatalf("connecting the publisher: %v", token.Error())
+	}
+	t.Cleanup(func() { client.Disconnect(250) })
+
+	return &testPublisher{t: t, config: config, client: client}
+}
+
+func (p *testPublisher) publish(deviceID uint32, payload string) {
+	p.t.Helper()
+
+	topic, err := parseTopicPattern(p.config.MQTT_TOPIC_PATTERN)
+	if err != nil {
+		p.t.Fatalf("parseTopicPattern: %v", err)
+	}
+	segments := append([]string{}, topic.segments...)
+	segments[topic.idIndex] = fmt.Sprint(deviceID)
+
+	token := p.client.Publish(strings.Join(segments, "/"), 1, false, payload)
+	if !token.WaitTimeout(mqttConnectTimeout) || token.Error() != nil {
+		p.t.Fatalf("publishing %s: %v", payload, token.Error())
+	}
+}
+
+// waitForStored waits until want readings of a device are stored. The bridge
+// subscribes once connected, so a reading published right after Start may
+// not arrive; until is published again while waiting.
+func waitForStored(t *testing.T, ingest *fakeIngest, deviceID uint32, want int, until func()) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for ingest.storedFor(deviceID) < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("stored %d readings of device %d, want %d", ingest.storedFor(deviceID), deviceID, want)
+		}
+		if until != nil {
+			until()
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestMQTTBridgeWithBroker(t *testing.T) {
+	config := newTestBrokerConfig(t)
+	ingest := &fakeIngest{}
+	bridge := startTestBrokerBridge(t, config, ingest)
+	defer bridge.Stop()
+	publisher := newTestPublisher(t, config)
+
+	// the message id keeps the retries of the first message from counting
+	waitForStored(t, ingest, 1, 1, func() { publisher.publish(1, `{"message_id":"subscribed"}`) })
+
+	publisher.publish(7, `{"message_id":"m-1","temperature":21.5}`)
+	publisher.publish(7, `{"message_id":"m-1","temperature":21.5}`)
+	publisher.publish(8, `{"temperature":21.5}`)
+	publisher.publish(8, `{"temperature":21.5}`)
+	publisher.publish(9, `{"temperature":`)
+	// messages of one publisher arrive in order, so once the last one is
+	// stored every message before it has been handled
+	publisher.publish(10, `{"message_id":"last"}`)
+	waitForStored(t, ingest, 10, 1, nil)
+
+	tests := []struct {
+		name     string
+		deviceID uint32
+		stored   int
+	}{
+		{name: "redelivered message id", deviceID: 7, stored: 1},
+		{name: "repeated value without message id", deviceID: 8, stored: 2},
+		{name: "invalid JSON", deviceID: 9, stored: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ingest.storedFor(tt.deviceID); got != tt.stored {
+				t.Errorf("stored %d readings of device %d, want %d", got, tt.deviceID, tt.stored)
+			}
+		})
+	}
+}
+
+func TestMQTTBridgeRedeliversAfterReconnect(t *testing.T) {
+	config := newTestBrokerConfig(t)
+	ingest := &fakeIngest{}
+	bridge := startTestBrokerBridge(t, config, ingest)
+	publisher := newTestPublisher(t, config)
+
+	waitForStored(t, ingest, 1, 1, func() { publisher.publish(1, `{"message_id":"subscribed"}`) })
+
+	// the reading fails to store, so it is left unacknowledged
+	ingest.setErr(pkg.Errorf(pkg.INTERNAL_ERROR, "failed to add reading"))
+	publisher.publish(7, `{"message_id":"m-1","temperature":21.5}`)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		ingest.mu.Lock()
+		attempts := len(ingest.payloads)
+		ingest.mu.Unlock()
+		if attempts > 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the failing reading was never handed to ingest")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	bridge.Stop()
+
+	// the persistent session brings the reading back to the next connection
+	ingest.setErr(nil)
+	bridge = startTestBrokerBridge(t, config, ingest)
+	defer bridge.Stop()
+
+	waitForStored(t, ingest, 7, 1, nil)
+}
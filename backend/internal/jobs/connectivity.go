@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// RunConnectivityChecker periodically works out which devices are online,
+// stale or offline from when they last reported. It blocks until ctx is
+// cancelled.
+func RunConnectivityChecker(ctx context.Context, config pkg.Config, devices repository.DeviceRepository) {
+	runEvery(ctx, "device connectivity", config.DEVICE_CONNECTIVITY_CHECK_INTERVAL, func(ctx context.Context) error {
+		changes, err := devices.UpdateDevicesConnectivity(ctx, config.DEVICE_STALE_AFTER_INTERVALS, config.DEVICE_OFFLINE_AFTER_INTERVALS)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			log.Printf("device %d is now %s", change.DeviceID, change.Connectivity)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,28 @@
+// Package jobs holds the background loops the server runs next to the HTTP
+// API.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runEvery calls fn once straight away and then on every tick of interval
+// until ctx is cancelled. Errors are logged and the loop keeps going.
+func runEvery(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("%s job failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// RunPartitionMaintenance keeps monthly sensor_readings partitions created
+// ahead of the readings that will land in them. It blocks until ctx is
+// cancelled.
+func RunPartitionMaintenance(ctx context.Context, config pkg.Config, devices repository.DeviceRepository) {
+	runEvery(ctx, "reading partition", config.READING_PARTITION_CHECK_INTERVAL, func(ctx context.Context) error {
+		return devices.EnsureReadingPartitions(ctx, config.READING_PARTITION_MONTHS_AHEAD)
+	})
+}
@@ -0,0 +1,188 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// errReportJobLeaseLost stops a report whose job was claimed again by another
+// worker after this one failed to renew the lease in time.
+var errReportJobLeaseLost = errors.New("report job lease lost")
+
+var reportJobNames = map[string]string{
+	repository.ReportJobKindReadingsXLSX:  "Readings workbook",
+	repository.ReportJobKindExperimentPDF: "Experiment PDF",
+}
+
+// RunReportJobs starts the report job workers and blocks until ctx is
+// cancelled. Workers claim jobs with SKIP LOCKED, so several can run here or
+// on other replicas without building the same report twice. A job left
+// running by a stopped server is claimed again once its lease runs out.
+func RunReportJobs(ctx context.Context, config pkg.Config, jobs repository.ReportJobRepository, report services.ReportService, email pkg.EmailSender) {
+	w := &reportWorker{config: config, jobs: jobs, report: report, email: email}
+
+	workers := max(config.REPORT_JOB_WORKERS, 1)
+	done := make(chan struct{}, workers)
+	for range workers {
+		go func() {
+			runEvery(ctx, "report jobs", config.REPORT_JOB_POLL_INTERVAL, w.drain)
+			done <- struct{}{}
+		}()
+	}
+
+	runEvery(ctx, "report job cleanup", time.Hour, func(ctx context.Context) error {
+		deleted, err := jobs.DeleteExpiredReportJobs(ctx)
+		if deleted > 0 {
+			log.Printf("deleted %d expired report jobs", deleted)
+		}
+		return err
+	})
+
+	for range workers {
+		<-done
+	}
+}
+
+type reportWorker struct {
+	config pkg.Config
+	jobs   repository.ReportJobRepository
+	report services.ReportService
+	email  pkg.EmailSender
+}
+
+// drain runs queued jobs one after the other until none are left.
+func (w *reportWorker) drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		job, err := w.jobs.ClaimReportJob(ctx, w.config.REPORT_JOB_LEASE)
+		if err != nil || job == nil {
+			return err
+		}
+
+		w.run(ctx, job)
+	}
+
+	return nil
+}
+
+func (w *reportWorker) run(ctx context.Context, job *repository.ReportJob) {
+	if int(job.Attempts) > w.config.REPORT_JOB_MAX_ATTEMPTS {
+		w.fail(ctx, job, fmt.Sprintf("gave up after %d attempts", job.Attempts-1))
+		return
+	}
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go w.renewLease(jobCtx, cancel, job)
+
+	progress := func(percent int32) {
+		if err := w.jobs.UpdateReportJobProgress(jobCtx, job.ID, job.Attempts, percent); err != nil {
+			log.Printf("failed to update progress of report job %d: %v", job.ID, err)
+		}
+	}
+
+	file, err := w.report.RunReportJob(jobCtx, job, progress)
+	if errors.Is(context.Cause(jobCtx), errReportJobLeaseLost) {
+		log.Printf("report job %d was claimed by another worker, dropping attempt %d", job.ID, job.Attempts)
+		return
+	}
+	cancel(nil)
+
+	if err != nil {
+		// on shutdown the job stays running and is claimed again when
+		// its lease runs out
+		if ctx.Err() != nil {
+			return
+		}
+
+		w.fail(ctx, job, pkg.ErrorMessage(err))
+		return
+	}
+
+	finished, err := w.jobs.CompleteReportJob(ctx, job.ID, job.Attempts, file, w.config.REPORT_JOB_TTL)
+	if err != nil {
+		log.Printf("failed to complete report job %d: %v", job.ID, err)
+		return
+	}
+
+	w.notify(finished)
+}
+
+// renewLease extends the lease of the job every third of it until ctx is
+// done, so a long report is not claimed again while it is still being built.
+// It cancels ctx when the lease turns out to be lost.
+func (w *reportWorker) renewLease(ctx context.Context, cancel context.CancelCauseFunc, job *repository.ReportJob) {
+	ticker := time.NewTicker(w.config.REPORT_JOB_LEASE / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := w.jobs.RenewReportJobLease(ctx, job.ID, job.Attempts, w.config.REPORT_JOB_LEASE)
+		if err == nil || ctx.Err() != nil {
+			continue
+		}
+
+		if pkg.ErrorCode(err) == pkg.NOT_FOUND_ERROR {
+			cancel(errReportJobLeaseLost)
+			return
+		}
+
+		log.Printf("failed to renew lease of report job %d: %v", job.ID, err)
+	}
+}
+
+func (w *reportWorker) fail(ctx context.Context, job *repository.ReportJob, message string) {
+	failed, err := w.jobs.FailReportJob(ctx, job.ID, job.Attempts, message, w.config.REPORT_JOB_TTL)
+	if err != nil {
+		log.Printf("failed to mark report job %d as failed: %v", job.ID, err)
+		return
+	}
+
+	w.notify(failed)
+}
+
+// notify emails whoever requested the job once it has finished either way.
+func (w *reportWorker) notify(job *repository.ReportJob) {
+	if job.NotifyEmail == "" {
+		return
+	}
+
+	failed := job.Status == repository.ReportJobStatusFailed
+	title := fmt.Sprintf("Your report #%d is ready", job.ID)
+	if failed {
+		title = fmt.Sprintf("Your report #%d failed", job.ID)
+	}
+
+	expires := ""
+	if job.ExpiresAt != nil {
+		expires = job.ExpiresAt.Format(time.RFC1123)
+	}
+
+	emailBody, err := pkg.GenerateText("report_job", pkg.ReportJobTemplate, map[string]any{
+		"Title":   title,
+		"Failed":  failed,
+		"Report":  reportJobNames[job.Kind],
+		"Error":   job.Error,
+		"Link":    services.ReportJobDownloadURL(w.config.REPORT_DOWNLOAD_BASE_URL, job),
+		"Expires": expires,
+	})
+	if err != nil {
+		log.Printf("failed to render email for report job %d: %v", job.ID, err)
+		return
+	}
+
+	if err := w.email.SendMail(title, emailBody, "text/html", []string{job.NotifyEmail}, nil, nil, nil, nil); err != nil {
+		log.Printf("failed to send email for report job %d: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// RunReadingRetention periodically rolls raw readings up into minute and
+// hourly rollups and then deletes whatever is past its retention policy.
+// Rolling up first means nothing is deleted before it has been summarised.
+// It blocks until ctx is cancelled.
+func RunReadingRetention(ctx context.Context, config pkg.Config, retention repository.RetentionRepository) {
+	runEvery(ctx, "reading retention", config.READING_ROLLUP_INTERVAL, func(ctx context.Context) error {
+		if err := retention.RollupReadings(ctx, config.READING_ROLLUP_LAG, config.READING_ROLLUP_WINDOW); err != nil {
+			return err
+		}
+
+		result, err := retention.ApplyRetention(ctx, config.READING_RETENTION_BATCH_SIZE)
+		if err != nil {
+			return err
+		}
+
+		if result.Readings > 0 || result.MinuteRollups > 0 || result.HourRollups > 0 {
+			log.Printf("retention deleted %d readings, %d minute rollups and %d hour rollups", result.Readings, result.MinuteRollups, result.HourRollups)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// RunReportSchedules emails scheduled reports when they are due. Every
+// replica runs this loop; claiming a schedule takes its row lock, so each run
+// is sent by one replica only. It blocks until ctx is cancelled.
+func RunReportSchedules(ctx context.Context, config pkg.Config, schedules repository.ReportScheduleRepository, report services.ReportService, email pkg.EmailSender) {
+	runEvery(ctx, "report schedules", config.REPORT_SCHEDULE_CHECK_INTERVAL, func(ctx context.Context) error {
+		for ctx.Err() == nil {
+			schedule, err := schedules.ClaimDueReportSchedule(ctx, time.Now())
+			if err != nil || schedule == nil {
+				return err
+			}
+
+			message := ""
+			if err := sendScheduledReport(ctx, schedule, report, email); err != nil {
+				log.Printf("report schedule %d failed: %v", schedule.ID, err)
+				message = pkg.ErrorMessage(err)
+			}
+
+			if err := schedules.SetReportScheduleError(ctx, schedule.ID, message); err != nil {
+				log.Printf("failed to record result of report schedule %d: %v", schedule.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// sendScheduledReport renders the window ending at the claimed run time and
+// emails it to the schedule recipients as attachments.
+func sendScheduledReport(ctx context.Context, schedule *repository.ReportSchedule, report services.ReportService, email pkg.EmailSender) error {
+	end := schedule.NextRunAt
+	start := end.Add(-schedule.Window())
+
+	files, err := report.RenderScheduledReport(ctx, schedule, start, end)
+	if err != nil {
+		return err
+	}
+
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return pkg.Errorf(pkg.INVALID_ERROR, "unknown timezone %q", schedule.Timezone)
+	}
+
+	emailBody, err := pkg.GenerateText("scheduled_report", pkg.ScheduledReportTemplate, map[string]any{
+		"Name":  schedule.Name,
+		"Files": len(files),
+		"Start": start.In(location).Format("2006-01-02 15:04 MST"),
+		"End":   end.In(location).Format("2006-01-02 15:04 MST"),
+	})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	data := make([][]byte, 0, len(files))
+	mimeType := ""
+	for _, file := range files {
+		names = append(names, file.FileName)
+		data = append(data, file.Data)
+		mimeType = file.ContentType
+	}
+
+	subject := fmt.Sprintf("%s - %s", schedule.Name, end.In(location).Format("2006-01-02"))
+	if err := email.SendMail(subject, emailBody, mimeType, schedule.Recipients, nil, nil, names, data); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to send scheduled report: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// AggregateReadings buckets a device's numeric payload values in Postgres and
+// returns the requested aggregate functions for every bucket and key. Parts
+// of the range past the device's raw retention are aggregated from rollups,
+// with buckets no finer than the rollup they come from.
+func (r *DeviceRepository) AggregateReadings(ctx context.Context, filter *repository.AggregateFilter) ([]*repository.ReadingBucket, error) {
+	if filter.Bucket <= 0 {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "bucket size must be provided")
+	}
+
+	if !filter.End.After(filter.Start) {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "end time must be after start time")
+	}
+
+	ranges, err := r.readingRanges(ctx, filter.DeviceID, filter.Start, filter.End)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	if len(filter.Keys) > 0 {
+		keys = filter.Keys
+	}
+
+	rows := []generated.AggregateReadingsRow{}
+	for _, rg := range ranges {
+		switch rg.resolution {
+		case repository.ResolutionRaw:
+			rawRows, err := r.queries.AggregateReadings(ctx, generated.AggregateReadingsParams{
+				BucketSeconds: int64(filter.Bucket.Seconds()),
+				DeviceID:      int64(filter.DeviceID),
+				StartTime:     rg.start,
+				EndTime:       rg.end,
+				Keys:          keys,
+			})
+			if err != nil {
+				return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to aggregate readings: %s", err.Error())
+			}
+
+			rows = append(rows, rawRows...)
+
+		case repository.ResolutionMinute:
+			minuteRows, err := r.queries.AggregateMinuteRollups(ctx, generated.AggregateMinuteRollupsParams{
+				BucketSeconds: int64(max(filter.Bucket, time.Minute).Seconds()),
+				DeviceID:      int64(filter.DeviceID),
+				StartTime:     rg.start,
+				EndTime:       rg.end,
+				Keys:          keys,
+			})
+			if err != nil {
+				return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to aggregate minute rollups: %s", err.Error())
+			}
+
+			for _, row := range minuteRows {
+				rows = append(rows, generated.AggregateReadingsRow(row))
+			}
+
+		case repository.ResolutionHour:
+			hourRows, err := r.queries.AggregateHourRollups(ctx, generated.AggregateHourRollupsParams{
+				BucketSeconds: int64(max(filter.Bucket, time.Hour).Seconds()),
+				DeviceID:      int64(filter.DeviceID),
+				StartTime:     rg.start,
+				EndTime:       rg.end,
+				Keys:          keys,
+			})
+			if err != nil {
+				return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to aggregate hour rollups: %s", err.Error())
+			}
+
+			for _, row := range hourRows {
+				rows = append(rows, generated.AggregateReadingsRow(row))
+			}
+		}
+	}
+
+	if len(ranges) > 1 {
+		rows = mergeAggregateRows(rows)
+	}
+
+	return mapAggregateRowsToBuckets(rows, filter.Functions), nil
+}
+
+// mergeAggregateRows combines rows of the same bucket and key coming from
+// neighbouring ranges. Rows must be ordered oldest range first so the last
+// value of the newer range wins.
+func mergeAggregateRows(rows []generated.AggregateReadingsRow) []generated.AggregateReadingsRow {
+	type rowKey struct {
+		bucket int64
+		key    string
+	}
+
+	merged := make([]generated.AggregateReadingsRow, 0, len(rows))
+	index := make(map[rowKey]int, len(rows))
+	for _, row := range rows {
+		k := rowKey{bucket: row.Bucket.UnixNano(), key: row.Key}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(merged)
+			merged = append(merged, row)
+			continue
+		}
+
+		existing := &merged[i]
+		count := existing.Count + row.Count
+		existing.AvgValue = (existing.AvgValue*float64(existing.Count) + row.AvgValue*float64(row.Count)) / float64(count)
+		existing.Count = count
+		existing.MinValue = min(existing.MinValue, row.MinValue)
+		existing.MaxValue = max(existing.MaxValue, row.MaxValue)
+		existing.LastValue = row.LastValue
+	}
+
+	slices.SortStableFunc(merged, func(a, b generated.AggregateReadingsRow) int {
+		if c := a.Bucket.Compare(b.Bucket); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return merged
+}
+
+func mapAggregateRowsToBuckets(rows []generated.AggregateReadingsRow, functions []string) []*repository.ReadingBucket {
+	buckets := []*repository.ReadingBucket{}
+
+	var current *repository.ReadingBucket
+	for _, row := range rows {
+		// rows are ordered by bucket so a new bucket starts whenever it changes
+		if current == nil || !current.Bucket.Equal(row.Bucket) {
+			current = &repository.ReadingBucket{
+				Bucket: row.Bucket,
+				Values: map[string]map[string]float64{},
+			}
+			buckets = append(buckets, current)
+		}
+
+		values := make(map[string]float64, len(functions))
+		for _, function := range functions {
+			switch function {
+			case repository.AggregateAvg:
+				values[function] = row.AvgValue
+			case repository.AggregateMin:
+				values[function] = row.MinValue
+			case repository.AggregateMax:
+				values[function] = row.MaxValue
+			case repository.AggregateCount:
+				values[function] = float64(row.Count)
+			case repository.AggregateLast:
+				values[function] = row.LastValue
+			}
+		}
+
+		current.Values[row.Key] = values
+	}
+
+	return buckets
+}
@@ -0,0 +1,314 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	_ repository.AlertRepository   = (*AlertRepository)(nil)
+	_ repository.AlertEvaluationTx = (*AlertRepository)(nil)
+)
+
+type AlertRepository struct {
+	store   *Store
+	queries *generated.Queries
+}
+
+func NewAlertRepository(store *Store) *AlertRepository {
+	return &AlertRepository{store: store, queries: generated.New(store.pool)}
+}
+
+func (r *AlertRepository) CreateAlertRule(ctx context.Context, rule *repository.AlertRule) (*repository.AlertRule, error) {
+	dbRule, err := r.queries.CreateAlertRule(ctx, generated.CreateAlertRuleParams{
+		DeviceID:        int64(rule.DeviceID),
+		Name:            rule.Name,
+		Key:             rule.Key,
+		Comparator:      rule.Comparator,
+		Threshold:       rule.Threshold,
+		DurationSeconds: rule.DurationSeconds,
+		Severity:        rule.Severity,
+		NotifyEmails:    notifyEmails(rule.NotifyEmails),
+		Enabled:         rule.Enabled,
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "device with id %d not found", rule.DeviceID)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create alert rule: %s", err.Error())
+	}
+
+	return mapDBAlertRuleToAlertRule(dbRule), nil
+}
+
+func (r *AlertRepository) GetAlertRuleByID(ctx context.Context, id uint32) (*repository.AlertRule, error) {
+	dbRule, err := r.queries.GetAlertRuleByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "alert rule with id %d not found", id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get alert rule: %s", err.Error())
+	}
+
+	return mapDBAlertRuleToAlertRule(dbRule), nil
+}
+
+func (r *AlertRepository) ListAlertRules(ctx context.Context, deviceID *uint32) ([]*repository.AlertRule, error) {
+	deviceIDParam := pgtype.Int8{Valid: false}
+	if deviceID != nil {
+		deviceIDParam = pgtype.Int8{Int64: int64(*deviceID), Valid: true}
+	}
+
+	dbRules, err := r.queries.ListAlertRules(ctx, deviceIDParam)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list alert rules: %s", err.Error())
+	}
+
+	return mapDBAlertRulesToAlertRules(dbRules), nil
+}
+
+// EvaluateDeviceAlertRules holds the rule rows of the device until fn
+// returns, so the breach state of a rule is never updated from two readings
+// at once, whichever replica ingests them. The repository passed to fn runs
+// its queries in that transaction.
+func (r *AlertRepository) EvaluateDeviceAlertRules(ctx context.Context, deviceID uint32, fn func(rules []*repository.AlertRule, tx repository.AlertEvaluationTx) error) error {
+	return r.store.ExecTx(ctx, func(q *generated.Queries) error {
+		dbRules, err := q.LockEnabledAlertRulesByDevice(ctx, int64(deviceID))
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to lock device alert rules: %s", err.Error())
+		}
+
+		if len(dbRules) == 0 {
+			return nil
+		}
+
+		return fn(mapDBAlertRulesToAlertRules(dbRules), &AlertRepository{store: r.store, queries: q})
+	})
+}
+
+// UpdateAlertRule replaces every editable field of the rule and clears any
+// breach in progress, so the new condition is evaluated from scratch.
+func (r *AlertRepository) UpdateAlertRule(ctx context.Context, rule *repository.AlertRule) (*repository.AlertRule, error) {
+	dbRule, err := r.queries.UpdateAlertRule(ctx, generated.UpdateAlertRuleParams{
+		Name:            rule.Name,
+		Key:             rule.Key,
+		Comparator:      rule.Comparator,
+		Threshold:       rule.Threshold,
+		DurationSeconds: rule.DurationSeconds,
+		Severity:        rule.Severity,
+		NotifyEmails:    notifyEmails(rule.NotifyEmails),
+		Enabled:         rule.Enabled,
+		ID:              int64(rule.ID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "alert rule with id %d not found", rule.ID)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update alert rule: %s", err.Error())
+	}
+
+	return mapDBAlertRuleToAlertRule(dbRule), nil
+}
+
+func (r *AlertRepository) DeleteAlertRule(ctx context.Context, id uint32) error {
+	deleted, err := r.queries.DeleteAlertRule(ctx, int64(id))
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete alert rule: %s", err.Error())
+	}
+
+	if deleted == 0 {
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "alert rule with id %d not found", id)
+	}
+
+	return nil
+}
+
+func (r *AlertRepository) SetAlertRuleBreachStartedAt(ctx context.Context, id uint32, startedAt *time.Time) error {
+	startedAtParam := pgtype.Timestamptz{Valid: false}
+	if startedAt != nil {
+		startedAtParam = pgtype.Timestamptz{Time: *startedAt, Valid: true}
+	}
+
+	err := r.queries.SetAlertRuleBreachStartedAt(ctx, generated.SetAlertRuleBreachStartedAtParams{
+		BreachStartedAt: startedAtParam,
+		ID:              int64(id),
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update alert rule breach: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *AlertRepository) ListAlertIncidents(ctx context.Context, filter *repository.FilterAlertIncidents) ([]*repository.AlertIncident, *pkg.Pagination, error) {
+	listParams := generated.ListAlertIncidentsParams{
+		Status:   pgtype.Text{Valid: false},
+		DeviceID: pgtype.Int8{Valid: false},
+		Limit:    int32(filter.Pagination.PageSize),
+		Offset:   pkg.Offset(filter.Pagination.Page, filter.Pagination.PageSize),
+	}
+
+	if filter.Status != nil {
+		listParams.Status = pgtype.Text{String: *filter.Status, Valid: true}
+	}
+	if filter.DeviceID != nil {
+		listParams.DeviceID = pgtype.Int8{Int64: int64(*filter.DeviceID), Valid: true}
+	}
+
+	dbIncidents, err := r.queries.ListAlertIncidents(ctx, listParams)
+	if err != nil {
+		return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list alert incidents: %s", err.Error())
+	}
+
+	totalCount, err := r.queries.CountListAlertIncidents(ctx, generated.CountListAlertIncidentsParams{
+		Status:   listParams.Status,
+		DeviceID: listParams.DeviceID,
+	})
+	if err != nil {
+		return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to count alert incidents: %s", err.Error())
+	}
+
+	incidents := make([]*repository.AlertIncident, 0, len(dbIncidents))
+	for _, dbIncident := range dbIncidents {
+		incidents = append(incidents, mapDBAlertIncidentToAlertIncident(dbIncident))
+	}
+
+	return incidents, pkg.CalculatePagination(uint32(totalCount), filter.Pagination.PageSize, filter.Pagination.Page), nil
+}
+
+func (r *AlertRepository) GetAlertIncidentByID(ctx context.Context, id uint32) (*repository.AlertIncident, error) {
+	dbIncident, err := r.queries.GetAlertIncidentByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "alert incident with id %d not found", id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get alert incident: %s", err.Error())
+	}
+
+	return mapDBAlertIncidentToAlertIncident(dbIncident), nil
+}
+
+// GetActiveAlertIncident returns the open or acknowledged incident of a rule,
+// or nil when the rule has none.
+func (r *AlertRepository) GetActiveAlertIncident(ctx context.Context, ruleID uint32) (*repository.AlertIncident, error) {
+	dbIncident, err := r.queries.GetActiveAlertIncidentByRule(ctx, int64(ruleID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get active alert incident: %s", err.Error())
+	}
+
+	return mapDBAlertIncidentToAlertIncident(dbIncident), nil
+}
+
+func (r *AlertRepository) OpenAlertIncident(ctx context.Context, rule *repository.AlertRule, value float64, openedAt time.Time) (*repository.AlertIncident, error) {
+	dbIncident, err := r.queries.CreateAlertIncident(ctx, generated.CreateAlertIncidentParams{
+		RuleID:       int64(rule.ID),
+		DeviceID:     int64(rule.DeviceID),
+		Severity:     rule.Severity,
+		TriggerValue: value,
+		OpenedAt:     openedAt,
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.UNIQUE_VIOLATION {
+			return nil, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "alert rule %d already has an active incident", rule.ID)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to open alert incident: %s", err.Error())
+	}
+
+	return mapDBAlertIncidentToAlertIncident(dbIncident), nil
+}
+
+func (r *AlertRepository) AcknowledgeAlertIncident(ctx context.Context, id uint32, userID uint32) (*repository.AlertIncident, error) {
+	dbIncident, err := r.queries.AcknowledgeAlertIncident(ctx, generated.AcknowledgeAlertIncidentParams{
+		AcknowledgedBy: pgtype.Int8{Int64: int64(userID), Valid: true},
+		ID:             int64(id),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "alert incident with id %d is not open", id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to acknowledge alert incident: %s", err.Error())
+	}
+
+	return mapDBAlertIncidentToAlertIncident(dbIncident), nil
+}
+
+func (r *AlertRepository) ResolveAlertIncident(ctx context.Context, id uint32, resolvedAt time.Time) (*repository.AlertIncident, error) {
+	dbIncident, err := r.queries.ResolveAlertIncident(ctx, generated.ResolveAlertIncidentParams{
+		ResolvedAt: pgtype.Timestamptz{Time: resolvedAt, Valid: true},
+		ID:         int64(id),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "alert incident with id %d is already resolved", id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to resolve alert incident: %s", err.Error())
+	}
+
+	return mapDBAlertIncidentToAlertIncident(dbIncident), nil
+}
+
+// notifyEmails keeps the column NOT NULL when a rule has no recipients.
+func notifyEmails(emails []string) []string {
+	if emails == nil {
+		return []string{}
+	}
+
+	return emails
+}
+
+func mapDBAlertRuleToAlertRule(dbRule generated.AlertRule) *repository.AlertRule {
+	return &repository.AlertRule{
+		ID:              uint32(dbRule.ID),
+		DeviceID:        uint32(dbRule.DeviceID),
+		Name:            dbRule.Name,
+		Key:             dbRule.Key,
+		Comparator:      dbRule.Comparator,
+		Threshold:       dbRule.Threshold,
+		DurationSeconds: dbRule.DurationSeconds,
+		Severity:        dbRule.Severity,
+		NotifyEmails:    notifyEmails(dbRule.NotifyEmails),
+		Enabled:         dbRule.Enabled,
+		BreachStartedAt: pgTimestamptzToTimePtr(dbRule.BreachStartedAt),
+		CreatedAt:       dbRule.CreatedAt,
+	}
+}
+
+func mapDBAlertRulesToAlertRules(dbRules []generated.AlertRule) []*repository.AlertRule {
+	rules := make([]*repository.AlertRule, 0, len(dbRules))
+	for _, dbRule := range dbRules {
+		rules = append(rules, mapDBAlertRuleToAlertRule(dbRule))
+	}
+
+	return rules
+}
+
+func mapDBAlertIncidentToAlertIncident(dbIncident generated.AlertIncident) *repository.AlertIncident {
+	var acknowledgedBy *uint32
+	if dbIncident.AcknowledgedBy.Valid {
+		userID := uint32(dbIncident.AcknowledgedBy.Int64)
+		acknowledgedBy = &userID
+	}
+
+	return &repository.AlertIncident{
+		ID:             uint32(dbIncident.ID),
+		RuleID:         uint32(dbIncident.RuleID),
+		DeviceID:       uint32(dbIncident.DeviceID),
+		Severity:       dbIncident.Severity,
+		Status:         dbIncident.Status,
+		TriggerValue:   dbIncident.TriggerValue,
+		OpenedAt:       dbIncident.OpenedAt,
+		AcknowledgedAt: pgTimestamptzToTimePtr(dbIncident.AcknowledgedAt),
+		AcknowledgedBy: acknowledgedBy,
+		ResolvedAt:     pgTimestamptzToTimePtr(dbIncident.ResolvedAt),
+	}
+}
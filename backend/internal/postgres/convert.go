@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Conversions between nullable columns and the optional values of the
+// repository types.
+
+func stringToPgText(value string) pgtype.Text {
+	if value == "" {
+		return pgtype.Text{Valid: false}
+	}
+
+	return pgtype.Text{String: value, Valid: true}
+}
+
+func pgTimestamptzToTimePtr(value pgtype.Timestamptz) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.Time
+}
+
+func uint32PtrToPgInt8(value *uint32) pgtype.Int8 {
+	if value == nil {
+		return pgtype.Int8{Valid: false}
+	}
+
+	return pgtype.Int8{Int64: int64(*value), Valid: true}
+}
+
+func pgInt8ToUint32Ptr(value pgtype.Int8) *uint32 {
+	if !value.Valid {
+		return nil
+	}
+
+	converted := uint32(value.Int64)
+	return &converted
+}
+
+func int32PtrToPgInt4(value *int32) pgtype.Int4 {
+	if value == nil {
+		return pgtype.Int4{Valid: false}
+	}
+
+	return pgtype.Int4{Int32: *value, Valid: true}
+}
+
+func pgInt4ToInt32Ptr(value pgtype.Int4) *int32 {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.Int32
+}
+
+func float64PtrToPgFloat8(f *float64) pgtype.Float8 {
+	if f == nil {
+		return pgtype.Float8{Valid: false}
+	}
+
+	return pgtype.Float8{Float64: *f, Valid: true}
+}
+
+func pgFloat8ToFloat64Ptr(f pgtype.Float8) *float64 {
+	if !f.Valid {
+		return nil
+	}
+
+	return &f.Float64
+}
@@ -14,18 +14,30 @@ import (
 )
 
 type PostgresRepo struct {
-	DeviceRepository     *DeviceRepository
-	UserRepository       *UserRepository
-	ReactorRepository    *ReactorRepository
-	ExperimentRepository *ExperimentRepository
+	DeviceRepository             *DeviceRepository
+	UserRepository               *UserRepository
+	ReactorRepository            *ReactorRepository
+	ExperimentRepository         *ExperimentRepository
+	ExperimentTemplateRepository *ExperimentTemplateRepository
+	AlertRepository              *AlertRepository
+	RetentionRepository          *RetentionRepository
+	ReportJobRepository          *ReportJobRepository
+	ReportScheduleRepository     *ReportScheduleRepository
+	FileRepository               *FileRepository
 }
 
 func NewPostgresRepo(store *Store) *PostgresRepo {
 	return &PostgresRepo{
-		DeviceRepository:     NewDeviceRepository(store),
-		UserRepository:       NewUserRepository(store),
-		ReactorRepository:    NewReactorRepository(store),
-		ExperimentRepository: NewExperimentRepository(store),
+		DeviceRepository:             NewDeviceRepository(store),
+		UserRepository:               NewUserRepository(store),
+		ReactorRepository:            NewReactorRepository(store),
+		ExperimentRepository:         NewExperimentRepository(store),
+		ExperimentTemplateRepository: NewExperimentTemplateRepository(store),
+		AlertRepository:              NewAlertRepository(store),
+		RetentionRepository:          NewRetentionRepository(store),
+		ReportJobRepository:          NewReportJobRepository(store),
+		ReportScheduleRepository:     NewReportScheduleRepository(store),
+		FileRepository:               NewFileRepository(store),
 	}
 }
 
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+func (r *DeviceRepository) CreateDeviceAPIKey(ctx context.Context, deviceID uint32, name string) (*repository.DeviceAPIKey, error) {
+	if _, err := r.GetDeviceByID(ctx, deviceID); err != nil {
+		return nil, err
+	}
+
+	key, prefix, err := pkg.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	dbKey, err := r.queries.CreateDeviceAPIKey(ctx, generated.CreateDeviceAPIKeyParams{
+		DeviceID: int64(deviceID),
+		Name:     name,
+		Prefix:   prefix,
+		KeyHash:  pkg.HashAPIKey(key),
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create device api key: %s", err.Error())
+	}
+
+	apiKey := mapDBDeviceAPIKeyToDeviceAPIKey(dbKey)
+	apiKey.Key = key
+
+	return apiKey, nil
+}
+
+func (r *DeviceRepository) ListDeviceAPIKeys(ctx context.Context, deviceID uint32) ([]*repository.DeviceAPIKey, error) {
+	dbKeys, err := r.queries.ListDeviceAPIKeys(ctx, int64(deviceID))
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list device api keys: %s", err.Error())
+	}
+
+	keys := make([]*repository.DeviceAPIKey, 0, len(dbKeys))
+	for _, dbKey := range dbKeys {
+		keys = append(keys, mapDBDeviceAPIKeyToDeviceAPIKey(dbKey))
+	}
+
+	return keys, nil
+}
+
+func (r *DeviceRepository) RotateDeviceAPIKey(ctx context.Context, deviceID, keyID uint32) (*repository.DeviceAPIKey, error) {
+	key, prefix, err := pkg.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var apiKey *repository.DeviceAPIKey
+	err = r.store.ExecTx(ctx, func(q *generated.Queries) error {
+		oldKey, err := q.RevokeDeviceAPIKey(ctx, generated.RevokeDeviceAPIKeyParams{
+			ID:       int64(keyID),
+			DeviceID: int64(deviceID),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return pkg.Errorf(pkg.NOT_FOUND_ERROR, "active api key with id %d not found for device %d", keyID, deviceID)
+			}
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to revoke device api key: %s", err.Error())
+		}
+
+		dbKey, err := q.CreateDeviceAPIKey(ctx, generated.CreateDeviceAPIKeyParams{
+			DeviceID: oldKey.DeviceID,
+			Name:     oldKey.Name,
+			Prefix:   prefix,
+			KeyHash:  pkg.HashAPIKey(key),
+		})
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create device api key: %s", err.Error())
+		}
+
+		apiKey = mapDBDeviceAPIKeyToDeviceAPIKey(dbKey)
+		apiKey.Key = key
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *DeviceRepository) RevokeDeviceAPIKey(ctx context.Context, deviceID, keyID uint32) error {
+	_, err := r.queries.RevokeDeviceAPIKey(ctx, generated.RevokeDeviceAPIKeyParams{
+		ID:       int64(keyID),
+		DeviceID: int64(deviceID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pkg.Errorf(pkg.NOT_FOUND_ERROR, "active api key with id %d not found for device %d", keyID, deviceID)
+		}
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to revoke device api key: %s", err.Error())
+	}
+
+	return nil
+}
+
+// AuthenticateDeviceAPIKey resolves a plaintext API key to the device it was
+// issued for. Revoked keys and keys of deleted devices are rejected.
+func (r *DeviceRepository) AuthenticateDeviceAPIKey(ctx context.Context, key string) (uint32, error) {
+	dbKey, err := r.queries.GetActiveDeviceAPIKeyByHash(ctx, pkg.HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, pkg.Errorf(pkg.AUTHENTICATION_ERROR, "invalid api key")
+		}
+		return 0, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get device api key: %s", err.Error())
+	}
+
+	if err := r.queries.TouchDeviceAPIKey(ctx, dbKey.ID); err != nil {
+		return 0, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update device api key: %s", err.Error())
+	}
+
+	return uint32(dbKey.DeviceID), nil
+}
+
+func mapDBDeviceAPIKeyToDeviceAPIKey(dbKey generated.DeviceApiKey) *repository.DeviceAPIKey {
+	var lastUsedAt, revokedAt *time.Time
+	if dbKey.LastUsedAt.Valid {
+		lastUsedAt = &dbKey.LastUsedAt.Time
+	}
+	if dbKey.RevokedAt.Valid {
+		revokedAt = &dbKey.RevokedAt.Time
+	}
+
+	return &repository.DeviceAPIKey{
+		ID:         uint32(dbKey.ID),
+		DeviceID:   uint32(dbKey.DeviceID),
+		Name:       dbKey.Name,
+		Prefix:     dbKey.Prefix,
+		LastUsedAt: lastUsedAt,
+		RevokedAt:  revokedAt,
+		CreatedAt:  dbKey.CreatedAt,
+	}
+}
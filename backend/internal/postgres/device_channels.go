@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+func (r *DeviceRepository) CreateDeviceChannel(ctx context.Context, channel *repository.DeviceChannel) (*repository.DeviceChannel, error) {
+	if _, err := r.GetDeviceByID(ctx, channel.DeviceID); err != nil {
+		return nil, err
+	}
+
+	dbChannel, err := r.queries.CreateDeviceChannel(ctx, generated.CreateDeviceChannelParams{
+		DeviceID: int64(channel.DeviceID),
+		Name:     channel.Name,
+		Unit:     channel.Unit,
+		DataType: channel.DataType,
+		MinValue: float64PtrToPgFloat8(channel.Min),
+		MaxValue: float64PtrToPgFloat8(channel.Max),
+		Required: channel.Required,
+		Position: channel.Position,
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.UNIQUE_VIOLATION {
+			return nil, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "channel %s already exists for device %d", channel.Name, channel.DeviceID)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create device channel: %s", err.Error())
+	}
+
+	return mapDBDeviceChannelToDeviceChannel(dbChannel), nil
+}
+
+func (r *DeviceRepository) ListDeviceChannels(ctx context.Context, deviceID uint32) ([]*repository.DeviceChannel, error) {
+	dbChannels, err := r.queries.ListDeviceChannels(ctx, int64(deviceID))
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list device channels: %s", err.Error())
+	}
+
+	channels := make([]*repository.DeviceChannel, 0, len(dbChannels))
+	for _, dbChannel := range dbChannels {
+		channels = append(channels, mapDBDeviceChannelToDeviceChannel(dbChannel))
+	}
+
+	return channels, nil
+}
+
+func (r *DeviceRepository) UpdateDeviceChannel(ctx context.Context, channel *repository.DeviceChannel) (*repository.DeviceChannel, error) {
+	dbChannel, err := r.queries.UpdateDeviceChannel(ctx, generated.UpdateDeviceChannelParams{
+		ID:       int64(channel.ID),
+		DeviceID: int64(channel.DeviceID),
+		Name:     channel.Name,
+		Unit:     channel.Unit,
+		DataType: channel.DataType,
+		MinValue: float64PtrToPgFloat8(channel.Min),
+		MaxValue: float64PtrToPgFloat8(channel.Max),
+		Required: channel.Required,
+		Position: channel.Position,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "channel with id %d not found for device %d", channel.ID, channel.DeviceID)
+		}
+		if pkg.PgxErrorCode(err) == pkg.UNIQUE_VIOLATION {
+			return nil, pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "channel %s already exists for device %d", channel.Name, channel.DeviceID)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update device channel: %s", err.Error())
+	}
+
+	return mapDBDeviceChannelToDeviceChannel(dbChannel), nil
+}
+
+func (r *DeviceRepository) DeleteDeviceChannel(ctx context.Context, deviceID, channelID uint32) error {
+	deleted, err := r.queries.DeleteDeviceChannel(ctx, generated.DeleteDeviceChannelParams{
+		ID:       int64(channelID),
+		DeviceID: int64(deviceID),
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete device channel: %s", err.Error())
+	}
+
+	if deleted == 0 {
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "channel with id %d not found for device %d", channelID, deviceID)
+	}
+
+	return nil
+}
+
+func mapDBDeviceChannelToDeviceChannel(dbChannel generated.DeviceChannel) *repository.DeviceChannel {
+	return &repository.DeviceChannel{
+		ID:        uint32(dbChannel.ID),
+		DeviceID:  uint32(dbChannel.DeviceID),
+		Name:      dbChannel.Name,
+		Unit:      dbChannel.Unit,
+		DataType:  dbChannel.DataType,
+		Min:       pgFloat8ToFloat64Ptr(dbChannel.MinValue),
+		Max:       pgFloat8ToFloat64Ptr(dbChannel.MaxValue),
+		Required:  dbChannel.Required,
+		Position:  dbChannel.Position,
+		CreatedAt: dbChannel.CreatedAt,
+	}
+}
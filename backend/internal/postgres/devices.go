@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
 	"github.com/Edwin9301/Zen/backend/internal/repository"
@@ -30,6 +31,8 @@ func (r *DeviceRepository) CreateDevice(ctx context.Context, device *repository.
 		ReactorID: pgtype.Int8{Valid: false},
 		Name:      device.Name,
 		Status:    device.Status,
+
+		ExpectedIntervalSeconds: device.ExpectedIntervalSeconds,
 	}
 
 	if device.ReactorID != 0 {
@@ -53,7 +56,14 @@ func (r *DeviceRepository) GetDeviceByID(ctx context.Context, id uint32) (*repos
 		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get device: %s", err.Error())
 	}
 
-	return mapDBDeviceToDevice(dbDevice), nil
+	device := mapDBDeviceToDevice(dbDevice)
+
+	device.Channels, err = r.ListDeviceChannels(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return device, nil
 }
 
 func (r *DeviceRepository) UpdateDevice(ctx context.Context, id uint32, update *repository.DeviceUpdate) (*repository.Device, error) {
@@ -62,6 +72,8 @@ func (r *DeviceRepository) UpdateDevice(ctx context.Context, id uint32, update *
 		ReactorID: pgtype.Int8{Valid: false},
 		Name:      pgtype.Text{Valid: false},
 		Status:    pgtype.Bool{Valid: false},
+
+		ExpectedIntervalSeconds: pgtype.Int4{Valid: false},
 	}
 
 	if update.ReactorID != nil {
@@ -85,6 +97,13 @@ func (r *DeviceRepository) UpdateDevice(ctx context.Context, id uint32, update *
 		}
 	}
 
+	if update.ExpectedIntervalSeconds != nil {
+		params.ExpectedIntervalSeconds = pgtype.Int4{
+			Int32: *update.ExpectedIntervalSeconds,
+			Valid: true,
+		}
+	}
+
 	dbDevice, err := r.queries.UpdateDevice(ctx, params)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -122,6 +141,20 @@ func (r *DeviceRepository) ListDevice(ctx context.Context) ([]*repository.Device
 	return devices, nil
 }
 
+func (r *DeviceRepository) ListDevicesByReactor(ctx context.Context, reactorID uint32) ([]*repository.Device, error) {
+	dbDevices, err := r.queries.ListDevicesByReactor(ctx, pgtype.Int8{Int64: int64(reactorID), Valid: true})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list reactor devices: %s", err.Error())
+	}
+
+	devices := make([]*repository.Device, 0, len(dbDevices))
+	for _, dbDevice := range dbDevices {
+		devices = append(devices, mapDBDeviceToDevice(dbDevice))
+	}
+
+	return devices, nil
+}
+
 func (r *DeviceRepository) GetDeviceStats(ctx context.Context) (*repository.DeviceStats, error) {
 	stats, err := r.queries.GetDeviceStats(ctx)
 	if err != nil {
@@ -132,10 +165,51 @@ func (r *DeviceRepository) GetDeviceStats(ctx context.Context) (*repository.Devi
 		TotalDevices:        stats.TotalDevices,
 		ActiveDevices:       stats.ActiveDevices,
 		InactiveDevices:     stats.InactiveDevices,
+		OnlineDevices:       stats.OnlineDevices,
+		StaleDevices:        stats.StaleDevices,
+		OfflineDevices:      stats.OfflineDevices,
 		TotalSensorReadings: stats.TotalSensorReadings,
 	}, nil
 }
 
+// UpdateDevicesConnectivity recomputes the connectivity of every device. A
+// device is online while its last reading is within staleAfter reporting
+// intervals, stale up to offlineAfter intervals and offline after that.
+// Only devices whose state changed are returned.
+func (r *DeviceRepository) UpdateDevicesConnectivity(ctx context.Context, staleAfter, offlineAfter float64) ([]repository.ConnectivityChange, error) {
+	rows, err := r.queries.UpdateDevicesConnectivity(ctx, generated.UpdateDevicesConnectivityParams{
+		StaleAfter:   staleAfter,
+		OfflineAfter: offlineAfter,
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update device connectivity: %s", err.Error())
+	}
+
+	changes := make([]repository.ConnectivityChange, 0, len(rows))
+	for _, row := range rows {
+		changes = append(changes, repository.ConnectivityChange{
+			DeviceID:     uint32(row.ID),
+			Connectivity: row.Connectivity,
+		})
+	}
+
+	return changes, nil
+}
+
+// touchDeviceLastSeen marks a device online after it delivered readings. It
+// leaves a recently seen online device untouched, see TouchDeviceLastSeen.
+func touchDeviceLastSeen(ctx context.Context, q *generated.Queries, deviceID uint32, seenAt time.Time) error {
+	err := q.TouchDeviceLastSeen(ctx, generated.TouchDeviceLastSeenParams{
+		LastSeenAt: seenAt,
+		ID:         int64(deviceID),
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update device last seen: %s", err.Error())
+	}
+
+	return nil
+}
+
 func mapDBDeviceToDevice(dbDevice generated.Device) *repository.Device {
 	var reactorID uint32
 	if dbDevice.ReactorID.Valid {
@@ -148,5 +222,9 @@ func mapDBDeviceToDevice(dbDevice generated.Device) *repository.Device {
 		Name:      dbDevice.Name,
 		Status:    dbDevice.Status,
 		CreatedAt: dbDevice.CreatedAt,
+
+		LastSeenAt:              pgTimestamptzToTimePtr(dbDevice.LastSeenAt),
+		ExpectedIntervalSeconds: dbDevice.ExpectedIntervalSeconds,
+		Connectivity:            dbDevice.Connectivity,
 	}
 }
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.ExperimentTemplateRepository = (*ExperimentTemplateRepository)(nil)
+
+type ExperimentTemplateRepository struct {
+	queries *generated.Queries
+}
+
+func NewExperimentTemplateRepository(store *Store) *ExperimentTemplateRepository {
+	return &ExperimentTemplateRepository{queries: generated.New(store.pool)}
+}
+
+func (r *ExperimentTemplateRepository) CreateExperimentTemplate(ctx context.Context, template *repository.ExperimentTemplate) (*repository.ExperimentTemplate, error) {
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+
+	materialFeedstockJSON, exposureConditionsJSON, err := marshalExperimentTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	dbTemplate, err := r.queries.CreateExperimentTemplate(ctx, generated.CreateExperimentTemplateParams{
+		Name:               template.Name,
+		Description:        template.Description,
+		MaterialFeedstock:  materialFeedstockJSON,
+		ExposureConditions: exposureConditionsJSON,
+		SourceExperimentID: uint32PtrToPgInt8(template.SourceExperimentID),
+		CreatedBy:          int64(template.CreatedBy),
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.UNIQUE_VIOLATION {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "an experiment template named %q already exists", template.Name)
+		}
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "experiment or user of the experiment template not found")
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create experiment template: %s", err.Error())
+	}
+
+	return mapDBExperimentTemplateToExperimentTemplate(dbTemplate)
+}
+
+func (r *ExperimentTemplateRepository) GetExperimentTemplateByID(ctx context.Context, id uint32) (*repository.ExperimentTemplate, error) {
+	dbTemplate, err := r.queries.GetExperimentTemplateByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "experiment template with id %d not found", id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get experiment template: %s", err.Error())
+	}
+
+	return mapDBExperimentTemplateToExperimentTemplate(dbTemplate)
+}
+
+func (r *ExperimentTemplateRepository) ListExperimentTemplates(ctx context.Context, search *string) ([]*repository.ExperimentTemplate, error) {
+	searchParam := pgtype.Text{Valid: false}
+	if search != nil {
+		searchParam = pgtype.Text{String: "%" + strings.ToLower(*search) + "%", Valid: true}
+	}
+
+	dbTemplates, err := r.queries.ListExperimentTemplates(ctx, searchParam)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list experiment templates: %s", err.Error())
+	}
+
+	templates := make([]*repository.ExperimentTemplate, 0, len(dbTemplates))
+	for _, dbTemplate := range dbTemplates {
+		template, err := mapDBExperimentTemplateToExperimentTemplate(dbTemplate)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+// UpdateExperimentTemplate replaces the name, description and conditions of
+// the template. Experiments already created from it are left as they are.
+func (r *ExperimentTemplateRepository) UpdateExperimentTemplate(ctx context.Context, template *repository.ExperimentTemplate) (*repository.ExperimentTemplate, error) {
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+
+	materialFeedstockJSON, exposureConditionsJSON, err := marshalExperimentTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	dbTemplate, err := r.queries.UpdateExperimentTemplate(ctx, generated.UpdateExperimentTemplateParams{
+		Name:               template.Name,
+		Description:        template.Description,
+		MaterialFeedstock:  materialFeedstockJSON,
+		ExposureConditions: exposureConditionsJSON,
+		ID:                 int64(template.ID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "experiment template with id %d not found", template.ID)
+		}
+		if pkg.PgxErrorCode(err) == pkg.UNIQUE_VIOLATION {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "an experiment template named %q already exists", template.Name)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update experiment template: %s", err.Error())
+	}
+
+	return mapDBExperimentTemplateToExperimentTemplate(dbTemplate)
+}
+
+func (r *ExperimentTemplateRepository) DeleteExperimentTemplate(ctx context.Context, id uint32) error {
+	deleted, err := r.queries.DeleteExperimentTemplate(ctx, int64(id))
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete experiment template: %s", err.Error())
+	}
+
+	if deleted == 0 {
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "experiment template with id %d not found", id)
+	}
+
+	return nil
+}
+
+func marshalExperimentTemplate(template *repository.ExperimentTemplate) ([]byte, []byte, error) {
+	materialFeedstockJSON, err := json.Marshal(template.MaterialFeedstock)
+	if err != nil {
+		return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal material feedstock: %v", err)
+	}
+
+	exposureConditionsJSON, err := json.Marshal(template.ExposureConditions)
+	if err != nil {
+		return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal exposure conditions: %v", err)
+	}
+
+	return materialFeedstockJSON, exposureConditionsJSON, nil
+}
+
+func mapDBExperimentTemplateToExperimentTemplate(dbTemplate generated.ExperimentTemplate) (*repository.ExperimentTemplate, error) {
+	var materialFeedstock repository.MaterialFeedstock
+	if err := json.Unmarshal(dbTemplate.MaterialFeedstock, &materialFeedstock); err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal material feedstock: %v", err)
+	}
+
+	var exposureConditions repository.ExposureConditions
+	if err := json.Unmarshal(dbTemplate.ExposureConditions, &exposureConditions); err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal exposure conditions: %v", err)
+	}
+
+	return &repository.ExperimentTemplate{
+		ID:                 uint32(dbTemplate.ID),
+		Name:               dbTemplate.Name,
+		Description:        dbTemplate.Description,
+		MaterialFeedstock:  materialFeedstock,
+		ExposureConditions: exposureConditions,
+		SourceExperimentID: pgInt8ToUint32Ptr(dbTemplate.SourceExperimentID),
+		CreatedBy:          uint32(dbTemplate.CreatedBy),
+		CreatedAt:          dbTemplate.CreatedAt,
+		UpdatedAt:          dbTemplate.UpdatedAt,
+	}, nil
+}
@@ -54,6 +54,16 @@ func (e *ExperimentRepository) CreateExperiment(ctx context.Context, experiment
 		return nil, pkg.Errorf(pkg.INVALID_ERROR, "end time cannot be earlier than start time")
 	}
 
+	parameterValues, err := experiment.NormaliseParameters()
+	if err != nil {
+		return nil, err
+	}
+
+	parameterValuesJSON, err := json.Marshal(parameterValues)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal parameter values: %v", err)
+	}
+
 	createParams := generated.CreateExperimentParams{
 		BatchID:            experiment.BatchID,
 		ReactorID:          int64(experiment.ReactorID),
@@ -65,6 +75,7 @@ func (e *ExperimentRepository) CreateExperiment(ctx context.Context, experiment
 		MaterialFeedstock:  materialFeedstockJSON,
 		ExposureConditions: exposureConditionsJSON,
 		AnalticalTests:     analyticalTestsJSON,
+		ParameterValues:    parameterValuesJSON,
 	}
 
 	dbExperiment, err := e.queries.CreateExperiment(ctx, createParams)
@@ -114,6 +125,22 @@ func (e *ExperimentRepository) UpdateExperiment(ctx context.Context, experiment
 		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to parse end time: %v", err)
 	}
 
+	parameterValues, err := experiment.NormaliseParameters()
+	if err != nil {
+		return err
+	}
+
+	parameterValuesJSON, err := json.Marshal(parameterValues)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal parameter values: %v", err)
+	}
+
+	// re-entered parameters replace the text they were migrated from
+	recordedParameters := make([]string, 0, len(parameterValues))
+	for name := range parameterValues {
+		recordedParameters = append(recordedParameters, name)
+	}
+
 	updateParams := generated.UpdateExperimentParams{
 		ID:                 int64(experiment.ID),
 		BatchID:            experiment.BatchID,
@@ -126,6 +153,8 @@ func (e *ExperimentRepository) UpdateExperiment(ctx context.Context, experiment
 		MaterialFeedstock:  materialFeedstockJSON,
 		ExposureConditions: exposureConditionsJSON,
 		AnalticalTests:     analyticalTestsJSON,
+		ParameterValues:    parameterValuesJSON,
+		RecordedParameters: recordedParameters,
 	}
 
 	_, err = e.queries.UpdateExperiment(ctx, updateParams)
@@ -143,12 +172,14 @@ func (e *ExperimentRepository) ListExperiments(ctx context.Context, filter *repo
 		Search:    pgtype.Text{Valid: false},
 		ReactorID: pgtype.Int8{Valid: false},
 		Date:      pgtype.Timestamptz{Valid: false},
+		Status:    pgtype.Text{Valid: false},
 	}
 
 	countParams := generated.CountListExperimentsParams{
 		Search:    pgtype.Text{Valid: false},
 		ReactorID: pgtype.Int8{Valid: false},
 		Date:      pgtype.Timestamptz{Valid: false},
+		Status:    pgtype.Text{Valid: false},
 	}
 
 	if filter.ReactorID != nil {
@@ -164,6 +195,19 @@ func (e *ExperimentRepository) ListExperiments(ctx context.Context, filter *repo
 		listParams.Date = pgtype.Timestamptz{Time: *filter.Date, Valid: true}
 		countParams.Date = pgtype.Timestamptz{Time: *filter.Date, Valid: true}
 	}
+	if filter.Status != nil {
+		listParams.Status = pgtype.Text{String: *filter.Status, Valid: true}
+		countParams.Status = pgtype.Text{String: *filter.Status, Valid: true}
+	}
+
+	parameters, operators, values := []string{}, []string{}, []float64{}
+	for _, parameter := range filter.Parameters {
+		parameters = append(parameters, parameter.Parameter)
+		operators = append(operators, parameter.Operator)
+		values = append(values, parameter.Value)
+	}
+	listParams.FilterParameters, listParams.FilterOperators, listParams.FilterValues = parameters, operators, values
+	countParams.FilterParameters, countParams.FilterOperators, countParams.FilterValues = parameters, operators, values
 
 	dbExperiments, err := e.queries.ListExperiments(ctx, listParams)
 	if err != nil {
@@ -194,6 +238,97 @@ func (e *ExperimentRepository) DeleteExperiment(ctx context.Context, id uint32)
 	return nil
 }
 
+func (e *ExperimentRepository) StartExperiment(ctx context.Context, id uint32) (*repository.Experiment, error) {
+	dbExperiment, err := e.queries.StartExperiment(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.transitionError(ctx, id, repository.ExperimentStatusRunning)
+		}
+		if pkg.PgxErrorCode(err) == pkg.UNIQUE_VIOLATION {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "the reactor of experiment %d is already running an experiment", id)
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to start experiment: %v", err)
+	}
+
+	return mapDBExperimentToExperiment(dbExperiment)
+}
+
+func (e *ExperimentRepository) StopExperiment(ctx context.Context, id uint32, status string) (*repository.Experiment, error) {
+	if !repository.CanTransition(repository.ExperimentStatusRunning, status) {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "a stopped experiment must be %s or %s", repository.ExperimentStatusCompleted, repository.ExperimentStatusAborted)
+	}
+
+	dbExperiment, err := e.queries.StopExperiment(ctx, generated.StopExperimentParams{
+		Status: status,
+		ID:     int64(id),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, e.transitionError(ctx, id, status)
+		}
+
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to stop experiment: %v", err)
+	}
+
+	return mapDBExperimentToExperiment(dbExperiment)
+}
+
+// transitionError explains why a status update matched no experiment: it
+// does not exist or is not in a status it can move to the target from.
+func (e *ExperimentRepository) transitionError(ctx context.Context, id uint32, status string) error {
+	experiment, err := e.GetExperimentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return pkg.Errorf(pkg.INVALID_ERROR, "experiment %d is %s and cannot become %s", id, experiment.Status, status)
+}
+
+func (e *ExperimentRepository) ListExperimentDevices(ctx context.Context, experiment *repository.Experiment) ([]*repository.ExperimentDevice, error) {
+	start, end, err := experiment.Window()
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := e.queries.ListReactorDeviceAssignments(ctx, generated.ListReactorDeviceAssignmentsParams{
+		StartTime: start,
+		EndTime:   end,
+		ReactorID: int64(experiment.ReactorID),
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list experiment devices: %v", err)
+	}
+
+	devices := make([]*repository.ExperimentDevice, 0, len(assignments))
+	for _, assignment := range assignments {
+		devices = append(devices, &repository.ExperimentDevice{
+			DeviceID:   uint32(assignment.DeviceID),
+			DeviceName: assignment.DeviceName,
+			Start:      assignment.WindowStart,
+			End:        assignment.WindowEnd,
+		})
+	}
+
+	return devices, nil
+}
+
+func (e *ExperimentRepository) SetExperimentRunStats(ctx context.Context, id uint32, stats *repository.ExperimentRunStats) error {
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to marshal run stats: %v", err)
+	}
+
+	if err := e.queries.SetExperimentRunStats(ctx, generated.SetExperimentRunStatsParams{
+		RunStats: statsJSON,
+		ID:       int64(id),
+	}); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to set experiment run stats: %v", err)
+	}
+
+	return nil
+}
+
 func mapDBExperimentToExperiment(dbExperiment generated.Experiment) (*repository.Experiment, error) {
 	var materialFeedstock repository.MaterialFeedstock
 	if err := json.Unmarshal(dbExperiment.MaterialFeedstock, &materialFeedstock); err != nil {
@@ -210,6 +345,20 @@ func mapDBExperimentToExperiment(dbExperiment generated.Experiment) (*repository
 		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal analytical tests: %v", err)
 	}
 
+	var legacyParameters map[string]string
+	if len(dbExperiment.LegacyParameters) > 0 {
+		if err := json.Unmarshal(dbExperiment.LegacyParameters, &legacyParameters); err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal legacy parameters: %v", err)
+		}
+	}
+
+	var runStats *repository.ExperimentRunStats
+	if len(dbExperiment.RunStats) > 0 {
+		if err := json.Unmarshal(dbExperiment.RunStats, &runStats); err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal run stats: %v", err)
+		}
+	}
+
 	var deletedAt *time.Time
 	if dbExperiment.DeletedAt.Valid {
 		deletedAt = &dbExperiment.DeletedAt.Time
@@ -227,7 +376,12 @@ func mapDBExperimentToExperiment(dbExperiment generated.Experiment) (*repository
 		MaterialFeedstock:  materialFeedstock,
 		ExposureConditions: exposureConditions,
 		AnalyticalTests:    analyticalTests,
+		RunStats:           runStats,
+		Status:             dbExperiment.Status,
+		StartedAt:          pgTimestamptzToTimePtr(dbExperiment.StartedAt),
+		EndedAt:            pgTimestamptzToTimePtr(dbExperiment.EndedAt),
 		DeletedAt:          deletedAt,
 		CreatedAt:          dbExperiment.CreatedAt,
+		LegacyParameters:   legacyParameters,
 	}, nil
 }
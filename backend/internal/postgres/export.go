@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// streamReadingsByTimeRange is GetReadingsByTimeRange from
+// queries/readings.sql; keep the two in step. sqlc only generates :many
+// queries that scan every row into a slice before returning, and has no
+// command that hands rows back one at a time, so the export keeps this copy
+// and reads the rows itself.
+const streamReadingsByTimeRange = `SELECT id, device_id, payload, timestamp, received_at, message_id
+FROM sensor_readings
+WHERE device_id = $1
+  AND timestamp >= $2
+  AND timestamp < $3
+ORDER BY timestamp ASC, id ASC`
+
+// StreamReadingsByTimeRange calls fn for every reading of the device in the
+// range, oldest first, without holding the whole range in memory. Parts of
+// the range past the device's raw retention are read from rollups.
+func (r *DeviceRepository) StreamReadingsByTimeRange(ctx context.Context, filter *repository.ReadingFilter, fn func(reading *repository.Reading) error) error {
+	if filter.Start == nil || filter.End == nil {
+		return pkg.Errorf(pkg.INVALID_ERROR, "start and end time must be provided")
+	}
+
+	ranges, err := r.readingRanges(ctx, filter.DeviceID, *filter.Start, *filter.End)
+	if err != nil {
+		return err
+	}
+
+	for _, rg := range ranges {
+		if rg.resolution != repository.ResolutionRaw {
+			// rollups are at most one row per minute, small enough to load
+			readings, err := r.listRangeReadings(ctx, filter.DeviceID, []readingRange{rg})
+			if err != nil {
+				return err
+			}
+
+			for _, reading := range readings {
+				if err := fn(reading); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if err := r.streamRawReadings(ctx, filter.DeviceID, rg, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *DeviceRepository) streamRawReadings(ctx context.Context, deviceID uint32, rg readingRange, fn func(reading *repository.Reading) error) error {
+	// pgx reads rows off the connection as they are consumed
+	rows, err := r.store.pool.Query(ctx, streamReadingsByTimeRange, int64(deviceID), rg.start, rg.end)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to stream readings: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dbReading generated.SensorReading
+		if err := rows.Scan(
+			&dbReading.ID,
+			&dbReading.DeviceID,
+			&dbReading.Payload,
+			&dbReading.Timestamp,
+			&dbReading.ReceivedAt,
+			&dbReading.MessageID,
+		); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to scan reading: %s", err.Error())
+		}
+
+		reading, err := mapDBReadingToReading(dbReading)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(reading); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to stream readings: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+var _ repository.FileRepository = (*FileRepository)(nil)
+
+type FileRepository struct {
+	queries *generated.Queries
+}
+
+func NewFileRepository(store *Store) *FileRepository {
+	return &FileRepository{queries: generated.New(store.pool)}
+}
+
+func (r *FileRepository) CreateFile(ctx context.Context, file *repository.File) (*repository.File, error) {
+	dbFile, err := r.queries.CreateFile(ctx, generated.CreateFileParams{
+		StorageKey:  file.StorageKey,
+		FileName:    file.FileName,
+		ContentType: file.ContentType,
+		Size:        file.Size,
+		Checksum:    file.Checksum,
+		CreatedBy:   int64(file.CreatedBy),
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "user with id %d not found", file.CreatedBy)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create file: %v", err)
+	}
+
+	return mapDBFileToFile(dbFile), nil
+}
+
+func (r *FileRepository) GetFileByID(ctx context.Context, id uint32) (*repository.File, error) {
+	dbFile, err := r.queries.GetFileByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "file with id %d not found", id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get file by id: %v", err)
+	}
+
+	return mapDBFileToFile(dbFile), nil
+}
+
+func mapDBFileToFile(dbFile generated.File) *repository.File {
+	return &repository.File{
+		ID:          uint32(dbFile.ID),
+		StorageKey:  dbFile.StorageKey,
+		FileName:    dbFile.FileName,
+		ContentType: dbFile.ContentType,
+		Size:        dbFile.Size,
+		Checksum:    dbFile.Checksum,
+		CreatedBy:   uint32(dbFile.CreatedBy),
+		CreatedAt:   dbFile.CreatedAt,
+	}
+}
@@ -7,6 +7,7 @@ package generated
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -15,36 +16,55 @@ const countTotalActiveInactiveDevices = `-- name: CountTotalActiveInactiveDevice
 SELECT
     (SELECT COUNT(*) FROM device WHERE deleted = false) AS total_devices,
     (SELECT COUNT(*) FROM device WHERE status = TRUE AND deleted = false) AS active_devices,
-    (SELECT COUNT(*) FROM device WHERE status = FALSE AND deleted = false) AS inactive_devices
+    (SELECT COUNT(*) FROM device WHERE status = FALSE AND deleted = false) AS inactive_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'online' AND deleted = false) AS online_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'stale' AND deleted = false) AS stale_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'offline' AND deleted = false) AS offline_devices
 `
 
 type CountTotalActiveInactiveDevicesRow struct {
 	TotalDevices    int64 `json:"total_devices"`
 	ActiveDevices   int64 `json:"active_devices"`
 	InactiveDevices int64 `json:"inactive_devices"`
+	OnlineDevices   int64 `json:"online_devices"`
+	StaleDevices    int64 `json:"stale_devices"`
+	OfflineDevices  int64 `json:"offline_devices"`
 }
 
 func (q *Queries) CountTotalActiveInactiveDevices(ctx context.Context) (CountTotalActiveInactiveDevicesRow, error) {
 	row := q.db.QueryRow(ctx, countTotalActiveInactiveDevices)
 	var i CountTotalActiveInactiveDevicesRow
-	err := row.Scan(&i.TotalDevices, &i.ActiveDevices, &i.InactiveDevices)
+	err := row.Scan(
+		&i.TotalDevices,
+		&i.ActiveDevices,
+		&i.InactiveDevices,
+		&i.OnlineDevices,
+		&i.StaleDevices,
+		&i.OfflineDevices,
+	)
 	return i, err
 }
 
 const createDevice = `-- name: CreateDevice :one
-INSERT INTO device (reactor_id, name, status)
-VALUES ($1, $2, $3)
-RETURNING id, name, status, deleted, created_at, reactor_id
+INSERT INTO device (reactor_id, name, status, expected_interval_seconds)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, status, deleted, created_at, reactor_id, last_seen_at, expected_interval_seconds, connectivity
 `
 
 type CreateDeviceParams struct {
-	ReactorID pgtype.Int8 `json:"reactor_id"`
-	Name      string      `json:"name"`
-	Status    bool        `json:"status"`
+	ReactorID               pgtype.Int8 `json:"reactor_id"`
+	Name                    string      `json:"name"`
+	Status                  bool        `json:"status"`
+	ExpectedIntervalSeconds int32       `json:"expected_interval_seconds"`
 }
 
 func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Device, error) {
-	row := q.db.QueryRow(ctx, createDevice, arg.ReactorID, arg.Name, arg.Status)
+	row := q.db.QueryRow(ctx, createDevice,
+		arg.ReactorID,
+		arg.Name,
+		arg.Status,
+		arg.ExpectedIntervalSeconds,
+	)
 	var i Device
 	err := row.Scan(
 		&i.ID,
@@ -53,6 +73,9 @@ func (q *Queries) CreateDevice(ctx context.Context, arg CreateDeviceParams) (Dev
 		&i.Deleted,
 		&i.CreatedAt,
 		&i.ReactorID,
+		&i.LastSeenAt,
+		&i.ExpectedIntervalSeconds,
+		&i.Connectivity,
 	)
 	return i, err
 }
@@ -69,7 +92,7 @@ func (q *Queries) DeleteDevice(ctx context.Context, id int64) error {
 }
 
 const getDevice = `-- name: GetDevice :one
-SELECT id, name, status, deleted, created_at, reactor_id
+SELECT id, name, status, deleted, created_at, reactor_id, last_seen_at, expected_interval_seconds, connectivity
 FROM device
 WHERE id = $1 AND deleted = false
 `
@@ -84,6 +107,9 @@ func (q *Queries) GetDevice(ctx context.Context, id int64) (Device, error) {
 		&i.Deleted,
 		&i.CreatedAt,
 		&i.ReactorID,
+		&i.LastSeenAt,
+		&i.ExpectedIntervalSeconds,
+		&i.Connectivity,
 	)
 	return i, err
 }
@@ -93,13 +119,23 @@ SELECT
     (SELECT COUNT(*) FROM device) AS total_devices,
     (SELECT COUNT(*) FROM device WHERE status = TRUE) AS active_devices,
     (SELECT COUNT(*) FROM device WHERE status = FALSE) AS inactive_devices,
-    (SELECT COUNT(*) FROM sensor_readings) AS total_sensor_readings
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'online' AND deleted = false) AS online_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'stale' AND deleted = false) AS stale_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'offline' AND deleted = false) AS offline_devices,
+    -- planner estimate summed over the partitions; an exact COUNT(*) scans every reading
+    (SELECT COALESCE(SUM(GREATEST(c.reltuples, 0)), 0)::bigint
+     FROM pg_inherits i
+     JOIN pg_class c ON c.oid = i.inhrelid
+     WHERE i.inhparent = 'sensor_readings'::regclass) AS total_sensor_readings
 `
 
 type GetDeviceStatsRow struct {
 	TotalDevices        int64 `json:"total_devices"`
 	ActiveDevices       int64 `json:"active_devices"`
 	InactiveDevices     int64 `json:"inactive_devices"`
+	OnlineDevices       int64 `json:"online_devices"`
+	StaleDevices        int64 `json:"stale_devices"`
+	OfflineDevices      int64 `json:"offline_devices"`
 	TotalSensorReadings int64 `json:"total_sensor_readings"`
 }
 
@@ -110,13 +146,16 @@ func (q *Queries) GetDeviceStats(ctx context.Context) (GetDeviceStatsRow, error)
 		&i.TotalDevices,
 		&i.ActiveDevices,
 		&i.InactiveDevices,
+		&i.OnlineDevices,
+		&i.StaleDevices,
+		&i.OfflineDevices,
 		&i.TotalSensorReadings,
 	)
 	return i, err
 }
 
 const listDevices = `-- name: ListDevices :many
-SELECT id, name, status, deleted, created_at, reactor_id
+SELECT id, name, status, deleted, created_at, reactor_id, last_seen_at, expected_interval_seconds, connectivity
 FROM device
 WHERE deleted = false
 ORDER BY created_at DESC
@@ -138,6 +177,46 @@ func (q *Queries) ListDevices(ctx context.Context) ([]Device, error) {
 			&i.Deleted,
 			&i.CreatedAt,
 			&i.ReactorID,
+			&i.LastSeenAt,
+			&i.ExpectedIntervalSeconds,
+			&i.Connectivity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDevicesByReactor = `-- name: ListDevicesByReactor :many
+SELECT id, name, status, deleted, created_at, reactor_id, last_seen_at, expected_interval_seconds, connectivity
+FROM device
+WHERE reactor_id = $1 AND deleted = false
+ORDER BY id ASC
+`
+
+func (q *Queries) ListDevicesByReactor(ctx context.Context, reactorID pgtype.Int8) ([]Device, error) {
+	rows, err := q.db.Query(ctx, listDevicesByReactor, reactorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Device{}
+	for rows.Next() {
+		var i Device
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Status,
+			&i.Deleted,
+			&i.CreatedAt,
+			&i.ReactorID,
+			&i.LastSeenAt,
+			&i.ExpectedIntervalSeconds,
+			&i.Connectivity,
 		); err != nil {
 			return nil, err
 		}
@@ -149,20 +228,47 @@ func (q *Queries) ListDevices(ctx context.Context) ([]Device, error) {
 	return items, nil
 }
 
+const touchDeviceLastSeen = `-- name: TouchDeviceLastSeen :exec
+UPDATE device
+SET last_seen_at = GREATEST(last_seen_at, $1::timestamptz),
+    connectivity = 'online'
+WHERE id = $2
+    AND (
+        connectivity <> 'online'
+        OR last_seen_at IS NULL
+        OR last_seen_at < $1::timestamptz - make_interval(secs => expected_interval_seconds / 2.0)
+    )
+`
+
+type TouchDeviceLastSeenParams struct {
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ID         int64     `json:"id"`
+}
+
+// Online devices are only touched once per half of their expected interval,
+// well within the stale threshold, so readings do not all lock and rewrite
+// the device row.
+func (q *Queries) TouchDeviceLastSeen(ctx context.Context, arg TouchDeviceLastSeenParams) error {
+	_, err := q.db.Exec(ctx, touchDeviceLastSeen, arg.LastSeenAt, arg.ID)
+	return err
+}
+
 const updateDevice = `-- name: UpdateDevice :one
 UPDATE device
 SET reactor_id   = COALESCE($1, reactor_id),
     name   = COALESCE($2, name),
-    status = COALESCE($3, status)
-WHERE id = $4 AND deleted = false
-RETURNING id, name, status, deleted, created_at, reactor_id
+    status = COALESCE($3, status),
+    expected_interval_seconds = COALESCE($4, expected_interval_seconds)
+WHERE id = $5 AND deleted = false
+RETURNING id, name, status, deleted, created_at, reactor_id, last_seen_at, expected_interval_seconds, connectivity
 `
 
 type UpdateDeviceParams struct {
-	ReactorID pgtype.Int8 `json:"reactor_id"`
-	Name      pgtype.Text `json:"name"`
-	Status    pgtype.Bool `json:"status"`
-	ID        int64       `json:"id"`
+	ReactorID               pgtype.Int8 `json:"reactor_id"`
+	Name                    pgtype.Text `json:"name"`
+	Status                  pgtype.Bool `json:"status"`
+	ExpectedIntervalSeconds pgtype.Int4 `json:"expected_interval_seconds"`
+	ID                      int64       `json:"id"`
 }
 
 func (q *Queries) UpdateDevice(ctx context.Context, arg UpdateDeviceParams) (Device, error) {
@@ -170,6 +276,7 @@ func (q *Queries) UpdateDevice(ctx context.Context, arg UpdateDeviceParams) (Dev
 		arg.ReactorID,
 		arg.Name,
 		arg.Status,
+		arg.ExpectedIntervalSeconds,
 		arg.ID,
 	)
 	var i Device
@@ -180,6 +287,56 @@ func (q *Queries) UpdateDevice(ctx context.Context, arg UpdateDeviceParams) (Dev
 		&i.Deleted,
 		&i.CreatedAt,
 		&i.ReactorID,
+		&i.LastSeenAt,
+		&i.ExpectedIntervalSeconds,
+		&i.Connectivity,
 	)
 	return i, err
 }
+
+const updateDevicesConnectivity = `-- name: UpdateDevicesConnectivity :many
+UPDATE device AS d
+SET connectivity = c.connectivity
+FROM (
+    SELECT id,
+        CASE
+            WHEN last_seen_at > now() - make_interval(secs => expected_interval_seconds * $1::float8) THEN 'online'
+            WHEN last_seen_at > now() - make_interval(secs => expected_interval_seconds * $2::float8) THEN 'stale'
+            ELSE 'offline'
+        END AS connectivity
+    FROM device
+    WHERE deleted = false
+) AS c
+WHERE d.id = c.id AND d.connectivity <> c.connectivity
+RETURNING d.id, d.connectivity
+`
+
+type UpdateDevicesConnectivityParams struct {
+	StaleAfter   float64 `json:"stale_after"`
+	OfflineAfter float64 `json:"offline_after"`
+}
+
+type UpdateDevicesConnectivityRow struct {
+	ID           int64  `json:"id"`
+	Connectivity string `json:"connectivity"`
+}
+
+func (q *Queries) UpdateDevicesConnectivity(ctx context.Context, arg UpdateDevicesConnectivityParams) ([]UpdateDevicesConnectivityRow, error) {
+	rows, err := q.db.Query(ctx, updateDevicesConnectivity, arg.StaleAfter, arg.OfflineAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UpdateDevicesConnectivityRow{}
+	for rows.Next() {
+		var i UpdateDevicesConnectivityRow
+		if err := rows.Scan(&i.ID, &i.Connectivity); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -56,32 +56,85 @@ WHERE deleted_at IS NULL
         $3::timestamptz IS NULL 
         OR date::date = $3
     )
+    AND (
+        $4::text IS NULL
+        OR status = $4
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM unnest(
+            $5::text[],
+            $6::text[],
+            $7::float8[]
+        ) AS f(parameter, operator, value)
+        -- values converted between units are rarely exactly equal, so they
+        -- are compared with the tolerance of repository.sameBaseValue
+        CROSS JOIN LATERAL (
+            SELECT (parameter_values ->> f.parameter)::float8 AS stored
+        ) p
+        CROSS JOIN LATERAL (
+            SELECT 1e-9 * greatest(abs(p.stored), abs(f.value)) AS tolerance
+        ) t
+        WHERE NOT COALESCE(CASE f.operator
+            WHEN '>=' THEN p.stored >= f.value - t.tolerance
+            WHEN '<=' THEN p.stored <= f.value + t.tolerance
+            WHEN '>' THEN p.stored > f.value + t.tolerance
+            WHEN '<' THEN p.stored < f.value - t.tolerance
+            WHEN '=' THEN abs(p.stored - f.value) <= t.tolerance
+        END, false)
+    )
 `
 
 type CountListExperimentsParams struct {
-	Search    interface{}        `json:"search"`
-	ReactorID pgtype.Int8        `json:"reactor_id"`
-	Date      pgtype.Timestamptz `json:"date"`
+	Search           interface{}        `json:"search"`
+	ReactorID        pgtype.Int8        `json:"reactor_id"`
+	Date             pgtype.Timestamptz `json:"date"`
+	Status           pgtype.Text        `json:"status"`
+	FilterParameters []string           `json:"filter_parameters"`
+	FilterOperators  []string           `json:"filter_operators"`
+	FilterValues     []float64          `json:"filter_values"`
 }
 
 func (q *Queries) CountListExperiments(ctx context.Context, arg CountListExperimentsParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countListExperiments, arg.Search, arg.ReactorID, arg.Date)
+	row := q.db.QueryRow(ctx, countListExperiments,
+		arg.Search,
+		arg.ReactorID,
+		arg.Date,
+		arg.Status,
+		arg.FilterParameters,
+		arg.FilterOperators,
+		arg.FilterValues,
+	)
 	var total_experiments int64
 	err := row.Scan(&total_experiments)
 	return total_experiments, err
 }
 
+const countRunningExperiments = `-- name: CountRunningExperiments :one
+SELECT COUNT(*) AS running_experiments
+FROM experiments
+WHERE status = 'running' AND deleted_at IS NULL
+`
+
+func (q *Queries) CountRunningExperiments(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countRunningExperiments)
+	var running_experiments int64
+	err := row.Scan(&running_experiments)
+	return running_experiments, err
+}
+
 const createExperiment = `-- name: CreateExperiment :one
 INSERT INTO experiments (
     batch_id, operator, date, reactor_id, block_id, time_start, time_end,
-    material_feedstock, exposure_conditions, analtical_tests
+    material_feedstock, exposure_conditions, analtical_tests, parameter_values
 )
 VALUES (
     $1, $2, $3, $4, $5,
     $6, $7,
-    $8, $9, $10
+    $8, $9, $10,
+    $11
 )
-RETURNING id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at
+RETURNING id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at, run_stats, status, started_at, ended_at, parameter_values, legacy_parameters
 `
 
 type CreateExperimentParams struct {
@@ -95,6 +148,7 @@ type CreateExperimentParams struct {
 	MaterialFeedstock  []byte      `json:"material_feedstock"`
 	ExposureConditions []byte      `json:"exposure_conditions"`
 	AnalticalTests     []byte      `json:"analtical_tests"`
+	ParameterValues    []byte      `json:"parameter_values"`
 }
 
 func (q *Queries) CreateExperiment(ctx context.Context, arg CreateExperimentParams) (Experiment, error) {
@@ -109,6 +163,7 @@ func (q *Queries) CreateExperiment(ctx context.Context, arg CreateExperimentPara
 		arg.MaterialFeedstock,
 		arg.ExposureConditions,
 		arg.AnalticalTests,
+		arg.ParameterValues,
 	)
 	var i Experiment
 	err := row.Scan(
@@ -125,6 +180,12 @@ func (q *Queries) CreateExperiment(ctx context.Context, arg CreateExperimentPara
 		&i.AnalticalTests,
 		&i.DeletedAt,
 		&i.CreatedAt,
+		&i.RunStats,
+		&i.Status,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.ParameterValues,
+		&i.LegacyParameters,
 	)
 	return i, err
 }
@@ -154,7 +215,7 @@ func (q *Queries) GetAverageExperimentDuration(ctx context.Context) (float64, er
 }
 
 const getExperimentByID = `-- name: GetExperimentByID :one
-SELECT id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at FROM experiments WHERE id = $1 AND deleted_at IS NULL
+SELECT id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at, run_stats, status, started_at, ended_at, parameter_values, legacy_parameters FROM experiments WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetExperimentByID(ctx context.Context, id int64) (Experiment, error) {
@@ -174,12 +235,18 @@ func (q *Queries) GetExperimentByID(ctx context.Context, id int64) (Experiment,
 		&i.AnalticalTests,
 		&i.DeletedAt,
 		&i.CreatedAt,
+		&i.RunStats,
+		&i.Status,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.ParameterValues,
+		&i.LegacyParameters,
 	)
 	return i, err
 }
 
 const listExperiments = `-- name: ListExperiments :many
-SELECT id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at FROM experiments
+SELECT id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at, run_stats, status, started_at, ended_at, parameter_values, legacy_parameters FROM experiments
 WHERE deleted_at IS NULL
     AND (
         COALESCE($1, '') = '' 
@@ -195,16 +262,47 @@ WHERE deleted_at IS NULL
         $3::timestamptz IS NULL 
         OR date::date = $3
     )
+    AND (
+        $4::text IS NULL
+        OR status = $4
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM unnest(
+            $5::text[],
+            $6::text[],
+            $7::float8[]
+        ) AS f(parameter, operator, value)
+        -- values converted between units are rarely exactly equal, so they
+        -- are compared with the tolerance of repository.sameBaseValue
+        CROSS JOIN LATERAL (
+            SELECT (parameter_values ->> f.parameter)::float8 AS stored
+        ) p
+        CROSS JOIN LATERAL (
+            SELECT 1e-9 * greatest(abs(p.stored), abs(f.value)) AS tolerance
+        ) t
+        WHERE NOT COALESCE(CASE f.operator
+            WHEN '>=' THEN p.stored >= f.value - t.tolerance
+            WHEN '<=' THEN p.stored <= f.value + t.tolerance
+            WHEN '>' THEN p.stored > f.value + t.tolerance
+            WHEN '<' THEN p.stored < f.value - t.tolerance
+            WHEN '=' THEN abs(p.stored - f.value) <= t.tolerance
+        END, false)
+    )
 ORDER BY created_at DESC
-LIMIT $5 OFFSET $4
+LIMIT $9 OFFSET $8
 `
 
 type ListExperimentsParams struct {
-	Search    interface{}        `json:"search"`
-	ReactorID pgtype.Int8        `json:"reactor_id"`
-	Date      pgtype.Timestamptz `json:"date"`
-	Offset    int32              `json:"offset"`
-	Limit     int32              `json:"limit"`
+	Search           interface{}        `json:"search"`
+	ReactorID        pgtype.Int8        `json:"reactor_id"`
+	Date             pgtype.Timestamptz `json:"date"`
+	Status           pgtype.Text        `json:"status"`
+	FilterParameters []string           `json:"filter_parameters"`
+	FilterOperators  []string           `json:"filter_operators"`
+	FilterValues     []float64          `json:"filter_values"`
+	Offset           int32              `json:"offset"`
+	Limit            int32              `json:"limit"`
 }
 
 func (q *Queries) ListExperiments(ctx context.Context, arg ListExperimentsParams) ([]Experiment, error) {
@@ -212,6 +310,10 @@ func (q *Queries) ListExperiments(ctx context.Context, arg ListExperimentsParams
 		arg.Search,
 		arg.ReactorID,
 		arg.Date,
+		arg.Status,
+		arg.FilterParameters,
+		arg.FilterOperators,
+		arg.FilterValues,
 		arg.Offset,
 		arg.Limit,
 	)
@@ -236,6 +338,66 @@ func (q *Queries) ListExperiments(ctx context.Context, arg ListExperimentsParams
 			&i.AnalticalTests,
 			&i.DeletedAt,
 			&i.CreatedAt,
+			&i.RunStats,
+			&i.Status,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.ParameterValues,
+			&i.LegacyParameters,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReactorDeviceAssignments = `-- name: ListReactorDeviceAssignments :many
+SELECT
+    a.device_id,
+    d.name AS device_name,
+    GREATEST(a.attached_at, $1::timestamptz)::timestamptz AS window_start,
+    LEAST(COALESCE(a.detached_at, $2::timestamptz), $2::timestamptz)::timestamptz AS window_end
+FROM device_reactor_assignments a
+JOIN device d ON d.id = a.device_id
+WHERE a.reactor_id = $3
+    AND a.attached_at < $2::timestamptz
+    AND (a.detached_at IS NULL OR a.detached_at > $1::timestamptz)
+ORDER BY a.device_id, a.attached_at
+`
+
+type ListReactorDeviceAssignmentsParams struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	ReactorID int64     `json:"reactor_id"`
+}
+
+type ListReactorDeviceAssignmentsRow struct {
+	DeviceID    int64     `json:"device_id"`
+	DeviceName  string    `json:"device_name"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+}
+
+// ListReactorDeviceAssignments returns the devices attached to a reactor at
+// some point of a time range, with each attachment clipped to the range.
+func (q *Queries) ListReactorDeviceAssignments(ctx context.Context, arg ListReactorDeviceAssignmentsParams) ([]ListReactorDeviceAssignmentsRow, error) {
+	rows, err := q.db.Query(ctx, listReactorDeviceAssignments, arg.StartTime, arg.EndTime, arg.ReactorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListReactorDeviceAssignmentsRow{}
+	for rows.Next() {
+		var i ListReactorDeviceAssignmentsRow
+		if err := rows.Scan(
+			&i.DeviceID,
+			&i.DeviceName,
+			&i.WindowStart,
+			&i.WindowEnd,
 		); err != nil {
 			return nil, err
 		}
@@ -247,6 +409,95 @@ func (q *Queries) ListExperiments(ctx context.Context, arg ListExperimentsParams
 	return items, nil
 }
 
+const setExperimentRunStats = `-- name: SetExperimentRunStats :exec
+UPDATE experiments
+SET run_stats = $1
+WHERE id = $2 AND deleted_at IS NULL
+`
+
+type SetExperimentRunStatsParams struct {
+	RunStats []byte `json:"run_stats"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) SetExperimentRunStats(ctx context.Context, arg SetExperimentRunStatsParams) error {
+	_, err := q.db.Exec(ctx, setExperimentRunStats, arg.RunStats, arg.ID)
+	return err
+}
+
+const startExperiment = `-- name: StartExperiment :one
+UPDATE experiments
+SET status = 'running', started_at = now(), run_stats = NULL
+WHERE id = $1 AND deleted_at IS NULL AND status = 'planned'
+RETURNING id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at, run_stats, status, started_at, ended_at, parameter_values, legacy_parameters
+`
+
+func (q *Queries) StartExperiment(ctx context.Context, id int64) (Experiment, error) {
+	row := q.db.QueryRow(ctx, startExperiment, id)
+	var i Experiment
+	err := row.Scan(
+		&i.ID,
+		&i.BatchID,
+		&i.Operator,
+		&i.Date,
+		&i.ReactorID,
+		&i.BlockID,
+		&i.TimeStart,
+		&i.TimeEnd,
+		&i.MaterialFeedstock,
+		&i.ExposureConditions,
+		&i.AnalticalTests,
+		&i.DeletedAt,
+		&i.CreatedAt,
+		&i.RunStats,
+		&i.Status,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.ParameterValues,
+		&i.LegacyParameters,
+	)
+	return i, err
+}
+
+const stopExperiment = `-- name: StopExperiment :one
+UPDATE experiments
+SET status = $1, ended_at = now(), run_stats = NULL
+WHERE id = $2 AND deleted_at IS NULL AND status = 'running'
+RETURNING id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at, run_stats, status, started_at, ended_at, parameter_values, legacy_parameters
+`
+
+type StopExperimentParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) StopExperiment(ctx context.Context, arg StopExperimentParams) (Experiment, error) {
+	row := q.db.QueryRow(ctx, stopExperiment, arg.Status, arg.ID)
+	var i Experiment
+	err := row.Scan(
+		&i.ID,
+		&i.BatchID,
+		&i.Operator,
+		&i.Date,
+		&i.ReactorID,
+		&i.BlockID,
+		&i.TimeStart,
+		&i.TimeEnd,
+		&i.MaterialFeedstock,
+		&i.ExposureConditions,
+		&i.AnalticalTests,
+		&i.DeletedAt,
+		&i.CreatedAt,
+		&i.RunStats,
+		&i.Status,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.ParameterValues,
+		&i.LegacyParameters,
+	)
+	return i, err
+}
+
 const updateExperiment = `-- name: UpdateExperiment :one
 UPDATE experiments
 SET
@@ -259,9 +510,12 @@ SET
     time_end = $7,
     material_feedstock = $8,
     exposure_conditions = $9,
-    analtical_tests = $10
-WHERE id = $11 AND deleted_at IS NULL
-RETURNING id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at
+    analtical_tests = $10,
+    parameter_values = $11,
+    legacy_parameters = legacy_parameters - $12::text[],
+    run_stats = NULL
+WHERE id = $13 AND deleted_at IS NULL
+RETURNING id, batch_id, operator, date, reactor_id, block_id, time_start, time_end, material_feedstock, exposure_conditions, analtical_tests, deleted_at, created_at, run_stats, status, started_at, ended_at, parameter_values, legacy_parameters
 `
 
 type UpdateExperimentParams struct {
@@ -275,6 +529,8 @@ type UpdateExperimentParams struct {
 	MaterialFeedstock  []byte      `json:"material_feedstock"`
 	ExposureConditions []byte      `json:"exposure_conditions"`
 	AnalticalTests     []byte      `json:"analtical_tests"`
+	ParameterValues    []byte      `json:"parameter_values"`
+	RecordedParameters []string    `json:"recorded_parameters"`
 	ID                 int64       `json:"id"`
 }
 
@@ -290,6 +546,8 @@ func (q *Queries) UpdateExperiment(ctx context.Context, arg UpdateExperimentPara
 		arg.MaterialFeedstock,
 		arg.ExposureConditions,
 		arg.AnalticalTests,
+		arg.ParameterValues,
+		arg.RecordedParameters,
 		arg.ID,
 	)
 	var i Experiment
@@ -307,6 +565,12 @@ func (q *Queries) UpdateExperiment(ctx context.Context, arg UpdateExperimentPara
 		&i.AnalticalTests,
 		&i.DeletedAt,
 		&i.CreatedAt,
+		&i.RunStats,
+		&i.Status,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.ParameterValues,
+		&i.LegacyParameters,
 	)
 	return i, err
 }
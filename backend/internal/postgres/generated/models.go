@@ -54,13 +54,83 @@ func (ns NullRole) Value() (driver.Value, error) {
 	return string(ns.Role), nil
 }
 
+type AlertIncident struct {
+	ID             int64              `json:"id"`
+	RuleID         int64              `json:"rule_id"`
+	DeviceID       int64              `json:"device_id"`
+	Severity       string             `json:"severity"`
+	Status         string             `json:"status"`
+	TriggerValue   float64            `json:"trigger_value"`
+	OpenedAt       time.Time          `json:"opened_at"`
+	AcknowledgedAt pgtype.Timestamptz `json:"acknowledged_at"`
+	AcknowledgedBy pgtype.Int8        `json:"acknowledged_by"`
+	ResolvedAt     pgtype.Timestamptz `json:"resolved_at"`
+}
+
+type AlertRule struct {
+	ID              int64              `json:"id"`
+	DeviceID        int64              `json:"device_id"`
+	Name            string             `json:"name"`
+	Key             string             `json:"key"`
+	Comparator      string             `json:"comparator"`
+	Threshold       float64            `json:"threshold"`
+	DurationSeconds int32              `json:"duration_seconds"`
+	Severity        string             `json:"severity"`
+	NotifyEmails    []string           `json:"notify_emails"`
+	Enabled         bool               `json:"enabled"`
+	BreachStartedAt pgtype.Timestamptz `json:"breach_started_at"`
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
 type Device struct {
-	ID        int64       `json:"id"`
-	Name      string      `json:"name"`
-	Status    bool        `json:"status"`
-	Deleted   bool        `json:"deleted"`
-	CreatedAt time.Time   `json:"created_at"`
-	ReactorID pgtype.Int8 `json:"reactor_id"`
+	ID                      int64              `json:"id"`
+	Name                    string             `json:"name"`
+	Status                  bool               `json:"status"`
+	Deleted                 bool               `json:"deleted"`
+	CreatedAt               time.Time          `json:"created_at"`
+	ReactorID               pgtype.Int8        `json:"reactor_id"`
+	LastSeenAt              pgtype.Timestamptz `json:"last_seen_at"`
+	ExpectedIntervalSeconds int32              `json:"expected_interval_seconds"`
+	Connectivity            string             `json:"connectivity"`
+}
+
+type DeviceApiKey struct {
+	ID         int64              `json:"id"`
+	DeviceID   int64              `json:"device_id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	KeyHash    string             `json:"key_hash"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type DeviceChannel struct {
+	ID        int64         `json:"id"`
+	DeviceID  int64         `json:"device_id"`
+	Name      string        `json:"name"`
+	Unit      string        `json:"unit"`
+	DataType  string        `json:"data_type"`
+	MinValue  pgtype.Float8 `json:"min_value"`
+	MaxValue  pgtype.Float8 `json:"max_value"`
+	Required  bool          `json:"required"`
+	Position  int32         `json:"position"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type DeviceReactorAssignment struct {
+	ID         int64              `json:"id"`
+	DeviceID   int64              `json:"device_id"`
+	ReactorID  int64              `json:"reactor_id"`
+	AttachedAt time.Time          `json:"attached_at"`
+	DetachedAt pgtype.Timestamptz `json:"detached_at"`
+}
+
+type EffectiveRetentionPolicy struct {
+	DeviceID                  int64       `json:"device_id"`
+	RawRetentionDays          pgtype.Int4 `json:"raw_retention_days"`
+	MinuteRollupRetentionDays pgtype.Int4 `json:"minute_rollup_retention_days"`
+	HourRollupRetentionDays   pgtype.Int4 `json:"hour_rollup_retention_days"`
 }
 
 type Experiment struct {
@@ -77,6 +147,35 @@ type Experiment struct {
 	AnalticalTests     []byte             `json:"analtical_tests"`
 	DeletedAt          pgtype.Timestamptz `json:"deleted_at"`
 	CreatedAt          time.Time          `json:"created_at"`
+	RunStats           []byte             `json:"run_stats"`
+	Status             string             `json:"status"`
+	StartedAt          pgtype.Timestamptz `json:"started_at"`
+	EndedAt            pgtype.Timestamptz `json:"ended_at"`
+	ParameterValues    []byte             `json:"parameter_values"`
+	LegacyParameters   []byte             `json:"legacy_parameters"`
+}
+
+type ExperimentTemplate struct {
+	ID                 int64       `json:"id"`
+	Name               string      `json:"name"`
+	Description        string      `json:"description"`
+	MaterialFeedstock  []byte      `json:"material_feedstock"`
+	ExposureConditions []byte      `json:"exposure_conditions"`
+	SourceExperimentID pgtype.Int8 `json:"source_experiment_id"`
+	CreatedBy          int64       `json:"created_by"`
+	CreatedAt          time.Time   `json:"created_at"`
+	UpdatedAt          time.Time   `json:"updated_at"`
+}
+
+type File struct {
+	ID          int64     `json:"id"`
+	StorageKey  string    `json:"storage_key"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreatedBy   int64     `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type Reactor struct {
@@ -87,13 +186,100 @@ type Reactor struct {
 	PdfUrl    pgtype.Text        `json:"pdf_url"`
 	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
 	CreatedAt time.Time          `json:"created_at"`
+	PdfFileID pgtype.Int8        `json:"pdf_file_id"`
 }
 
-type SensorReading struct {
-	ID        int64     `json:"id"`
+type ReadingRollupWatermark struct {
+	Tier       string    `json:"tier"`
+	RolledUpTo time.Time `json:"rolled_up_to"`
+}
+
+type ReadingRollups1h struct {
+	DeviceID  int64     `json:"device_id"`
+	Bucket    time.Time `json:"bucket"`
+	Key       string    `json:"key"`
+	Count     int64     `json:"count"`
+	SumValue  float64   `json:"sum_value"`
+	MinValue  float64   `json:"min_value"`
+	MaxValue  float64   `json:"max_value"`
+	LastValue float64   `json:"last_value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReadingRollups1m struct {
 	DeviceID  int64     `json:"device_id"`
-	Payload   []byte    `json:"payload"`
-	Timestamp time.Time `json:"timestamp"`
+	Bucket    time.Time `json:"bucket"`
+	Key       string    `json:"key"`
+	Count     int64     `json:"count"`
+	SumValue  float64   `json:"sum_value"`
+	MinValue  float64   `json:"min_value"`
+	MaxValue  float64   `json:"max_value"`
+	LastValue float64   `json:"last_value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReportJob struct {
+	ID            int64              `json:"id"`
+	Kind          string             `json:"kind"`
+	Params        []byte             `json:"params"`
+	Status        string             `json:"status"`
+	Progress      int32              `json:"progress"`
+	Error         pgtype.Text        `json:"error"`
+	Attempts      int32              `json:"attempts"`
+	CreatedBy     int64              `json:"created_by"`
+	NotifyEmail   pgtype.Text        `json:"notify_email"`
+	DownloadToken string             `json:"download_token"`
+	FileName      pgtype.Text        `json:"file_name"`
+	ContentType   pgtype.Text        `json:"content_type"`
+	FileSize      pgtype.Int8        `json:"file_size"`
+	LockedUntil   pgtype.Timestamptz `json:"locked_until"`
+	StartedAt     pgtype.Timestamptz `json:"started_at"`
+	FinishedAt    pgtype.Timestamptz `json:"finished_at"`
+	ExpiresAt     pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+type ReportJobFile struct {
+	JobID int64  `json:"job_id"`
+	Data  []byte `json:"data"`
+}
+
+type ReportSchedule struct {
+	ID             int64              `json:"id"`
+	Name           string             `json:"name"`
+	CronExpression string             `json:"cron_expression"`
+	Timezone       string             `json:"timezone"`
+	Kind           string             `json:"kind"`
+	ReactorID      pgtype.Int8        `json:"reactor_id"`
+	DeviceIds      []int64            `json:"device_ids"`
+	WindowSeconds  int64              `json:"window_seconds"`
+	Recipients     []string           `json:"recipients"`
+	Enabled        bool               `json:"enabled"`
+	NextRunAt      time.Time          `json:"next_run_at"`
+	LastRunAt      pgtype.Timestamptz `json:"last_run_at"`
+	LastError      pgtype.Text        `json:"last_error"`
+	CreatedBy      int64              `json:"created_by"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type RetentionPolicy struct {
+	ID                        int64       `json:"id"`
+	DeviceID                  pgtype.Int8 `json:"device_id"`
+	RawRetentionDays          pgtype.Int4 `json:"raw_retention_days"`
+	MinuteRollupRetentionDays pgtype.Int4 `json:"minute_rollup_retention_days"`
+	HourRollupRetentionDays   pgtype.Int4 `json:"hour_rollup_retention_days"`
+	CreatedAt                 time.Time   `json:"created_at"`
+	UpdatedAt                 time.Time   `json:"updated_at"`
+}
+
+type SensorReading struct {
+	ID         int64       `json:"id"`
+	DeviceID   int64       `json:"device_id"`
+	Payload    []byte      `json:"payload"`
+	Timestamp  time.Time   `json:"timestamp"`
+	ReceivedAt time.Time   `json:"received_at"`
+	MessageID  pgtype.Text `json:"message_id"`
 }
 
 type User struct {
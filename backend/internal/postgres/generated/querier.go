@@ -6,53 +6,168 @@ package generated
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AcknowledgeAlertIncident(ctx context.Context, arg AcknowledgeAlertIncidentParams) (AlertIncident, error)
+	AdvanceReportSchedule(ctx context.Context, arg AdvanceReportScheduleParams) error
+	AggregateHourRollups(ctx context.Context, arg AggregateHourRollupsParams) ([]AggregateHourRollupsRow, error)
+	AggregateMinuteRollups(ctx context.Context, arg AggregateMinuteRollupsParams) ([]AggregateMinuteRollupsRow, error)
+	AggregateReadings(ctx context.Context, arg AggregateReadingsParams) ([]AggregateReadingsRow, error)
+	// Claims a message id for a reading about to be stored. No row is affected
+	// when it was claimed before; a concurrent claim waits for the transaction
+	// that made it.
+	ClaimReadingMessageID(ctx context.Context, arg ClaimReadingMessageIDParams) (int64, error)
+	// Claims the message ids of a batch, returning those that were not claimed
+	// before.
+	ClaimReadingMessageIDs(ctx context.Context, arg ClaimReadingMessageIDsParams) ([]string, error)
+	// Takes the oldest queued job, or a running one whose worker stopped renewing
+	// its lease, for example because the server restarted.
+	ClaimReportJob(ctx context.Context, leaseSeconds float64) (ReportJob, error)
+	CompleteReportJob(ctx context.Context, arg CompleteReportJobParams) (ReportJob, error)
 	CountActiveInactiveReactors(ctx context.Context) (CountActiveInactiveReactorsRow, error)
 	CountDeviceReadings(ctx context.Context, deviceID int64) (int64, error)
 	CountExperimentsRunThisWeek(ctx context.Context) (int64, error)
 	CountExperimentsRunToday(ctx context.Context) (int64, error)
+	CountListAlertIncidents(ctx context.Context, arg CountListAlertIncidentsParams) (int64, error)
 	CountListExperiments(ctx context.Context, arg CountListExperimentsParams) (int64, error)
 	CountListReactors(ctx context.Context, arg CountListReactorsParams) (int64, error)
 	CountListUsers(ctx context.Context, arg CountListUsersParams) (int64, error)
+	CountRunningExperiments(ctx context.Context) (int64, error)
 	CountTotalActiveInactiveDevices(ctx context.Context) (CountTotalActiveInactiveDevicesRow, error)
 	CountTotalInactiveActiveUsers(ctx context.Context) (CountTotalInactiveActiveUsersRow, error)
+	CreateAlertIncident(ctx context.Context, arg CreateAlertIncidentParams) (AlertIncident, error)
+	CreateAlertRule(ctx context.Context, arg CreateAlertRuleParams) (AlertRule, error)
 	CreateDevice(ctx context.Context, arg CreateDeviceParams) (Device, error)
+	CreateDeviceAPIKey(ctx context.Context, arg CreateDeviceAPIKeyParams) (DeviceApiKey, error)
+	CreateDeviceChannel(ctx context.Context, arg CreateDeviceChannelParams) (DeviceChannel, error)
 	CreateExperiment(ctx context.Context, arg CreateExperimentParams) (Experiment, error)
+	CreateExperimentTemplate(ctx context.Context, arg CreateExperimentTemplateParams) (ExperimentTemplate, error)
+	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreateReactor(ctx context.Context, arg CreateReactorParams) (Reactor, error)
+	CreateReportJob(ctx context.Context, arg CreateReportJobParams) (ReportJob, error)
+	CreateReportJobFile(ctx context.Context, arg CreateReportJobFileParams) error
+	CreateReportSchedule(ctx context.Context, arg CreateReportScheduleParams) (ReportSchedule, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAlertRule(ctx context.Context, id int64) (int64, error)
 	DeleteDevice(ctx context.Context, id int64) error
+	DeleteDeviceChannel(ctx context.Context, arg DeleteDeviceChannelParams) (int64, error)
+	DeleteDeviceRetentionPolicy(ctx context.Context, deviceID pgtype.Int8) (int64, error)
 	DeleteExperiment(ctx context.Context, id int64) error
+	DeleteExperimentTemplate(ctx context.Context, id int64) (int64, error)
+	DeleteExpiredHourRollups(ctx context.Context) (int64, error)
+	DeleteExpiredMinuteRollups(ctx context.Context, rolledUpTo time.Time) (int64, error)
+	// Message ids are forgotten once readings received with them are past raw
+	// retention.
+	DeleteExpiredReadingMessageIDs(ctx context.Context, batchSize int32) (int64, error)
+	// Only readings the minute rollup has already covered are dropped.
+	DeleteExpiredReadings(ctx context.Context, arg DeleteExpiredReadingsParams) (int64, error)
+	DeleteExpiredReportJobs(ctx context.Context) (int64, error)
 	DeleteReactor(ctx context.Context, id int64) error
+	DeleteReportSchedule(ctx context.Context, id int64) (int64, error)
 	DeleteUser(ctx context.Context, id int64) error
+	// Turns off a schedule whose next run cannot be computed, such as one with a
+	// timezone since removed from the tz database, so it stops blocking the claim
+	// of the schedules due after it.
+	DisableReportSchedule(ctx context.Context, arg DisableReportScheduleParams) error
+	EnsureReadingPartitions(ctx context.Context, monthsAhead int32) error
+	EstimateDeviceReadings(ctx context.Context, deviceID int64) (int64, error)
+	FailReportJob(ctx context.Context, arg FailReportJobParams) (ReportJob, error)
+	GetActiveAlertIncidentByRule(ctx context.Context, ruleID int64) (AlertIncident, error)
+	GetActiveDeviceAPIKeyByHash(ctx context.Context, keyHash string) (DeviceApiKey, error)
+	GetAlertIncidentByID(ctx context.Context, id int64) (AlertIncident, error)
+	GetAlertRuleByID(ctx context.Context, id int64) (AlertRule, error)
 	GetAverageExperimentDuration(ctx context.Context) (float64, error)
 	GetDevice(ctx context.Context, id int64) (Device, error)
 	GetDeviceReadings(ctx context.Context, arg GetDeviceReadingsParams) ([]SensorReading, error)
+	// Readings older than the (timestamp, id) cursor, newest first.
 	GetDeviceReadingsPaged(ctx context.Context, arg GetDeviceReadingsPagedParams) ([]SensorReading, error)
+	// Readings newer than the (timestamp, id) cursor, oldest first.
+	GetDeviceReadingsPagedAfter(ctx context.Context, arg GetDeviceReadingsPagedAfterParams) ([]SensorReading, error)
 	GetDeviceStats(ctx context.Context) (GetDeviceStatsRow, error)
+	GetEffectiveRetentionPolicy(ctx context.Context, deviceID int64) (EffectiveRetentionPolicy, error)
 	GetExperimentByID(ctx context.Context, id int64) (Experiment, error)
+	GetExperimentTemplateByID(ctx context.Context, id int64) (ExperimentTemplate, error)
+	GetFileByID(ctx context.Context, id int64) (File, error)
 	GetReactorByID(ctx context.Context, id int64) (Reactor, error)
 	GetReadingByID(ctx context.Context, id int64) (SensorReading, error)
+	GetReadingByMessageID(ctx context.Context, arg GetReadingByMessageIDParams) (SensorReading, error)
 	GetReadingsByDate(ctx context.Context, arg GetReadingsByDateParams) ([]SensorReading, error)
+	// A copy of this query is streamed row by row for exports, see
+	// streamReadingsByTimeRange in postgres/export.go.
 	GetReadingsByTimeRange(ctx context.Context, arg GetReadingsByTimeRangeParams) ([]SensorReading, error)
+	GetReportJobByID(ctx context.Context, id int64) (ReportJob, error)
+	GetReportJobFile(ctx context.Context, arg GetReportJobFileParams) (GetReportJobFileRow, error)
+	GetReportScheduleByID(ctx context.Context, id int64) (ReportSchedule, error)
+	GetRollupWatermark(ctx context.Context, tier string) (time.Time, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id int64) (User, error)
 	GetUserPasswordByEmail(ctx context.Context, email string) (string, error)
 	GetUserRefreshTokenByID(ctx context.Context, id int64) (pgtype.Text, error)
 	InsertReading(ctx context.Context, arg InsertReadingParams) (SensorReading, error)
+	InsertReadings(ctx context.Context, arg []InsertReadingsParams) (int64, error)
+	ListAlertIncidents(ctx context.Context, arg ListAlertIncidentsParams) ([]AlertIncident, error)
+	ListAlertRules(ctx context.Context, deviceID pgtype.Int8) ([]AlertRule, error)
+	ListDeviceAPIKeys(ctx context.Context, deviceID int64) ([]DeviceApiKey, error)
+	ListDeviceChannels(ctx context.Context, deviceID int64) ([]DeviceChannel, error)
 	ListDevices(ctx context.Context) ([]Device, error)
+	ListDevicesByReactor(ctx context.Context, reactorID pgtype.Int8) ([]Device, error)
+	ListExperimentTemplates(ctx context.Context, search interface{}) ([]ExperimentTemplate, error)
 	ListExperiments(ctx context.Context, arg ListExperimentsParams) ([]Experiment, error)
+	ListHourRollupReadings(ctx context.Context, arg ListHourRollupReadingsParams) ([]ListHourRollupReadingsRow, error)
+	ListMinuteRollupReadings(ctx context.Context, arg ListMinuteRollupReadingsParams) ([]ListMinuteRollupReadingsRow, error)
+	// ListReactorDeviceAssignments returns the devices attached to a reactor at
+	// some point of a time range, with each attachment clipped to the range.
+	ListReactorDeviceAssignments(ctx context.Context, arg ListReactorDeviceAssignmentsParams) ([]ListReactorDeviceAssignmentsRow, error)
 	ListReactors(ctx context.Context, arg ListReactorsParams) ([]Reactor, error)
+	ListReportJobsByUser(ctx context.Context, arg ListReportJobsByUserParams) ([]ReportJob, error)
+	ListReportSchedules(ctx context.Context) ([]ReportSchedule, error)
+	ListRetentionPolicies(ctx context.Context) ([]RetentionPolicy, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	// Locks the most overdue schedule for the rest of the transaction. Other
+	// replicas skip it, so each run happens once.
+	LockDueReportSchedule(ctx context.Context, now time.Time) (ReportSchedule, error)
+	// Locks the enabled rules of a device for the rest of the transaction, so
+	// readings of the device are evaluated one at a time across replicas.
+	LockEnabledAlertRulesByDevice(ctx context.Context, deviceID int64) ([]AlertRule, error)
+	// Extends the lease of a job for the worker that claimed it. A job claimed
+	// again since has more attempts, so no row means the lease was lost.
+	RenewReportJobLease(ctx context.Context, arg RenewReportJobLeaseParams) (int64, error)
+	ResolveAlertIncident(ctx context.Context, arg ResolveAlertIncidentParams) (AlertIncident, error)
+	RevokeDeviceAPIKey(ctx context.Context, arg RevokeDeviceAPIKeyParams) (DeviceApiKey, error)
+	RollupHourReadings(ctx context.Context, arg RollupHourReadingsParams) (int64, error)
+	// Recomputes every minute bucket that received readings in the window, so
+	// late readings update buckets that were already rolled up.
+	RollupMinuteReadings(ctx context.Context, arg RollupMinuteReadingsParams) (int64, error)
+	SetAlertRuleBreachStartedAt(ctx context.Context, arg SetAlertRuleBreachStartedAtParams) error
+	SetExperimentRunStats(ctx context.Context, arg SetExperimentRunStatsParams) error
+	SetReportScheduleError(ctx context.Context, arg SetReportScheduleErrorParams) error
+	SetRollupWatermark(ctx context.Context, arg SetRollupWatermarkParams) error
+	StartExperiment(ctx context.Context, id int64) (Experiment, error)
+	StopExperiment(ctx context.Context, arg StopExperimentParams) (Experiment, error)
+	TouchDeviceAPIKey(ctx context.Context, id int64) error
+	// Online devices are only touched once per half of their expected interval,
+	// well within the stale threshold, so readings do not all lock and rewrite
+	// the device row.
+	TouchDeviceLastSeen(ctx context.Context, arg TouchDeviceLastSeenParams) error
+	UpdateAlertRule(ctx context.Context, arg UpdateAlertRuleParams) (AlertRule, error)
 	UpdateDevice(ctx context.Context, arg UpdateDeviceParams) (Device, error)
+	UpdateDeviceChannel(ctx context.Context, arg UpdateDeviceChannelParams) (DeviceChannel, error)
+	UpdateDevicesConnectivity(ctx context.Context, arg UpdateDevicesConnectivityParams) ([]UpdateDevicesConnectivityRow, error)
 	UpdateExperiment(ctx context.Context, arg UpdateExperimentParams) (Experiment, error)
+	UpdateExperimentTemplate(ctx context.Context, arg UpdateExperimentTemplateParams) (ExperimentTemplate, error)
+	UpdateGlobalRetentionPolicy(ctx context.Context, arg UpdateGlobalRetentionPolicyParams) (RetentionPolicy, error)
+	// A pdf_file_id of 0 detaches the document, including a legacy pdf_url.
 	UpdateReactor(ctx context.Context, arg UpdateReactorParams) error
+	UpdateReportJobProgress(ctx context.Context, arg UpdateReportJobProgressParams) error
+	UpdateReportSchedule(ctx context.Context, arg UpdateReportScheduleParams) (ReportSchedule, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
 	UpdateUserRefreshToken(ctx context.Context, arg UpdateUserRefreshTokenParams) error
+	UpsertDeviceRetentionPolicy(ctx context.Context, arg UpsertDeviceRetentionPolicyParams) (RetentionPolicy, error)
 }
 
 var _ Querier = (*Queries)(nil)
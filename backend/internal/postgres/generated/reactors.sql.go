@@ -63,16 +63,16 @@ func (q *Queries) CountListReactors(ctx context.Context, arg CountListReactorsPa
 }
 
 const createReactor = `-- name: CreateReactor :one
-INSERT INTO reactors (name, status, pathway, pdf_url)
+INSERT INTO reactors (name, status, pathway, pdf_file_id)
 VALUES ($1, $2, $3, $4)
-RETURNING id, name, status, pathway, pdf_url, deleted_at, created_at
+RETURNING id, name, status, pathway, pdf_url, deleted_at, created_at, pdf_file_id
 `
 
 type CreateReactorParams struct {
-	Name    string      `json:"name"`
-	Status  string      `json:"status"`
-	Pathway pgtype.Text `json:"pathway"`
-	PdfUrl  pgtype.Text `json:"pdf_url"`
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	Pathway   pgtype.Text `json:"pathway"`
+	PdfFileID pgtype.Int8 `json:"pdf_file_id"`
 }
 
 func (q *Queries) CreateReactor(ctx context.Context, arg CreateReactorParams) (Reactor, error) {
@@ -80,7 +80,7 @@ func (q *Queries) CreateReactor(ctx context.Context, arg CreateReactorParams) (R
 		arg.Name,
 		arg.Status,
 		arg.Pathway,
-		arg.PdfUrl,
+		arg.PdfFileID,
 	)
 	var i Reactor
 	err := row.Scan(
@@ -91,6 +91,7 @@ func (q *Queries) CreateReactor(ctx context.Context, arg CreateReactorParams) (R
 		&i.PdfUrl,
 		&i.DeletedAt,
 		&i.CreatedAt,
+		&i.PdfFileID,
 	)
 	return i, err
 }
@@ -107,7 +108,7 @@ func (q *Queries) DeleteReactor(ctx context.Context, id int64) error {
 }
 
 const getReactorByID = `-- name: GetReactorByID :one
-SELECT id, name, status, pathway, pdf_url, deleted_at, created_at FROM reactors
+SELECT id, name, status, pathway, pdf_url, deleted_at, created_at, pdf_file_id FROM reactors
 WHERE id = $1 AND deleted_at IS NULL
 `
 
@@ -122,12 +123,13 @@ func (q *Queries) GetReactorByID(ctx context.Context, id int64) (Reactor, error)
 		&i.PdfUrl,
 		&i.DeletedAt,
 		&i.CreatedAt,
+		&i.PdfFileID,
 	)
 	return i, err
 }
 
 const listReactors = `-- name: ListReactors :many
-SELECT id, name, status, pathway, pdf_url, deleted_at, created_at FROM reactors
+SELECT id, name, status, pathway, pdf_url, deleted_at, created_at, pdf_file_id FROM reactors
 WHERE deleted_at IS NULL
     AND (
         COALESCE($1, '') = '' 
@@ -176,6 +178,7 @@ func (q *Queries) ListReactors(ctx context.Context, arg ListReactorsParams) ([]R
 			&i.PdfUrl,
 			&i.DeletedAt,
 			&i.CreatedAt,
+			&i.PdfFileID,
 		); err != nil {
 			return nil, err
 		}
@@ -192,24 +195,26 @@ UPDATE reactors
 SET name = coalesce($1, name),
     status = coalesce($2, status),
     pathway = coalesce($3, pathway),
-    pdf_url = coalesce($4, pdf_url)
+    pdf_file_id = CASE WHEN $4::bigint = 0 THEN NULL ELSE coalesce($4, pdf_file_id) END,
+    pdf_url = CASE WHEN $4::bigint = 0 THEN NULL ELSE pdf_url END
 WHERE id = $5 AND deleted_at IS NULL
 `
 
 type UpdateReactorParams struct {
-	Name    pgtype.Text `json:"name"`
-	Status  pgtype.Text `json:"status"`
-	Pathway pgtype.Text `json:"pathway"`
-	PdfUrl  pgtype.Text `json:"pdf_url"`
-	ID      int64       `json:"id"`
+	Name      pgtype.Text `json:"name"`
+	Status    pgtype.Text `json:"status"`
+	Pathway   pgtype.Text `json:"pathway"`
+	PdfFileID pgtype.Int8 `json:"pdf_file_id"`
+	ID        int64       `json:"id"`
 }
 
+// A pdf_file_id of 0 detaches the document, including a legacy pdf_url.
 func (q *Queries) UpdateReactor(ctx context.Context, arg UpdateReactorParams) error {
 	_, err := q.db.Exec(ctx, updateReactor,
 		arg.Name,
 		arg.Status,
 		arg.Pathway,
-		arg.PdfUrl,
+		arg.PdfFileID,
 		arg.ID,
 	)
 	return err
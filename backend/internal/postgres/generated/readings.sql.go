@@ -12,6 +12,137 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const aggregateReadings = `-- name: AggregateReadings :many
+SELECT
+    to_timestamp(floor(extract(epoch FROM r.timestamp) / $1::bigint) * $1::bigint)::timestamptz AS bucket,
+    p.key::text AS key,
+    COUNT(*) AS count,
+    AVG(p.value::float8)::float8 AS avg_value,
+    MIN(p.value::float8)::float8 AS min_value,
+    MAX(p.value::float8)::float8 AS max_value,
+    (array_agg(p.value::float8 ORDER BY r.timestamp DESC))[1]::float8 AS last_value
+FROM sensor_readings r
+CROSS JOIN LATERAL jsonb_each(r.payload) AS p
+WHERE r.device_id = $2
+  AND r.timestamp >= $3
+  AND r.timestamp < $4
+  AND jsonb_typeof(p.value) = 'number'
+  AND p.key NOT IN ('timestamp', 'ServerTS') -- repository.ReadingMetadataKeys
+  AND ($5::text[] IS NULL OR p.key = ANY($5::text[]))
+GROUP BY 1, 2
+ORDER BY 1 ASC, 2 ASC
+`
+
+type AggregateReadingsParams struct {
+	BucketSeconds int64     `json:"bucket_seconds"`
+	DeviceID      int64     `json:"device_id"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Keys          []string  `json:"keys"`
+}
+
+type AggregateReadingsRow struct {
+	Bucket    time.Time `json:"bucket"`
+	Key       string    `json:"key"`
+	Count     int64     `json:"count"`
+	AvgValue  float64   `json:"avg_value"`
+	MinValue  float64   `json:"min_value"`
+	MaxValue  float64   `json:"max_value"`
+	LastValue float64   `json:"last_value"`
+}
+
+func (q *Queries) AggregateReadings(ctx context.Context, arg AggregateReadingsParams) ([]AggregateReadingsRow, error) {
+	rows, err := q.db.Query(ctx, aggregateReadings,
+		arg.BucketSeconds,
+		arg.DeviceID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Keys,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AggregateReadingsRow{}
+	for rows.Next() {
+		var i AggregateReadingsRow
+		if err := rows.Scan(
+			&i.Bucket,
+			&i.Key,
+			&i.Count,
+			&i.AvgValue,
+			&i.MinValue,
+			&i.MaxValue,
+			&i.LastValue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimReadingMessageID = `-- name: ClaimReadingMessageID :execrows
+INSERT INTO reading_message_ids (device_id, message_id, received_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (device_id, message_id) DO NOTHING
+`
+
+type ClaimReadingMessageIDParams struct {
+	DeviceID   int64     `json:"device_id"`
+	MessageID  string    `json:"message_id"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Claims a message id for a reading about to be stored. No row is affected
+// when it was claimed before; a concurrent claim waits for the transaction
+// that made it.
+func (q *Queries) ClaimReadingMessageID(ctx context.Context, arg ClaimReadingMessageIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimReadingMessageID, arg.DeviceID, arg.MessageID, arg.ReceivedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimReadingMessageIDs = `-- name: ClaimReadingMessageIDs :many
+INSERT INTO reading_message_ids (device_id, message_id, received_at)
+SELECT $1, unnest($2::text[]), $3
+ON CONFLICT (device_id, message_id) DO NOTHING
+RETURNING message_id
+`
+
+type ClaimReadingMessageIDsParams struct {
+	DeviceID   int64     `json:"device_id"`
+	MessageIds []string  `json:"message_ids"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Claims the message ids of a batch, returning those that were not claimed
+// before.
+func (q *Queries) ClaimReadingMessageIDs(ctx context.Context, arg ClaimReadingMessageIDsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, claimReadingMessageIDs, arg.DeviceID, arg.MessageIds, arg.ReceivedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var message_id string
+		if err := rows.Scan(&message_id); err != nil {
+			return nil, err
+		}
+		items = append(items, message_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const countDeviceReadings = `-- name: CountDeviceReadings :one
 SELECT COUNT(*) AS count
 FROM sensor_readings
@@ -25,11 +156,31 @@ func (q *Queries) CountDeviceReadings(ctx context.Context, deviceID int64) (int6
 	return count, err
 }
 
+const ensureReadingPartitions = `-- name: EnsureReadingPartitions :exec
+SELECT ensure_sensor_readings_partitions($1::int)
+`
+
+func (q *Queries) EnsureReadingPartitions(ctx context.Context, monthsAhead int32) error {
+	_, err := q.db.Exec(ctx, ensureReadingPartitions, monthsAhead)
+	return err
+}
+
+const estimateDeviceReadings = `-- name: EstimateDeviceReadings :one
+SELECT estimate_device_readings($1::bigint)::bigint AS estimate
+`
+
+func (q *Queries) EstimateDeviceReadings(ctx context.Context, deviceID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, estimateDeviceReadings, deviceID)
+	var estimate int64
+	err := row.Scan(&estimate)
+	return estimate, err
+}
+
 const getDeviceReadings = `-- name: GetDeviceReadings :many
-SELECT id, device_id, payload, timestamp
+SELECT id, device_id, payload, timestamp, received_at, message_id
 FROM sensor_readings
 WHERE device_id = $1
-ORDER BY timestamp DESC
+ORDER BY timestamp DESC, id DESC
 LIMIT $2 OFFSET $3
 `
 
@@ -53,6 +204,8 @@ func (q *Queries) GetDeviceReadings(ctx context.Context, arg GetDeviceReadingsPa
 			&i.DeviceID,
 			&i.Payload,
 			&i.Timestamp,
+			&i.ReceivedAt,
+			&i.MessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -65,27 +218,28 @@ func (q *Queries) GetDeviceReadings(ctx context.Context, arg GetDeviceReadingsPa
 }
 
 const getDeviceReadingsPaged = `-- name: GetDeviceReadingsPaged :many
-SELECT id, device_id, payload, timestamp
+SELECT id, device_id, payload, timestamp, received_at, message_id
 FROM sensor_readings
 WHERE device_id = $1
-  AND timestamp < $2
-ORDER BY timestamp DESC
-LIMIT $3 OFFSET $4
+  AND (timestamp, id) < ($2::timestamptz, $3::bigint)
+ORDER BY timestamp DESC, id DESC
+LIMIT $4
 `
 
 type GetDeviceReadingsPagedParams struct {
-	DeviceID  int64     `json:"device_id"`
-	Timestamp time.Time `json:"timestamp"`
-	Limit     int32     `json:"limit"`
-	Offset    int32     `json:"offset"`
+	DeviceID        int64     `json:"device_id"`
+	CursorTimestamp time.Time `json:"cursor_timestamp"`
+	CursorID        int64     `json:"cursor_id"`
+	Limit           int32     `json:"limit"`
 }
 
+// Readings older than the (timestamp, id) cursor, newest first.
 func (q *Queries) GetDeviceReadingsPaged(ctx context.Context, arg GetDeviceReadingsPagedParams) ([]SensorReading, error) {
 	rows, err := q.db.Query(ctx, getDeviceReadingsPaged,
 		arg.DeviceID,
-		arg.Timestamp,
+		arg.CursorTimestamp,
+		arg.CursorID,
 		arg.Limit,
-		arg.Offset,
 	)
 	if err != nil {
 		return nil, err
@@ -99,6 +253,57 @@ func (q *Queries) GetDeviceReadingsPaged(ctx context.Context, arg GetDeviceReadi
 			&i.DeviceID,
 			&i.Payload,
 			&i.Timestamp,
+			&i.ReceivedAt,
+			&i.MessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeviceReadingsPagedAfter = `-- name: GetDeviceReadingsPagedAfter :many
+SELECT id, device_id, payload, timestamp, received_at, message_id
+FROM sensor_readings
+WHERE device_id = $1
+  AND (timestamp, id) > ($2::timestamptz, $3::bigint)
+ORDER BY timestamp ASC, id ASC
+LIMIT $4
+`
+
+type GetDeviceReadingsPagedAfterParams struct {
+	DeviceID        int64     `json:"device_id"`
+	CursorTimestamp time.Time `json:"cursor_timestamp"`
+	CursorID        int64     `json:"cursor_id"`
+	Limit           int32     `json:"limit"`
+}
+
+// Readings newer than the (timestamp, id) cursor, oldest first.
+func (q *Queries) GetDeviceReadingsPagedAfter(ctx context.Context, arg GetDeviceReadingsPagedAfterParams) ([]SensorReading, error) {
+	rows, err := q.db.Query(ctx, getDeviceReadingsPagedAfter,
+		arg.DeviceID,
+		arg.CursorTimestamp,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SensorReading{}
+	for rows.Next() {
+		var i SensorReading
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeviceID,
+			&i.Payload,
+			&i.Timestamp,
+			&i.ReceivedAt,
+			&i.MessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -111,7 +316,7 @@ func (q *Queries) GetDeviceReadingsPaged(ctx context.Context, arg GetDeviceReadi
 }
 
 const getReadingByID = `-- name: GetReadingByID :one
-SELECT id, device_id, payload, timestamp
+SELECT id, device_id, payload, timestamp, received_at, message_id
 FROM sensor_readings
 WHERE id = $1
 `
@@ -124,12 +329,39 @@ func (q *Queries) GetReadingByID(ctx context.Context, id int64) (SensorReading,
 		&i.DeviceID,
 		&i.Payload,
 		&i.Timestamp,
+		&i.ReceivedAt,
+		&i.MessageID,
+	)
+	return i, err
+}
+
+const getReadingByMessageID = `-- name: GetReadingByMessageID :one
+SELECT id, device_id, payload, timestamp, received_at, message_id
+FROM sensor_readings
+WHERE device_id = $1 AND message_id = $2
+`
+
+type GetReadingByMessageIDParams struct {
+	DeviceID  int64       `json:"device_id"`
+	MessageID pgtype.Text `json:"message_id"`
+}
+
+func (q *Queries) GetReadingByMessageID(ctx context.Context, arg GetReadingByMessageIDParams) (SensorReading, error) {
+	row := q.db.QueryRow(ctx, getReadingByMessageID, arg.DeviceID, arg.MessageID)
+	var i SensorReading
+	err := row.Scan(
+		&i.ID,
+		&i.DeviceID,
+		&i.Payload,
+		&i.Timestamp,
+		&i.ReceivedAt,
+		&i.MessageID,
 	)
 	return i, err
 }
 
 const getReadingsByDate = `-- name: GetReadingsByDate :many
-SELECT id, device_id, payload, timestamp
+SELECT id, device_id, payload, timestamp, received_at, message_id
 FROM sensor_readings
 WHERE device_id = $1
   AND timestamp >= $2::date
@@ -156,6 +388,8 @@ func (q *Queries) GetReadingsByDate(ctx context.Context, arg GetReadingsByDatePa
 			&i.DeviceID,
 			&i.Payload,
 			&i.Timestamp,
+			&i.ReceivedAt,
+			&i.MessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -168,12 +402,12 @@ func (q *Queries) GetReadingsByDate(ctx context.Context, arg GetReadingsByDatePa
 }
 
 const getReadingsByTimeRange = `-- name: GetReadingsByTimeRange :many
-SELECT id, device_id, payload, timestamp
+SELECT id, device_id, payload, timestamp, received_at, message_id
 FROM sensor_readings
 WHERE device_id = $1
   AND timestamp >= $2
-  AND timestamp <= $3
-ORDER BY timestamp ASC
+  AND timestamp < $3
+ORDER BY timestamp ASC, id ASC
 `
 
 type GetReadingsByTimeRangeParams struct {
@@ -182,6 +416,8 @@ type GetReadingsByTimeRangeParams struct {
 	Timestamp_2 time.Time `json:"timestamp_2"`
 }
 
+// A copy of this query is streamed row by row for exports, see
+// streamReadingsByTimeRange in postgres/export.go.
 func (q *Queries) GetReadingsByTimeRange(ctx context.Context, arg GetReadingsByTimeRangeParams) ([]SensorReading, error) {
 	rows, err := q.db.Query(ctx, getReadingsByTimeRange, arg.DeviceID, arg.Timestamp, arg.Timestamp_2)
 	if err != nil {
@@ -196,6 +432,8 @@ func (q *Queries) GetReadingsByTimeRange(ctx context.Context, arg GetReadingsByT
 			&i.DeviceID,
 			&i.Payload,
 			&i.Timestamp,
+			&i.ReceivedAt,
+			&i.MessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -208,24 +446,43 @@ func (q *Queries) GetReadingsByTimeRange(ctx context.Context, arg GetReadingsByT
 }
 
 const insertReading = `-- name: InsertReading :one
-INSERT INTO sensor_readings (device_id, payload)
-VALUES ($1, $2)
-RETURNING id, device_id, payload, timestamp
+INSERT INTO sensor_readings (device_id, payload, timestamp, received_at, message_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, device_id, payload, timestamp, received_at, message_id
 `
 
 type InsertReadingParams struct {
-	DeviceID int64  `json:"device_id"`
-	Payload  []byte `json:"payload"`
+	DeviceID   int64       `json:"device_id"`
+	Payload    []byte      `json:"payload"`
+	Timestamp  time.Time   `json:"timestamp"`
+	ReceivedAt time.Time   `json:"received_at"`
+	MessageID  pgtype.Text `json:"message_id"`
 }
 
 func (q *Queries) InsertReading(ctx context.Context, arg InsertReadingParams) (SensorReading, error) {
-	row := q.db.QueryRow(ctx, insertReading, arg.DeviceID, arg.Payload)
+	row := q.db.QueryRow(ctx, insertReading,
+		arg.DeviceID,
+		arg.Payload,
+		arg.Timestamp,
+		arg.ReceivedAt,
+		arg.MessageID,
+	)
 	var i SensorReading
 	err := row.Scan(
 		&i.ID,
 		&i.DeviceID,
 		&i.Payload,
 		&i.Timestamp,
+		&i.ReceivedAt,
+		&i.MessageID,
 	)
 	return i, err
 }
+
+type InsertReadingsParams struct {
+	DeviceID   int64       `json:"device_id"`
+	Payload    []byte      `json:"payload"`
+	Timestamp  time.Time   `json:"timestamp"`
+	ReceivedAt time.Time   `json:"received_at"`
+	MessageID  pgtype.Text `json:"message_id"`
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+)
+
+const (
+	readingsNotifyChannel = "sensor_readings"
+	listenRetryInterval   = 5 * time.Second
+)
+
+type readingNotification struct {
+	ID       uint32 `json:"id"`
+	DeviceID uint32 `json:"device_id"`
+}
+
+// ListenReadings holds a dedicated connection subscribed to the reading insert
+// notifications and hands every new reading to publish. Since the notification
+// comes from Postgres, readings written by any replica are delivered.
+// wanted lets the caller skip loading readings for devices nobody watches.
+// It reconnects on connection loss and returns when ctx is cancelled.
+func (r *DeviceRepository) ListenReadings(ctx context.Context, wanted func(deviceID uint32) bool, publish func(reading *repository.Reading)) {
+	for {
+		err := r.listenReadings(ctx, wanted, publish)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("reading listener stopped: %v, retrying in %s", err, listenRetryInterval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+func (r *DeviceRepository) listenReadings(ctx context.Context, wanted func(deviceID uint32) bool, publish func(reading *repository.Reading)) error {
+	conn, err := r.store.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the connection is still LISTENing, so close it rather than hand it back
+	defer func() {
+		conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+readingsNotifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var n readingNotification
+		if err := json.Unmarshal([]byte(notification.Payload), &n); err != nil {
+			log.Printf("invalid reading notification %q: %v", notification.Payload, err)
+			continue
+		}
+
+		if !wanted(n.DeviceID) {
+			continue
+		}
+
+		reading, err := r.GetReadingByID(ctx, n.ID)
+		if err != nil {
+			log.Printf("failed to load notified reading %d: %v", n.ID, err)
+			continue
+		}
+
+		publish(reading)
+	}
+}
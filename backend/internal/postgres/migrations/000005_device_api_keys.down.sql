@@ -0,0 +1,3 @@
+ALTER TABLE "device_api_keys" DROP CONSTRAINT "device_api_keys_device_device_id_fkey";
+
+DROP TABLE IF EXISTS "device_api_keys";
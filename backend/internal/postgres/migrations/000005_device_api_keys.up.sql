@@ -0,0 +1,14 @@
+CREATE TABLE "device_api_keys" (
+    "id" bigserial PRIMARY KEY,
+    "device_id" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "prefix" varchar(16) NOT NULL,
+    "key_hash" varchar(64) UNIQUE NOT NULL,
+    "last_used_at" timestamptz NULL,
+    "revoked_at" timestamptz NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "device_api_keys_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id")
+);
+
+CREATE INDEX "device_api_keys_device_id_idx" ON "device_api_keys" ("device_id");
@@ -0,0 +1,5 @@
+ALTER TABLE "sensor_readings" ADD COLUMN "received_at" timestamptz NOT NULL DEFAULT (now());
+
+-- "timestamp" now holds the device measured-at time. Rows written before this
+-- migration only ever had the server time, so it doubles as received_at.
+UPDATE "sensor_readings" SET "received_at" = "timestamp";
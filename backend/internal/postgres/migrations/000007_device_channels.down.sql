@@ -0,0 +1,3 @@
+ALTER TABLE "device_channels" DROP CONSTRAINT "device_channels_device_device_id_fkey";
+
+DROP TABLE IF EXISTS "device_channels";
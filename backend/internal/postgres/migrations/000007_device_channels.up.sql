@@ -0,0 +1,15 @@
+CREATE TABLE "device_channels" (
+    "id" bigserial PRIMARY KEY,
+    "device_id" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "unit" varchar(50) NOT NULL DEFAULT '',
+    "data_type" varchar(20) NOT NULL DEFAULT 'float',
+    "min_value" float8 NULL,
+    "max_value" float8 NULL,
+    "required" boolean NOT NULL DEFAULT false,
+    "position" int NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "device_channels_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id"),
+    CONSTRAINT "device_channels_device_id_name_key" UNIQUE ("device_id", "name")
+);
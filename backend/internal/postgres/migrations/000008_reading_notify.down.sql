@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS "sensor_readings_notify" ON "sensor_readings";
+DROP FUNCTION IF EXISTS "notify_sensor_reading"();
@@ -0,0 +1,16 @@
+-- Announce every stored reading so each backend replica can push it to its
+-- live stream subscribers. Only ids are sent to stay under the NOTIFY payload
+-- limit; listeners load the reading itself.
+CREATE OR REPLACE FUNCTION "notify_sensor_reading"() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify(
+    'sensor_readings',
+    json_build_object('id', NEW."id", 'device_id', NEW."device_id")::text
+  );
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "sensor_readings_notify"
+AFTER INSERT ON "sensor_readings"
+FOR EACH ROW EXECUTE FUNCTION "notify_sensor_reading"();
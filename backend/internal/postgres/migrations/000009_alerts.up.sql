@@ -0,0 +1,39 @@
+CREATE TABLE "alert_rules" (
+    "id" bigserial PRIMARY KEY,
+    "device_id" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "key" varchar(100) NOT NULL,
+    "comparator" varchar(10) NOT NULL,
+    "threshold" float8 NOT NULL,
+    "duration_seconds" int NOT NULL DEFAULT 0,
+    "severity" varchar(20) NOT NULL DEFAULT 'warning',
+    "notify_emails" text[] NOT NULL DEFAULT '{}',
+    "enabled" boolean NOT NULL DEFAULT true,
+    "breach_started_at" timestamptz NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "alert_rules_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id")
+);
+
+CREATE INDEX "alert_rules_device_id_idx" ON "alert_rules" ("device_id");
+
+CREATE TABLE "alert_incidents" (
+    "id" bigserial PRIMARY KEY,
+    "rule_id" bigint NOT NULL,
+    "device_id" bigint NOT NULL,
+    "severity" varchar(20) NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'open',
+    "trigger_value" float8 NOT NULL,
+    "opened_at" timestamptz NOT NULL DEFAULT (now()),
+    "acknowledged_at" timestamptz NULL,
+    "acknowledged_by" bigint NULL,
+    "resolved_at" timestamptz NULL,
+
+    CONSTRAINT "alert_incidents_alert_rules_rule_id_fkey" FOREIGN KEY ("rule_id") REFERENCES "alert_rules" ("id") ON DELETE CASCADE,
+    CONSTRAINT "alert_incidents_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id"),
+    CONSTRAINT "alert_incidents_users_acknowledged_by_fkey" FOREIGN KEY ("acknowledged_by") REFERENCES "users" ("id")
+);
+
+-- a rule has at most one incident that is not yet resolved
+CREATE UNIQUE INDEX "alert_incidents_rule_id_active_idx" ON "alert_incidents" ("rule_id") WHERE "status" <> 'resolved';
+CREATE INDEX "alert_incidents_status_opened_at_idx" ON "alert_incidents" ("status", "opened_at" DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE "device" DROP COLUMN "connectivity";
+ALTER TABLE "device" DROP COLUMN "expected_interval_seconds";
+ALTER TABLE "device" DROP COLUMN "last_seen_at";
@@ -0,0 +1,12 @@
+ALTER TABLE "device" ADD COLUMN "last_seen_at" timestamptz NULL;
+ALTER TABLE "device" ADD COLUMN "expected_interval_seconds" int NOT NULL DEFAULT 60;
+ALTER TABLE "device" ADD COLUMN "connectivity" varchar(20) NOT NULL DEFAULT 'offline';
+
+UPDATE "device" d
+SET "last_seen_at" = r."last_seen_at"
+FROM (
+    SELECT "device_id", MAX("received_at") AS "last_seen_at"
+    FROM "sensor_readings"
+    GROUP BY "device_id"
+) r
+WHERE d."id" = r."device_id";
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "sensor_readings_device_id_message_id_key";
+ALTER TABLE "sensor_readings" DROP COLUMN "message_id";
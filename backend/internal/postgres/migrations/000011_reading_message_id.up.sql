@@ -0,0 +1,4 @@
+ALTER TABLE "sensor_readings" ADD COLUMN "message_id" varchar(100) NULL;
+
+-- readings without a message id never conflict since NULLs are distinct
+CREATE UNIQUE INDEX "sensor_readings_device_id_message_id_key" ON "sensor_readings" ("device_id", "message_id");
@@ -0,0 +1,31 @@
+DROP FUNCTION IF EXISTS "ensure_sensor_readings_partitions"(integer);
+DROP FUNCTION IF EXISTS "create_sensor_readings_partition"(timestamptz);
+
+CREATE TABLE "sensor_readings_flat" (
+    "id" bigint NOT NULL DEFAULT nextval('sensor_readings_id_seq') PRIMARY KEY,
+    "device_id" bigint NOT NULL,
+    "payload" jsonb,
+    "timestamp" timestamptz NOT NULL DEFAULT (now()),
+    "received_at" timestamptz NOT NULL DEFAULT (now()),
+    "message_id" varchar(100) NULL
+);
+
+INSERT INTO "sensor_readings_flat" ("id", "device_id", "payload", "timestamp", "received_at", "message_id")
+SELECT "id", "device_id", "payload", "timestamp", "received_at", "message_id" FROM "sensor_readings";
+
+ALTER SEQUENCE "sensor_readings_id_seq" OWNED BY "sensor_readings_flat"."id";
+DROP TABLE "sensor_readings";
+
+ALTER TABLE "sensor_readings_flat" RENAME TO "sensor_readings";
+ALTER TABLE "sensor_readings" RENAME CONSTRAINT "sensor_readings_flat_pkey" TO "sensor_readings_pkey";
+ALTER TABLE "sensor_readings" ADD CONSTRAINT "fk_device_id" FOREIGN KEY ("device_id") REFERENCES "device" ("id");
+
+CREATE INDEX "idx_sensor_device_ts" ON "sensor_readings" ("device_id", "timestamp");
+-- duplicates that only differed by timestamp keep the lowest id
+DELETE FROM "sensor_readings" a USING "sensor_readings" b
+WHERE a."device_id" = b."device_id" AND a."message_id" = b."message_id" AND a."id" > b."id";
+CREATE UNIQUE INDEX "sensor_readings_device_id_message_id_key" ON "sensor_readings" ("device_id", "message_id");
+
+CREATE TRIGGER "sensor_readings_notify"
+AFTER INSERT ON "sensor_readings"
+FOR EACH ROW EXECUTE FUNCTION "notify_sensor_reading"();
@@ -0,0 +1,90 @@
+-- Move sensor_readings to monthly range partitions on "timestamp".
+--
+-- Existing rows are not copied: the old table is attached as one partition
+-- covering everything up to the end of its newest month, so the migration
+-- does not rewrite the history. Later months get their own partitions,
+-- created ahead of time by ensure_sensor_readings_partitions().
+
+ALTER TABLE "sensor_readings" RENAME TO "sensor_readings_legacy";
+-- the parent's (id, timestamp) key replaces it once the table is attached
+ALTER TABLE "sensor_readings_legacy" DROP CONSTRAINT "sensor_readings_pkey";
+ALTER TABLE "sensor_readings_legacy" DROP CONSTRAINT "fk_device_id";
+DROP TRIGGER "sensor_readings_notify" ON "sensor_readings_legacy";
+DROP INDEX "sensor_readings_device_id_message_id_key";
+DROP INDEX "idx_sensor_device_ts";
+
+CREATE TABLE "sensor_readings" (
+    "id" bigint NOT NULL DEFAULT nextval('sensor_readings_id_seq'),
+    "device_id" bigint NOT NULL,
+    "payload" jsonb,
+    "timestamp" timestamptz NOT NULL DEFAULT (now()),
+    "received_at" timestamptz NOT NULL DEFAULT (now()),
+    "message_id" varchar(100) NULL,
+
+    -- unique constraints on a partitioned table must include the partition key
+    CONSTRAINT "sensor_readings_pkey" PRIMARY KEY ("id", "timestamp"),
+    CONSTRAINT "fk_device_id" FOREIGN KEY ("device_id") REFERENCES "device" ("id")
+) PARTITION BY RANGE ("timestamp");
+
+ALTER SEQUENCE "sensor_readings_id_seq" OWNED BY "sensor_readings"."id";
+
+CREATE INDEX "idx_sensor_device_ts" ON "sensor_readings" ("device_id", "timestamp");
+CREATE UNIQUE INDEX "sensor_readings_device_id_message_id_key" ON "sensor_readings" ("device_id", "message_id", "timestamp");
+CREATE INDEX "idx_sensor_device_message_id" ON "sensor_readings" ("device_id", "message_id") WHERE "message_id" IS NOT NULL;
+
+CREATE TRIGGER "sensor_readings_notify"
+AFTER INSERT ON "sensor_readings"
+FOR EACH ROW EXECUTE FUNCTION "notify_sensor_reading"();
+
+DO $$
+DECLARE
+    legacy_end timestamptz;
+BEGIN
+    SELECT (date_trunc('month', GREATEST(MAX("timestamp"), now()) AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC'
+    INTO legacy_end
+    FROM "sensor_readings_legacy";
+
+    -- a validated CHECK lets ATTACH skip scanning the table to prove the bound
+    EXECUTE format(
+        'ALTER TABLE "sensor_readings_legacy" ADD CONSTRAINT "sensor_readings_legacy_bound" CHECK ("timestamp" < %L)',
+        legacy_end
+    );
+    EXECUTE format(
+        'ALTER TABLE "sensor_readings" ATTACH PARTITION "sensor_readings_legacy" FOR VALUES FROM (MINVALUE) TO (%L)',
+        legacy_end
+    );
+    ALTER TABLE "sensor_readings_legacy" DROP CONSTRAINT "sensor_readings_legacy_bound";
+END;
+$$;
+
+-- Creates the partition for the UTC month containing "month". Months that an
+-- existing partition already covers (including the legacy one) are skipped.
+CREATE OR REPLACE FUNCTION "create_sensor_readings_partition"("month" timestamptz) RETURNS void AS $$
+DECLARE
+    start_at timestamptz := date_trunc('month', "month" AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    end_at timestamptz := (date_trunc('month', "month" AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';
+BEGIN
+    EXECUTE format(
+        'CREATE TABLE IF NOT EXISTS %I PARTITION OF "sensor_readings" FOR VALUES FROM (%L) TO (%L)',
+        'sensor_readings_' || to_char(start_at AT TIME ZONE 'UTC', 'YYYY_MM'),
+        start_at,
+        end_at
+    );
+EXCEPTION
+    -- raised when the range overlaps an existing partition
+    WHEN invalid_object_definition THEN
+        NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Makes sure partitions exist for the current month and the next
+-- "months_ahead" months.
+CREATE OR REPLACE FUNCTION "ensure_sensor_readings_partitions"("months_ahead" integer) RETURNS void AS $$
+BEGIN
+    FOR i IN 0..GREATEST("months_ahead", 0) LOOP
+        PERFORM "create_sensor_readings_partition"(now() + make_interval(months => i));
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT "ensure_sensor_readings_partitions"(3);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "sensor_readings_received_at_idx";
+DROP TABLE IF EXISTS "reading_rollup_watermarks";
+DROP TABLE IF EXISTS "reading_rollups_1h";
+DROP TABLE IF EXISTS "reading_rollups_1m";
+DROP VIEW IF EXISTS "effective_retention_policies";
+DROP TABLE IF EXISTS "retention_policies";
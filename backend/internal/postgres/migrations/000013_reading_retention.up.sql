@@ -0,0 +1,79 @@
+-- Retention is configured globally (device_id NULL) and can be replaced per
+-- device. A NULL retention keeps that tier forever.
+CREATE TABLE "retention_policies" (
+    "id" bigserial PRIMARY KEY,
+    "device_id" bigint NULL,
+    "raw_retention_days" int NULL,
+    "minute_rollup_retention_days" int NULL,
+    "hour_rollup_retention_days" int NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "retention_policies_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id")
+);
+
+CREATE UNIQUE INDEX "retention_policies_device_id_key" ON "retention_policies" ("device_id");
+-- only one global policy
+CREATE UNIQUE INDEX "retention_policies_global_key" ON "retention_policies" (("device_id" IS NULL)) WHERE "device_id" IS NULL;
+
+INSERT INTO "retention_policies" ("device_id") VALUES (NULL);
+
+-- the policy that applies to every device: its own one, else the global one
+CREATE VIEW "effective_retention_policies" AS
+SELECT
+    d."id" AS "device_id",
+    CASE WHEN p."id" IS NULL THEN g."raw_retention_days" ELSE p."raw_retention_days" END AS "raw_retention_days",
+    CASE WHEN p."id" IS NULL THEN g."minute_rollup_retention_days" ELSE p."minute_rollup_retention_days" END AS "minute_rollup_retention_days",
+    CASE WHEN p."id" IS NULL THEN g."hour_rollup_retention_days" ELSE p."hour_rollup_retention_days" END AS "hour_rollup_retention_days"
+FROM "device" d
+LEFT JOIN "retention_policies" p ON p."device_id" = d."id"
+LEFT JOIN "retention_policies" g ON g."device_id" IS NULL;
+
+-- Rollups keep sums and counts rather than averages so coarser buckets can be
+-- built from finer ones.
+CREATE TABLE "reading_rollups_1m" (
+    "device_id" bigint NOT NULL,
+    "bucket" timestamptz NOT NULL,
+    "key" text NOT NULL,
+    "count" bigint NOT NULL,
+    "sum_value" float8 NOT NULL,
+    "min_value" float8 NOT NULL,
+    "max_value" float8 NOT NULL,
+    "last_value" float8 NOT NULL,
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+
+    PRIMARY KEY ("device_id", "bucket", "key"),
+    CONSTRAINT "reading_rollups_1m_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id")
+);
+
+CREATE INDEX "reading_rollups_1m_updated_at_idx" ON "reading_rollups_1m" ("updated_at");
+
+CREATE TABLE "reading_rollups_1h" (
+    "device_id" bigint NOT NULL,
+    "bucket" timestamptz NOT NULL,
+    "key" text NOT NULL,
+    "count" bigint NOT NULL,
+    "sum_value" float8 NOT NULL,
+    "min_value" float8 NOT NULL,
+    "max_value" float8 NOT NULL,
+    "last_value" float8 NOT NULL,
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+
+    PRIMARY KEY ("device_id", "bucket", "key"),
+    CONSTRAINT "reading_rollups_1h_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id")
+);
+
+-- How far each rollup tier has caught up with its source, measured on the
+-- source's received_at (raw) or updated_at (minute rollups) so late readings
+-- are still picked up.
+CREATE TABLE "reading_rollup_watermarks" (
+    "tier" varchar(10) PRIMARY KEY,
+    "rolled_up_to" timestamptz NOT NULL
+);
+
+CREATE INDEX "sensor_readings_received_at_idx" ON "sensor_readings" ("received_at");
+
+INSERT INTO "reading_rollup_watermarks" ("tier", "rolled_up_to")
+SELECT '1m', COALESCE(MIN("received_at"), now()) - INTERVAL '1 microsecond' FROM "sensor_readings";
+
+INSERT INTO "reading_rollup_watermarks" ("tier", "rolled_up_to") VALUES ('1h', now());
@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS "estimate_device_readings"(bigint);
+CREATE INDEX IF NOT EXISTS "idx_sensor_device_ts" ON "sensor_readings" ("device_id", "timestamp");
+DROP INDEX IF EXISTS "idx_sensor_device_ts_id";
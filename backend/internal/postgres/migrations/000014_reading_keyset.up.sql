@@ -0,0 +1,15 @@
+-- (device_id, timestamp, id) serves keyset pages ordered by (timestamp, id)
+-- as well as the plain device/time range lookups the old index covered.
+CREATE INDEX "idx_sensor_device_ts_id" ON "sensor_readings" ("device_id", "timestamp", "id");
+DROP INDEX IF EXISTS "idx_sensor_device_ts";
+
+-- Planner estimate of a device's reading count, cheap enough to run on
+-- every page where an exact COUNT(*) would scan the device's history.
+CREATE OR REPLACE FUNCTION "estimate_device_readings"("device" bigint) RETURNS bigint AS $$
+DECLARE
+    plan json;
+BEGIN
+    EXECUTE format('EXPLAIN (FORMAT JSON) SELECT 1 FROM "sensor_readings" WHERE "device_id" = %s', "device") INTO plan;
+    RETURN (plan -> 0 -> 'Plan' ->> 'Plan Rows')::bigint;
+END;
+$$ LANGUAGE plpgsql STABLE;
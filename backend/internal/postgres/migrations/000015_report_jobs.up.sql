@@ -0,0 +1,41 @@
+-- Report jobs build workbooks and PDFs in the background. The finished file
+-- is kept until the job expires and is downloaded with the token, so the link
+-- in the notification email works without a session.
+CREATE TABLE "report_jobs" (
+    "id" bigserial PRIMARY KEY,
+    "kind" text NOT NULL,
+    "params" jsonb NOT NULL DEFAULT '{}'::jsonb,
+    "status" text NOT NULL DEFAULT 'queued',
+    "progress" int NOT NULL DEFAULT 0,
+    "error" text NULL,
+    "attempts" int NOT NULL DEFAULT 0,
+    "created_by" bigint NOT NULL,
+    "notify_email" text NULL,
+    "download_token" text NOT NULL,
+    "file_name" text NULL,
+    "content_type" text NULL,
+    "file_size" bigint NULL,
+    "locked_until" timestamptz NULL,
+    "started_at" timestamptz NULL,
+    "finished_at" timestamptz NULL,
+    "expires_at" timestamptz NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "report_jobs_kind_check" CHECK ("kind" IN ('readings_xlsx', 'experiment_pdf')),
+    CONSTRAINT "report_jobs_status_check" CHECK ("status" IN ('queued', 'running', 'succeeded', 'failed')),
+    CONSTRAINT "report_jobs_progress_check" CHECK ("progress" BETWEEN 0 AND 100),
+    CONSTRAINT "report_jobs_users_created_by_fkey" FOREIGN KEY ("created_by") REFERENCES "users" ("id")
+);
+
+-- the worker only ever looks for unfinished jobs
+CREATE INDEX "idx_report_jobs_pending" ON "report_jobs" ("created_at") WHERE "status" IN ('queued', 'running');
+CREATE INDEX "idx_report_jobs_created_by" ON "report_jobs" ("created_by", "created_at" DESC);
+CREATE INDEX "idx_report_jobs_expires_at" ON "report_jobs" ("expires_at") WHERE "expires_at" IS NOT NULL;
+
+-- file contents live apart from the job so listing jobs never reads them
+CREATE TABLE "report_job_files" (
+    "job_id" bigint PRIMARY KEY,
+    "data" bytea NOT NULL,
+
+    CONSTRAINT "report_job_files_report_jobs_job_id_fkey" FOREIGN KEY ("job_id") REFERENCES "report_jobs" ("id") ON DELETE CASCADE
+);
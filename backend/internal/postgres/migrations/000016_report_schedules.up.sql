@@ -0,0 +1,29 @@
+-- Report schedules email a report on a cron schedule. The scope is a reactor,
+-- a list of devices, or every device when neither is set. Each run covers
+-- window_seconds up to its scheduled time.
+CREATE TABLE "report_schedules" (
+    "id" bigserial PRIMARY KEY,
+    "name" text NOT NULL,
+    "cron_expression" text NOT NULL,
+    "timezone" text NOT NULL DEFAULT 'UTC',
+    "kind" text NOT NULL,
+    "reactor_id" bigint NULL,
+    "device_ids" bigint[] NOT NULL DEFAULT '{}',
+    "window_seconds" bigint NOT NULL,
+    "recipients" text[] NOT NULL,
+    "enabled" boolean NOT NULL DEFAULT true,
+    "next_run_at" timestamptz NOT NULL,
+    "last_run_at" timestamptz NULL,
+    "last_error" text NULL,
+    "created_by" bigint NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "report_schedules_kind_check" CHECK ("kind" IN ('readings_xlsx', 'readings_csv')),
+    CONSTRAINT "report_schedules_scope_check" CHECK ("reactor_id" IS NULL OR cardinality("device_ids") = 0),
+    CONSTRAINT "report_schedules_window_seconds_check" CHECK ("window_seconds" > 0),
+    CONSTRAINT "report_schedules_reactors_reactor_id_fkey" FOREIGN KEY ("reactor_id") REFERENCES "reactors" ("id") ON DELETE CASCADE,
+    CONSTRAINT "report_schedules_users_created_by_fkey" FOREIGN KEY ("created_by") REFERENCES "users" ("id")
+);
+
+CREATE INDEX "idx_report_schedules_next_run_at" ON "report_schedules" ("next_run_at") WHERE "enabled";
@@ -0,0 +1,4 @@
+ALTER TABLE "experiments" DROP COLUMN IF EXISTS "run_stats";
+DROP TRIGGER IF EXISTS "device_reactor_assignment" ON "device";
+DROP FUNCTION IF EXISTS "track_device_reactor_assignment"();
+DROP TABLE IF EXISTS "device_reactor_assignments";
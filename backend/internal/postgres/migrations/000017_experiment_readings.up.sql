@@ -0,0 +1,53 @@
+-- Device reactor assignments record which reactor a device was attached to
+-- and when, so the readings of a past experiment come from the devices that
+-- were on its reactor at the time. A trigger on "device" keeps the history.
+CREATE TABLE "device_reactor_assignments" (
+    "id" bigserial PRIMARY KEY,
+    "device_id" bigint NOT NULL,
+    "reactor_id" bigint NOT NULL,
+    "attached_at" timestamptz NOT NULL DEFAULT (now()),
+    "detached_at" timestamptz NULL,
+
+    CONSTRAINT "device_reactor_assignments_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id") ON DELETE CASCADE,
+    CONSTRAINT "device_reactor_assignments_reactors_reactor_id_fkey" FOREIGN KEY ("reactor_id") REFERENCES "reactors" ("id") ON DELETE CASCADE
+);
+
+-- a device is attached to at most one reactor at a time
+CREATE UNIQUE INDEX "device_reactor_assignments_device_id_open_idx" ON "device_reactor_assignments" ("device_id") WHERE "detached_at" IS NULL;
+CREATE INDEX "device_reactor_assignments_reactor_id_attached_at_idx" ON "device_reactor_assignments" ("reactor_id", "attached_at");
+
+-- Nothing is known about earlier moves, so current assignments are taken to
+-- date from the creation of the device.
+INSERT INTO "device_reactor_assignments" ("device_id", "reactor_id", "attached_at")
+SELECT "id", "reactor_id", "created_at"
+FROM "device"
+WHERE "reactor_id" IS NOT NULL AND "deleted" = false;
+
+CREATE OR REPLACE FUNCTION "track_device_reactor_assignment"() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE'
+     AND NEW."reactor_id" IS NOT DISTINCT FROM OLD."reactor_id"
+     AND NEW."deleted" = OLD."deleted" THEN
+    RETURN NEW;
+  END IF;
+
+  UPDATE "device_reactor_assignments"
+  SET "detached_at" = now()
+  WHERE "device_id" = NEW."id" AND "detached_at" IS NULL;
+
+  IF NEW."reactor_id" IS NOT NULL AND NOT NEW."deleted" THEN
+    INSERT INTO "device_reactor_assignments" ("device_id", "reactor_id")
+    VALUES (NEW."id", NEW."reactor_id");
+  END IF;
+
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "device_reactor_assignment"
+AFTER INSERT OR UPDATE OF "reactor_id", "deleted" ON "device"
+FOR EACH ROW EXECUTE FUNCTION "track_device_reactor_assignment"();
+
+-- Run statistics derived from the readings of an experiment, cleared when
+-- the experiment changes and recomputed on demand.
+ALTER TABLE "experiments" ADD COLUMN "run_stats" jsonb NULL;
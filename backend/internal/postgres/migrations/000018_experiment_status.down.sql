@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "experiments_status_idx";
+DROP INDEX IF EXISTS "experiments_reactor_id_running_idx";
+ALTER TABLE "experiments" DROP CONSTRAINT IF EXISTS "experiments_status_check";
+ALTER TABLE "experiments" DROP COLUMN IF EXISTS "ended_at";
+ALTER TABLE "experiments" DROP COLUMN IF EXISTS "started_at";
+ALTER TABLE "experiments" DROP COLUMN IF EXISTS "status";
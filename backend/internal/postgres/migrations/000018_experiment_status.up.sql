@@ -0,0 +1,19 @@
+-- Experiments move from planned to running to completed or aborted. Start and
+-- stop record the server time in started_at and ended_at; the date and
+-- time_start/time_end columns keep the planned run.
+ALTER TABLE "experiments" ADD COLUMN "status" text NOT NULL DEFAULT 'planned';
+ALTER TABLE "experiments" ADD COLUMN "started_at" timestamptz NULL;
+ALTER TABLE "experiments" ADD COLUMN "ended_at" timestamptz NULL;
+
+ALTER TABLE "experiments" ADD CONSTRAINT "experiments_status_check" CHECK ("status" IN ('planned', 'running', 'completed', 'aborted'));
+
+-- experiments recorded before statuses existed are done once their planned
+-- end has passed
+UPDATE "experiments"
+SET "status" = 'completed'
+WHERE ("date" AT TIME ZONE 'UTC')::date + "time_end" <= (now() AT TIME ZONE 'UTC');
+
+-- readings are attributed to experiments by reactor, so a reactor runs one
+-- experiment at a time
+CREATE UNIQUE INDEX "experiments_reactor_id_running_idx" ON "experiments" ("reactor_id") WHERE "status" = 'running' AND "deleted_at" IS NULL;
+CREATE INDEX "experiments_status_idx" ON "experiments" ("status");
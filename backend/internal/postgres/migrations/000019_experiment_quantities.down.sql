@@ -0,0 +1,23 @@
+-- Quantities go back to "<value> <unit>" text; text kept in
+-- legacy_parameters is restored as it was.
+UPDATE "experiments" e
+SET
+    "material_feedstock" = e."material_feedstock" || COALESCE((
+        SELECT jsonb_object_agg(f."field", COALESCE(
+            e."legacy_parameters" ->> f."field",
+            btrim((e."material_feedstock" -> f."field" ->> 'value') || ' ' || (e."material_feedstock" -> f."field" ->> 'unit')),
+            ''
+        ))
+        FROM unnest(ARRAY['cement', 'fineAggregate', 'coarseAggregate', 'water', 'waterCementRatio', 'blockSizeLength', 'blockSizeWidth', 'blockSizeHeight']) AS f("field")
+    ), '{}'),
+    "exposure_conditions" = e."exposure_conditions" || COALESCE((
+        SELECT jsonb_object_agg(f."field", COALESCE(
+            e."legacy_parameters" ->> f."field",
+            btrim((e."exposure_conditions" -> f."field" ->> 'value') || ' ' || (e."exposure_conditions" -> f."field" ->> 'unit')),
+            ''
+        ))
+        FROM unnest(ARRAY['co2Mass', 'injectionPressure', 'headSpace', 'reactionTime']) AS f("field")
+    ), '{}');
+
+ALTER TABLE "experiments" DROP COLUMN IF EXISTS "legacy_parameters";
+ALTER TABLE "experiments" DROP COLUMN IF EXISTS "parameter_values";
@@ -0,0 +1,106 @@
+-- Numeric material feedstock and exposure condition fields become quantities,
+-- {"value": 5, "unit": "kg"}, instead of free text. parameter_values holds
+-- every recorded quantity in the base unit of its dimension for range
+-- filters. Text that cannot be read as a quantity is kept in
+-- legacy_parameters and the field is left null.
+ALTER TABLE "experiments" ADD COLUMN "parameter_values" jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE "experiments" ADD COLUMN "legacy_parameters" jsonb NOT NULL DEFAULT '{}';
+
+-- units and their factor to the base unit, a copy of repository.units as it
+-- was when this migration was written. Go is the source of truth; this copy
+-- only converts the text recorded before quantities existed.
+CREATE TEMPORARY TABLE "quantity_units" ("unit" text, "dimension" text, "factor" float8);
+INSERT INTO "quantity_units" VALUES
+    ('g', 'mass', 0.001), ('kg', 'mass', 1), ('t', 'mass', 1000), ('lb', 'mass', 0.45359237),
+    ('mm', 'length', 1), ('cm', 'length', 10), ('m', 'length', 1000), ('in', 'length', 25.4),
+    ('bar', 'pressure', 1), ('mbar', 'pressure', 0.001), ('Pa', 'pressure', 0.00001),
+    ('kPa', 'pressure', 0.01), ('MPa', 'pressure', 10), ('psi', 'pressure', 0.0689475729),
+    ('atm', 'pressure', 1.01325),
+    ('mL', 'volume', 0.001), ('L', 'volume', 1), ('cm3', 'volume', 0.001), ('m3', 'volume', 1000),
+    ('s', 'duration', 1.0 / 60), ('min', 'duration', 1), ('h', 'duration', 60), ('d', 'duration', 1440),
+    ('', 'ratio', 1), ('%', 'ratio', 0.01);
+
+-- numeric fields, a copy of repository.ExperimentParameters as it was when
+-- this migration was written
+CREATE TEMPORARY TABLE "quantity_fields" ("column_name" text, "field" text, "dimension" text, "default_unit" text);
+INSERT INTO "quantity_fields" VALUES
+    ('material_feedstock', 'cement', 'mass', 'kg'),
+    ('material_feedstock', 'fineAggregate', 'mass', 'kg'),
+    ('material_feedstock', 'coarseAggregate', 'mass', 'kg'),
+    ('material_feedstock', 'water', 'mass', 'kg'),
+    ('material_feedstock', 'waterCementRatio', 'ratio', ''),
+    ('material_feedstock', 'blockSizeLength', 'length', 'mm'),
+    ('material_feedstock', 'blockSizeWidth', 'length', 'mm'),
+    ('material_feedstock', 'blockSizeHeight', 'length', 'mm'),
+    ('exposure_conditions', 'co2Mass', 'mass', 'kg'),
+    ('exposure_conditions', 'injectionPressure', 'pressure', 'bar'),
+    ('exposure_conditions', 'headSpace', 'volume', 'L'),
+    ('exposure_conditions', 'reactionTime', 'duration', 'min');
+
+-- quantity_value reads a number like strconv.ParseFloat in
+-- repository.ParseQuantity: text such as 1e999 that does not fit a float8 is
+-- not a quantity. A plain cast would abort the migration instead.
+CREATE FUNCTION pg_temp.quantity_value(text) RETURNS float8 AS $$
+BEGIN
+    RETURN $1::float8;
+EXCEPTION WHEN numeric_value_out_of_range OR invalid_text_representation THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE STRICT;
+
+-- values past 1e300 are not parsed either, so the conversion to the base
+-- unit cannot overflow
+CREATE TEMPORARY TABLE "parsed_quantities" AS
+SELECT
+    e."id",
+    f."column_name",
+    f."field",
+    raw."text" AS "raw",
+    u."unit",
+    v."value",
+    u."factor",
+    (v."value" IS NOT NULL AND u."unit" IS NOT NULL AND v."value" >= 0 AND v."value" <= 1e300) AS "parsed"
+FROM "experiments" e
+CROSS JOIN "quantity_fields" f
+CROSS JOIN LATERAL (
+    SELECT (CASE f."column_name" WHEN 'material_feedstock' THEN e."material_feedstock" ELSE e."exposure_conditions" END) ->> f."field" AS "text"
+) raw
+CROSS JOIN LATERAL (
+    SELECT regexp_match(raw."text", '^\s*([-+]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][-+]?[0-9]+)?)\s*(\S*)\s*$') AS "match"
+) p
+CROSS JOIN LATERAL (
+    SELECT pg_temp.quantity_value(p."match"[1]) AS "value", COALESCE(NULLIF(p."match"[2], ''), f."default_unit") AS "unit"
+) v
+-- units are matched by case, as mPa is not MPa, after the aliases of
+-- repository.unitAliases
+LEFT JOIN "quantity_units" u
+    ON u."dimension" = f."dimension"
+    AND u."unit" = (CASE v."unit" WHEN 'l' THEN 'L' WHEN 'ml' THEN 'mL' ELSE v."unit" END);
+
+UPDATE "experiments" e
+SET
+    "material_feedstock" = e."material_feedstock" || COALESCE((
+        SELECT jsonb_object_agg(q."field", CASE WHEN q."parsed" THEN jsonb_build_object('value', q."value", 'unit', q."unit") ELSE 'null'::jsonb END)
+        FROM "parsed_quantities" q
+        WHERE q."id" = e."id" AND q."column_name" = 'material_feedstock'
+    ), '{}'),
+    "exposure_conditions" = e."exposure_conditions" || COALESCE((
+        SELECT jsonb_object_agg(q."field", CASE WHEN q."parsed" THEN jsonb_build_object('value', q."value", 'unit', q."unit") ELSE 'null'::jsonb END)
+        FROM "parsed_quantities" q
+        WHERE q."id" = e."id" AND q."column_name" = 'exposure_conditions'
+    ), '{}'),
+    "parameter_values" = COALESCE((
+        SELECT jsonb_object_agg(q."field", q."value" * q."factor")
+        FROM "parsed_quantities" q
+        WHERE q."id" = e."id" AND q."parsed"
+    ), '{}'),
+    "legacy_parameters" = COALESCE((
+        SELECT jsonb_object_agg(q."field", q."raw")
+        FROM "parsed_quantities" q
+        WHERE q."id" = e."id" AND NOT q."parsed" AND btrim(COALESCE(q."raw", '')) <> ''
+    ), '{}');
+
+DROP TABLE "parsed_quantities";
+DROP TABLE "quantity_fields";
+DROP TABLE "quantity_units";
+DROP FUNCTION pg_temp.quantity_value(text);
@@ -0,0 +1,4 @@
+ALTER TABLE "reactors" DROP CONSTRAINT IF EXISTS "reactors_files_pdf_file_id_fkey";
+ALTER TABLE "reactors" DROP COLUMN IF EXISTS "pdf_file_id";
+
+DROP TABLE IF EXISTS "files";
@@ -0,0 +1,21 @@
+-- Uploaded files. The content lives in the configured storage backend under
+-- storage_key; the row keeps what was checked when it was uploaded.
+CREATE TABLE "files" (
+    "id" bigserial PRIMARY KEY,
+    "storage_key" text NOT NULL,
+    "file_name" text NOT NULL,
+    "content_type" text NOT NULL,
+    "size" bigint NOT NULL,
+    "checksum" text NOT NULL,
+    "created_by" bigint NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "files_storage_key_key" UNIQUE ("storage_key"),
+    CONSTRAINT "files_size_check" CHECK ("size" >= 0),
+    CONSTRAINT "files_users_created_by_fkey" FOREIGN KEY ("created_by") REFERENCES "users" ("id")
+);
+
+-- reactor documents reference an uploaded file; pdf_url is kept read-only for
+-- documents linked before files were stored here
+ALTER TABLE "reactors" ADD COLUMN "pdf_file_id" bigint NULL;
+ALTER TABLE "reactors" ADD CONSTRAINT "reactors_files_pdf_file_id_fkey" FOREIGN KEY ("pdf_file_id") REFERENCES "files" ("id") ON DELETE SET NULL;
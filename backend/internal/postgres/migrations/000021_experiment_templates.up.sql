@@ -0,0 +1,19 @@
+-- Experiment templates hold the feedstock and exposure conditions of a mix
+-- design so new experiments can be pre-filled from them. A template saved
+-- from an experiment remembers it in source_experiment_id.
+CREATE TABLE "experiment_templates" (
+    "id" bigserial PRIMARY KEY,
+    "name" varchar(100) NOT NULL,
+    "description" text NOT NULL DEFAULT '',
+    "material_feedstock" jsonb NOT NULL,
+    "exposure_conditions" jsonb NOT NULL,
+    "source_experiment_id" bigint NULL,
+    "created_by" bigint NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "experiment_templates_experiments_source_experiment_id_fkey" FOREIGN KEY ("source_experiment_id") REFERENCES "experiments" ("id") ON DELETE SET NULL,
+    CONSTRAINT "experiment_templates_users_created_by_fkey" FOREIGN KEY ("created_by") REFERENCES "users" ("id")
+);
+
+CREATE UNIQUE INDEX "experiment_templates_name_key" ON "experiment_templates" (lower("name"));
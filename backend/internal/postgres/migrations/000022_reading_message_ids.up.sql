@@ -0,0 +1,21 @@
+-- The unique index on sensor_readings has to include the "timestamp"
+-- partition key, so it does not stop two retries of a message with different
+-- timestamps from both being stored. Message ids are claimed here first, on
+-- a table that is not partitioned and can enforce them on their own. An id is
+-- kept until the raw readings of its device are past retention.
+CREATE TABLE "reading_message_ids" (
+    "device_id" bigint NOT NULL,
+    "message_id" varchar(100) NOT NULL,
+    "received_at" timestamptz NOT NULL DEFAULT (now()),
+
+    CONSTRAINT "reading_message_ids_pkey" PRIMARY KEY ("device_id", "message_id"),
+    CONSTRAINT "reading_message_ids_device_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "device" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "reading_message_ids_received_at_idx" ON "reading_message_ids" ("received_at");
+
+INSERT INTO "reading_message_ids" ("device_id", "message_id", "received_at")
+SELECT "device_id", "message_id", MIN("received_at")
+FROM "sensor_readings"
+WHERE "message_id" IS NOT NULL
+GROUP BY "device_id", "message_id";
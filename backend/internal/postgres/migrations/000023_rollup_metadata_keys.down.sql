@@ -0,0 +1 @@
+-- Rollup rows of metadata keys are not restored.
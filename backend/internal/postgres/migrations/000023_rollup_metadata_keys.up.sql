@@ -0,0 +1,4 @@
+-- Payload metadata such as a numeric device "timestamp" is not a measurement.
+-- Rollups no longer include it; drop what earlier rollups stored.
+DELETE FROM "reading_rollups_1m" WHERE "key" IN ('timestamp', 'ServerTS');
+DELETE FROM "reading_rollups_1h" WHERE "key" IN ('timestamp', 'ServerTS');
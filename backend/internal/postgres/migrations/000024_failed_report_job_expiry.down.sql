@@ -0,0 +1 @@
+-- The expiry of failed report jobs is kept.
@@ -0,0 +1,6 @@
+-- Failed report jobs now expire like succeeded ones. Give the jobs that
+-- failed before this the default REPORT_JOB_TTL of seven days from when they
+-- finished, so DeleteExpiredReportJobs removes them.
+UPDATE "report_jobs"
+SET "expires_at" = COALESCE("finished_at", "created_at") + interval '7 days'
+WHERE "status" = 'failed' AND "expires_at" IS NULL;
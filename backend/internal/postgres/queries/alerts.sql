@@ -0,0 +1,88 @@
+-- name: CreateAlertRule :one
+INSERT INTO alert_rules (device_id, name, key, comparator, threshold, duration_seconds, severity, notify_emails, enabled)
+VALUES (
+    sqlc.arg('device_id'), sqlc.arg('name'), sqlc.arg('key'), sqlc.arg('comparator'), sqlc.arg('threshold'),
+    sqlc.arg('duration_seconds'), sqlc.arg('severity'), sqlc.arg('notify_emails'), sqlc.arg('enabled')
+)
+RETURNING *;
+
+-- name: GetAlertRuleByID :one
+SELECT * FROM alert_rules
+WHERE id = $1;
+
+-- name: ListAlertRules :many
+SELECT * FROM alert_rules
+WHERE sqlc.narg('device_id')::bigint IS NULL OR device_id = sqlc.narg('device_id')
+ORDER BY id ASC;
+
+-- name: LockEnabledAlertRulesByDevice :many
+-- Locks the enabled rules of a device for the rest of the transaction, so
+-- readings of the device are evaluated one at a time across replicas.
+SELECT * FROM alert_rules
+WHERE device_id = $1 AND enabled = true
+ORDER BY id ASC
+FOR UPDATE;
+
+-- name: UpdateAlertRule :one
+UPDATE alert_rules
+SET name = sqlc.arg('name'),
+    key = sqlc.arg('key'),
+    comparator = sqlc.arg('comparator'),
+    threshold = sqlc.arg('threshold'),
+    duration_seconds = sqlc.arg('duration_seconds'),
+    severity = sqlc.arg('severity'),
+    notify_emails = sqlc.arg('notify_emails'),
+    enabled = sqlc.arg('enabled'),
+    breach_started_at = NULL
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: SetAlertRuleBreachStartedAt :exec
+UPDATE alert_rules
+SET breach_started_at = sqlc.narg('breach_started_at')
+WHERE id = sqlc.arg('id');
+
+-- name: DeleteAlertRule :execrows
+DELETE FROM alert_rules
+WHERE id = $1;
+
+-- name: CreateAlertIncident :one
+INSERT INTO alert_incidents (rule_id, device_id, severity, trigger_value, opened_at)
+VALUES (sqlc.arg('rule_id'), sqlc.arg('device_id'), sqlc.arg('severity'), sqlc.arg('trigger_value'), sqlc.arg('opened_at'))
+RETURNING *;
+
+-- name: GetAlertIncidentByID :one
+SELECT * FROM alert_incidents
+WHERE id = $1;
+
+-- name: GetActiveAlertIncidentByRule :one
+SELECT * FROM alert_incidents
+WHERE rule_id = $1 AND status <> 'resolved';
+
+-- name: ListAlertIncidents :many
+SELECT * FROM alert_incidents
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('device_id')::bigint IS NULL OR device_id = sqlc.narg('device_id'))
+ORDER BY opened_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountListAlertIncidents :one
+SELECT COUNT(*) AS total_incidents
+FROM alert_incidents
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('device_id')::bigint IS NULL OR device_id = sqlc.narg('device_id'));
+
+-- name: AcknowledgeAlertIncident :one
+UPDATE alert_incidents
+SET status = 'acknowledged',
+    acknowledged_at = now(),
+    acknowledged_by = sqlc.arg('acknowledged_by')
+WHERE id = sqlc.arg('id') AND status = 'open'
+RETURNING *;
+
+-- name: ResolveAlertIncident :one
+UPDATE alert_incidents
+SET status = 'resolved',
+    resolved_at = sqlc.arg('resolved_at')
+WHERE id = sqlc.arg('id') AND status <> 'resolved'
+RETURNING *;
@@ -4,21 +4,28 @@ FROM device
 WHERE deleted = false
 ORDER BY created_at DESC;
 
+-- name: ListDevicesByReactor :many
+SELECT *
+FROM device
+WHERE reactor_id = $1 AND deleted = false
+ORDER BY id ASC;
+
 -- name: GetDevice :one
 SELECT *
 FROM device
 WHERE id = $1 AND deleted = false;
 
 -- name: CreateDevice :one
-INSERT INTO device (reactor_id, name, status)
-VALUES (sqlc.narg('reactor_id'), sqlc.arg('name'), sqlc.arg('status'))
+INSERT INTO device (reactor_id, name, status, expected_interval_seconds)
+VALUES (sqlc.narg('reactor_id'), sqlc.arg('name'), sqlc.arg('status'), sqlc.arg('expected_interval_seconds'))
 RETURNING *;
 
 -- name: UpdateDevice :one
 UPDATE device
 SET reactor_id   = COALESCE(sqlc.narg('reactor_id'), reactor_id),
     name   = COALESCE(sqlc.narg('name'), name),
-    status = COALESCE(sqlc.narg('status'), status)
+    status = COALESCE(sqlc.narg('status'), status),
+    expected_interval_seconds = COALESCE(sqlc.narg('expected_interval_seconds'), expected_interval_seconds)
 WHERE id = sqlc.arg('id') AND deleted = false
 RETURNING *;
 
@@ -32,11 +39,50 @@ SELECT
     (SELECT COUNT(*) FROM device) AS total_devices,
     (SELECT COUNT(*) FROM device WHERE status = TRUE) AS active_devices,
     (SELECT COUNT(*) FROM device WHERE status = FALSE) AS inactive_devices,
-    (SELECT COUNT(*) FROM sensor_readings) AS total_sensor_readings;
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'online' AND deleted = false) AS online_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'stale' AND deleted = false) AS stale_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'offline' AND deleted = false) AS offline_devices,
+    -- planner estimate summed over the partitions; an exact COUNT(*) scans every reading
+    (SELECT COALESCE(SUM(GREATEST(c.reltuples, 0)), 0)::bigint
+     FROM pg_inherits i
+     JOIN pg_class c ON c.oid = i.inhrelid
+     WHERE i.inhparent = 'sensor_readings'::regclass) AS total_sensor_readings;
 
 -- name: CountTotalActiveInactiveDevices :one
 SELECT
     (SELECT COUNT(*) FROM device WHERE deleted = false) AS total_devices,
     (SELECT COUNT(*) FROM device WHERE status = TRUE AND deleted = false) AS active_devices,
-    (SELECT COUNT(*) FROM device WHERE status = FALSE AND deleted = false) AS inactive_devices;
-    
\ No newline at end of file
+    (SELECT COUNT(*) FROM device WHERE status = FALSE AND deleted = false) AS inactive_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'online' AND deleted = false) AS online_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'stale' AND deleted = false) AS stale_devices,
+    (SELECT COUNT(*) FROM device WHERE connectivity = 'offline' AND deleted = false) AS offline_devices;
+
+-- name: TouchDeviceLastSeen :exec
+-- Online devices are only touched once per half of their expected interval,
+-- well within the stale threshold, so readings do not all lock and rewrite
+-- the device row.
+UPDATE device
+SET last_seen_at = GREATEST(last_seen_at, sqlc.arg('last_seen_at')::timestamptz),
+    connectivity = 'online'
+WHERE id = sqlc.arg('id')
+    AND (
+        connectivity <> 'online'
+        OR last_seen_at IS NULL
+        OR last_seen_at < sqlc.arg('last_seen_at')::timestamptz - make_interval(secs => expected_interval_seconds / 2.0)
+    );
+
+-- name: UpdateDevicesConnectivity :many
+UPDATE device AS d
+SET connectivity = c.connectivity
+FROM (
+    SELECT id,
+        CASE
+            WHEN last_seen_at > now() - make_interval(secs => expected_interval_seconds * sqlc.arg('stale_after')::float8) THEN 'online'
+            WHEN last_seen_at > now() - make_interval(secs => expected_interval_seconds * sqlc.arg('offline_after')::float8) THEN 'stale'
+            ELSE 'offline'
+        END AS connectivity
+    FROM device
+    WHERE deleted = false
+) AS c
+WHERE d.id = c.id AND d.connectivity <> c.connectivity
+RETURNING d.id, d.connectivity;
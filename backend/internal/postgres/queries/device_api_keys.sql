@@ -0,0 +1,29 @@
+-- name: CreateDeviceAPIKey :one
+INSERT INTO device_api_keys (device_id, name, prefix, key_hash)
+VALUES (sqlc.arg('device_id'), sqlc.arg('name'), sqlc.arg('prefix'), sqlc.arg('key_hash'))
+RETURNING *;
+
+-- name: ListDeviceAPIKeys :many
+SELECT *
+FROM device_api_keys
+WHERE device_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetActiveDeviceAPIKeyByHash :one
+SELECT device_api_keys.*
+FROM device_api_keys
+JOIN device ON device.id = device_api_keys.device_id
+WHERE device_api_keys.key_hash = $1
+  AND device_api_keys.revoked_at IS NULL
+  AND device.deleted = false;
+
+-- name: RevokeDeviceAPIKey :one
+UPDATE device_api_keys
+SET revoked_at = now()
+WHERE id = sqlc.arg('id') AND device_id = sqlc.arg('device_id') AND revoked_at IS NULL
+RETURNING *;
+
+-- name: TouchDeviceAPIKey :exec
+UPDATE device_api_keys
+SET last_used_at = now()
+WHERE id = $1;
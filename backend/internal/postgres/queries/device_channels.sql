@@ -0,0 +1,29 @@
+-- name: CreateDeviceChannel :one
+INSERT INTO device_channels (device_id, name, unit, data_type, min_value, max_value, required, position)
+VALUES (
+    sqlc.arg('device_id'), sqlc.arg('name'), sqlc.arg('unit'), sqlc.arg('data_type'),
+    sqlc.narg('min_value'), sqlc.narg('max_value'), sqlc.arg('required'), sqlc.arg('position')
+)
+RETURNING *;
+
+-- name: ListDeviceChannels :many
+SELECT *
+FROM device_channels
+WHERE device_id = $1
+ORDER BY position ASC, id ASC;
+
+-- name: UpdateDeviceChannel :one
+UPDATE device_channels
+SET name = sqlc.arg('name'),
+    unit = sqlc.arg('unit'),
+    data_type = sqlc.arg('data_type'),
+    min_value = sqlc.narg('min_value'),
+    max_value = sqlc.narg('max_value'),
+    required = sqlc.arg('required'),
+    position = sqlc.arg('position')
+WHERE id = sqlc.arg('id') AND device_id = sqlc.arg('device_id')
+RETURNING *;
+
+-- name: DeleteDeviceChannel :execrows
+DELETE FROM device_channels
+WHERE id = sqlc.arg('id') AND device_id = sqlc.arg('device_id');
@@ -0,0 +1,32 @@
+-- name: CreateExperimentTemplate :one
+INSERT INTO experiment_templates (
+    name, description, material_feedstock, exposure_conditions, source_experiment_id, created_by
+)
+VALUES (
+    sqlc.arg('name'), sqlc.arg('description'), sqlc.arg('material_feedstock'), sqlc.arg('exposure_conditions'),
+    sqlc.narg('source_experiment_id'), sqlc.arg('created_by')
+)
+RETURNING *;
+
+-- name: GetExperimentTemplateByID :one
+SELECT * FROM experiment_templates
+WHERE id = $1;
+
+-- name: ListExperimentTemplates :many
+SELECT * FROM experiment_templates
+WHERE COALESCE(sqlc.narg('search'), '') = '' OR LOWER(name) LIKE sqlc.narg('search')
+ORDER BY LOWER(name) ASC;
+
+-- name: UpdateExperimentTemplate :one
+UPDATE experiment_templates
+SET name = sqlc.arg('name'),
+    description = sqlc.arg('description'),
+    material_feedstock = sqlc.arg('material_feedstock'),
+    exposure_conditions = sqlc.arg('exposure_conditions'),
+    updated_at = now()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: DeleteExperimentTemplate :execrows
+DELETE FROM experiment_templates
+WHERE id = $1;
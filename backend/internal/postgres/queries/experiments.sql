@@ -1,12 +1,13 @@
 -- name: CreateExperiment :one
 INSERT INTO experiments (
     batch_id, operator, date, reactor_id, block_id, time_start, time_end,
-    material_feedstock, exposure_conditions, analtical_tests
+    material_feedstock, exposure_conditions, analtical_tests, parameter_values
 )
 VALUES (
     sqlc.arg('batch_id'), sqlc.arg('operator'), sqlc.arg('date'), sqlc.arg('reactor_id'), sqlc.arg('block_id'),
     sqlc.arg('time_start'), sqlc.arg('time_end'),
-    sqlc.arg('material_feedstock'), sqlc.arg('exposure_conditions'), sqlc.arg('analtical_tests')
+    sqlc.arg('material_feedstock'), sqlc.arg('exposure_conditions'), sqlc.arg('analtical_tests'),
+    sqlc.arg('parameter_values')
 )
 RETURNING *;
 
@@ -25,7 +26,10 @@ SET
     time_end = sqlc.arg('time_end'),
     material_feedstock = sqlc.arg('material_feedstock'),
     exposure_conditions = sqlc.arg('exposure_conditions'),
-    analtical_tests = sqlc.arg('analtical_tests')
+    analtical_tests = sqlc.arg('analtical_tests'),
+    parameter_values = sqlc.arg('parameter_values'),
+    legacy_parameters = legacy_parameters - sqlc.arg('recorded_parameters')::text[],
+    run_stats = NULL
 WHERE id = sqlc.arg('id') AND deleted_at IS NULL
 RETURNING *;
 
@@ -46,6 +50,33 @@ WHERE deleted_at IS NULL
         sqlc.narg('date')::timestamptz IS NULL 
         OR date::date = sqlc.narg('date')
     )
+    AND (
+        sqlc.narg('status')::text IS NULL
+        OR status = sqlc.narg('status')
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM unnest(
+            sqlc.arg('filter_parameters')::text[],
+            sqlc.arg('filter_operators')::text[],
+            sqlc.arg('filter_values')::float8[]
+        ) AS f(parameter, operator, value)
+        -- values converted between units are rarely exactly equal, so they
+        -- are compared with the tolerance of repository.sameBaseValue
+        CROSS JOIN LATERAL (
+            SELECT (parameter_values ->> f.parameter)::float8 AS stored
+        ) p
+        CROSS JOIN LATERAL (
+            SELECT 1e-9 * greatest(abs(p.stored), abs(f.value)) AS tolerance
+        ) t
+        WHERE NOT COALESCE(CASE f.operator
+            WHEN '>=' THEN p.stored >= f.value - t.tolerance
+            WHEN '<=' THEN p.stored <= f.value + t.tolerance
+            WHEN '>' THEN p.stored > f.value + t.tolerance
+            WHEN '<' THEN p.stored < f.value - t.tolerance
+            WHEN '=' THEN abs(p.stored - f.value) <= t.tolerance
+        END, false)
+    )
 ORDER BY created_at DESC
 LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
@@ -66,6 +97,33 @@ WHERE deleted_at IS NULL
     AND (
         sqlc.narg('date')::timestamptz IS NULL 
         OR date::date = sqlc.narg('date')
+    )
+    AND (
+        sqlc.narg('status')::text IS NULL
+        OR status = sqlc.narg('status')
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM unnest(
+            sqlc.arg('filter_parameters')::text[],
+            sqlc.arg('filter_operators')::text[],
+            sqlc.arg('filter_values')::float8[]
+        ) AS f(parameter, operator, value)
+        -- values converted between units are rarely exactly equal, so they
+        -- are compared with the tolerance of repository.sameBaseValue
+        CROSS JOIN LATERAL (
+            SELECT (parameter_values ->> f.parameter)::float8 AS stored
+        ) p
+        CROSS JOIN LATERAL (
+            SELECT 1e-9 * greatest(abs(p.stored), abs(f.value)) AS tolerance
+        ) t
+        WHERE NOT COALESCE(CASE f.operator
+            WHEN '>=' THEN p.stored >= f.value - t.tolerance
+            WHEN '<=' THEN p.stored <= f.value + t.tolerance
+            WHEN '>' THEN p.stored > f.value + t.tolerance
+            WHEN '<' THEN p.stored < f.value - t.tolerance
+            WHEN '=' THEN abs(p.stored - f.value) <= t.tolerance
+        END, false)
     );
 
 -- name: DeleteExperiment :exec
@@ -73,6 +131,11 @@ UPDATE experiments
 SET deleted_at = now()
 WHERE id = sqlc.arg('id') AND deleted_at IS NULL;
 
+-- name: CountRunningExperiments :one
+SELECT COUNT(*) AS running_experiments
+FROM experiments
+WHERE status = 'running' AND deleted_at IS NULL;
+
 -- name: CountExperimentsRunToday :one
 SELECT COUNT(*) AS experiments_done_today
 FROM experiments
@@ -87,3 +150,35 @@ WHERE date::date >= date_trunc('week', CURRENT_DATE) AND deleted_at IS NULL;
 SELECT AVG(EXTRACT(EPOCH FROM (time_end - time_start))) AS average_experiment_duration
 FROM experiments
 WHERE deleted_at IS NULL;
+
+-- name: StartExperiment :one
+UPDATE experiments
+SET status = 'running', started_at = now(), run_stats = NULL
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL AND status = 'planned'
+RETURNING *;
+
+-- name: StopExperiment :one
+UPDATE experiments
+SET status = sqlc.arg('status'), ended_at = now(), run_stats = NULL
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL AND status = 'running'
+RETURNING *;
+
+-- name: SetExperimentRunStats :exec
+UPDATE experiments
+SET run_stats = sqlc.arg('run_stats')
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL;
+
+-- name: ListReactorDeviceAssignments :many
+-- ListReactorDeviceAssignments returns the devices attached to a reactor at
+-- some point of a time range, with each attachment clipped to the range.
+SELECT
+    a.device_id,
+    d.name AS device_name,
+    GREATEST(a.attached_at, sqlc.arg('start_time')::timestamptz)::timestamptz AS window_start,
+    LEAST(COALESCE(a.detached_at, sqlc.arg('end_time')::timestamptz), sqlc.arg('end_time')::timestamptz)::timestamptz AS window_end
+FROM device_reactor_assignments a
+JOIN device d ON d.id = a.device_id
+WHERE a.reactor_id = sqlc.arg('reactor_id')
+    AND a.attached_at < sqlc.arg('end_time')::timestamptz
+    AND (a.detached_at IS NULL OR a.detached_at > sqlc.arg('start_time')::timestamptz)
+ORDER BY a.device_id, a.attached_at;
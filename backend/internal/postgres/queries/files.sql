@@ -0,0 +1,8 @@
+-- name: CreateFile :one
+INSERT INTO files (storage_key, file_name, content_type, size, checksum, created_by)
+VALUES (sqlc.arg('storage_key'), sqlc.arg('file_name'), sqlc.arg('content_type'), sqlc.arg('size'), sqlc.arg('checksum'), sqlc.arg('created_by'))
+RETURNING *;
+
+-- name: GetFileByID :one
+SELECT * FROM files
+WHERE id = $1;
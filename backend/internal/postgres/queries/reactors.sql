@@ -1,6 +1,6 @@
 -- name: CreateReactor :one
-INSERT INTO reactors (name, status, pathway, pdf_url)
-VALUES (sqlc.arg('name'), sqlc.arg('status'), sqlc.narg('pathway'), sqlc.narg('pdf_url'))
+INSERT INTO reactors (name, status, pathway, pdf_file_id)
+VALUES (sqlc.arg('name'), sqlc.arg('status'), sqlc.narg('pathway'), sqlc.narg('pdf_file_id'))
 RETURNING *;
 
 -- name: GetReactorByID :one
@@ -8,11 +8,13 @@ SELECT * FROM reactors
 WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: UpdateReactor :exec
+-- A pdf_file_id of 0 detaches the document, including a legacy pdf_url.
 UPDATE reactors
 SET name = coalesce(sqlc.narg('name'), name),
     status = coalesce(sqlc.narg('status'), status),
     pathway = coalesce(sqlc.narg('pathway'), pathway),
-    pdf_url = coalesce(sqlc.narg('pdf_url'), pdf_url)
+    pdf_file_id = CASE WHEN sqlc.narg('pdf_file_id')::bigint = 0 THEN NULL ELSE coalesce(sqlc.narg('pdf_file_id'), pdf_file_id) END,
+    pdf_url = CASE WHEN sqlc.narg('pdf_file_id')::bigint = 0 THEN NULL ELSE pdf_url END
 WHERE id = sqlc.arg('id') AND deleted_at IS NULL;
 
 -- name: ListReactors :many
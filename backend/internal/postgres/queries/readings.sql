@@ -1,8 +1,29 @@
 -- name: InsertReading :one
-INSERT INTO sensor_readings (device_id, payload)
-VALUES ($1, $2)
+INSERT INTO sensor_readings (device_id, payload, timestamp, received_at, message_id)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
+-- name: ClaimReadingMessageID :execrows
+-- Claims a message id for a reading about to be stored. No row is affected
+-- when it was claimed before; a concurrent claim waits for the transaction
+-- that made it.
+INSERT INTO reading_message_ids (device_id, message_id, received_at)
+VALUES (sqlc.arg('device_id'), sqlc.arg('message_id'), sqlc.arg('received_at'))
+ON CONFLICT (device_id, message_id) DO NOTHING;
+
+-- name: ClaimReadingMessageIDs :many
+-- Claims the message ids of a batch, returning those that were not claimed
+-- before.
+INSERT INTO reading_message_ids (device_id, message_id, received_at)
+SELECT sqlc.arg('device_id'), unnest(sqlc.arg('message_ids')::text[]), sqlc.arg('received_at')
+ON CONFLICT (device_id, message_id) DO NOTHING
+RETURNING message_id;
+
+-- name: GetReadingByMessageID :one
+SELECT *
+FROM sensor_readings
+WHERE device_id = $1 AND message_id = $2;
+
 -- name: GetReadingByID :one
 SELECT *
 FROM sensor_readings
@@ -12,7 +33,7 @@ WHERE id = $1;
 SELECT *
 FROM sensor_readings
 WHERE device_id = $1
-ORDER BY timestamp DESC
+ORDER BY timestamp DESC, id DESC
 LIMIT $2 OFFSET $3;
 
 -- name: CountDeviceReadings :one
@@ -20,6 +41,9 @@ SELECT COUNT(*) AS count
 FROM sensor_readings
 WHERE device_id = $1;
 
+-- name: EstimateDeviceReadings :one
+SELECT estimate_device_readings(sqlc.arg('device_id')::bigint)::bigint AS estimate;
+
 -- name: GetReadingsByDate :many
 SELECT *
 FROM sensor_readings
@@ -29,17 +53,56 @@ WHERE device_id = $1
 ORDER BY timestamp DESC;
 
 -- name: GetReadingsByTimeRange :many
+-- A copy of this query is streamed row by row for exports, see
+-- streamReadingsByTimeRange in postgres/export.go.
 SELECT *
 FROM sensor_readings
 WHERE device_id = $1
   AND timestamp >= $2
-  AND timestamp <= $3
-ORDER BY timestamp ASC;
+  AND timestamp < $3
+ORDER BY timestamp ASC, id ASC;
 
 -- name: GetDeviceReadingsPaged :many
+-- Readings older than the (timestamp, id) cursor, newest first.
 SELECT *
 FROM sensor_readings
-WHERE device_id = $1
-  AND timestamp < $2
-ORDER BY timestamp DESC
-LIMIT $3 OFFSET $4;
\ No newline at end of file
+WHERE device_id = sqlc.arg('device_id')
+  AND (timestamp, id) < (sqlc.arg('cursor_timestamp')::timestamptz, sqlc.arg('cursor_id')::bigint)
+ORDER BY timestamp DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetDeviceReadingsPagedAfter :many
+-- Readings newer than the (timestamp, id) cursor, oldest first.
+SELECT *
+FROM sensor_readings
+WHERE device_id = sqlc.arg('device_id')
+  AND (timestamp, id) > (sqlc.arg('cursor_timestamp')::timestamptz, sqlc.arg('cursor_id')::bigint)
+ORDER BY timestamp ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: InsertReadings :copyfrom
+INSERT INTO sensor_readings (device_id, payload, timestamp, received_at, message_id)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: AggregateReadings :many
+SELECT
+    to_timestamp(floor(extract(epoch FROM r.timestamp) / sqlc.arg('bucket_seconds')::bigint) * sqlc.arg('bucket_seconds')::bigint)::timestamptz AS bucket,
+    p.key::text AS key,
+    COUNT(*) AS count,
+    AVG(p.value::float8)::float8 AS avg_value,
+    MIN(p.value::float8)::float8 AS min_value,
+    MAX(p.value::float8)::float8 AS max_value,
+    (array_agg(p.value::float8 ORDER BY r.timestamp DESC))[1]::float8 AS last_value
+FROM sensor_readings r
+CROSS JOIN LATERAL jsonb_each(r.payload) AS p
+WHERE r.device_id = sqlc.arg('device_id')
+  AND r.timestamp >= sqlc.arg('start_time')
+  AND r.timestamp < sqlc.arg('end_time')
+  AND jsonb_typeof(p.value) = 'number'
+  AND p.key NOT IN ('timestamp', 'ServerTS') -- repository.ReadingMetadataKeys
+  AND (sqlc.narg('keys')::text[] IS NULL OR p.key = ANY(sqlc.narg('keys')::text[]))
+GROUP BY 1, 2
+ORDER BY 1 ASC, 2 ASC;
+
+-- name: EnsureReadingPartitions :exec
+SELECT ensure_sensor_readings_partitions(sqlc.arg('months_ahead')::int);
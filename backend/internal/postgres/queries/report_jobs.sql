@@ -0,0 +1,87 @@
+-- name: CreateReportJob :one
+INSERT INTO report_jobs (kind, params, created_by, notify_email, download_token)
+VALUES (sqlc.arg('kind'), sqlc.arg('params'), sqlc.arg('created_by'), sqlc.narg('notify_email'), sqlc.arg('download_token'))
+RETURNING *;
+
+-- name: GetReportJobByID :one
+SELECT * FROM report_jobs
+WHERE id = $1;
+
+-- name: ListReportJobsByUser :many
+SELECT * FROM report_jobs
+WHERE created_by = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: ClaimReportJob :one
+-- Takes the oldest queued job, or a running one whose worker stopped renewing
+-- its lease, for example because the server restarted.
+UPDATE report_jobs
+SET status = 'running',
+    attempts = attempts + 1,
+    progress = 0,
+    error = NULL,
+    started_at = now(),
+    locked_until = now() + make_interval(secs => sqlc.arg('lease_seconds')::float8)
+WHERE id = (
+    SELECT id FROM report_jobs
+    WHERE status = 'queued'
+       OR (status = 'running' AND locked_until < now())
+    ORDER BY created_at ASC
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: RenewReportJobLease :execrows
+-- Extends the lease of a job for the worker that claimed it. A job claimed
+-- again since has more attempts, so no row means the lease was lost.
+UPDATE report_jobs
+SET locked_until = now() + make_interval(secs => sqlc.arg('lease_seconds')::float8)
+WHERE id = sqlc.arg('id') AND status = 'running' AND attempts = sqlc.arg('attempts');
+
+-- name: UpdateReportJobProgress :exec
+UPDATE report_jobs
+SET progress = sqlc.arg('progress')
+WHERE id = sqlc.arg('id') AND status = 'running' AND attempts = sqlc.arg('attempts');
+
+-- name: CompleteReportJob :one
+UPDATE report_jobs
+SET status = 'succeeded',
+    progress = 100,
+    file_name = sqlc.arg('file_name'),
+    content_type = sqlc.arg('content_type'),
+    file_size = sqlc.arg('file_size'),
+    locked_until = NULL,
+    finished_at = now(),
+    expires_at = sqlc.arg('expires_at')
+WHERE id = sqlc.arg('id') AND status = 'running' AND attempts = sqlc.arg('attempts')
+RETURNING *;
+
+-- name: FailReportJob :one
+UPDATE report_jobs
+SET status = 'failed',
+    error = sqlc.arg('error'),
+    locked_until = NULL,
+    finished_at = now(),
+    expires_at = sqlc.arg('expires_at')
+WHERE id = sqlc.arg('id') AND status = 'running' AND attempts = sqlc.arg('attempts')
+RETURNING *;
+
+-- name: CreateReportJobFile :exec
+INSERT INTO report_job_files (job_id, data)
+VALUES ($1, $2)
+ON CONFLICT (job_id) DO UPDATE SET data = EXCLUDED.data;
+
+-- name: GetReportJobFile :one
+SELECT j.file_name, j.content_type, f.data
+FROM report_jobs j
+JOIN report_job_files f ON f.job_id = j.id
+WHERE j.id = sqlc.arg('id')
+  AND j.download_token = sqlc.arg('download_token')
+  AND j.status = 'succeeded'
+  AND (j.expires_at IS NULL OR j.expires_at > now());
+
+-- name: DeleteExpiredReportJobs :execrows
+DELETE FROM report_jobs
+WHERE expires_at < now();
@@ -0,0 +1,68 @@
+-- name: CreateReportSchedule :one
+INSERT INTO report_schedules (
+    name, cron_expression, timezone, kind, reactor_id, device_ids, window_seconds, recipients, enabled, next_run_at, created_by
+)
+VALUES (
+    sqlc.arg('name'), sqlc.arg('cron_expression'), sqlc.arg('timezone'), sqlc.arg('kind'), sqlc.narg('reactor_id'),
+    sqlc.arg('device_ids'), sqlc.arg('window_seconds'), sqlc.arg('recipients'), sqlc.arg('enabled'), sqlc.arg('next_run_at'),
+    sqlc.arg('created_by')
+)
+RETURNING *;
+
+-- name: GetReportScheduleByID :one
+SELECT * FROM report_schedules
+WHERE id = $1;
+
+-- name: ListReportSchedules :many
+SELECT * FROM report_schedules
+ORDER BY id ASC;
+
+-- name: UpdateReportSchedule :one
+UPDATE report_schedules
+SET name = sqlc.arg('name'),
+    cron_expression = sqlc.arg('cron_expression'),
+    timezone = sqlc.arg('timezone'),
+    kind = sqlc.arg('kind'),
+    reactor_id = sqlc.narg('reactor_id'),
+    device_ids = sqlc.arg('device_ids'),
+    window_seconds = sqlc.arg('window_seconds'),
+    recipients = sqlc.arg('recipients'),
+    enabled = sqlc.arg('enabled'),
+    next_run_at = sqlc.arg('next_run_at'),
+    updated_at = now()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: DeleteReportSchedule :execrows
+DELETE FROM report_schedules
+WHERE id = $1;
+
+-- name: LockDueReportSchedule :one
+-- Locks the most overdue schedule for the rest of the transaction. Other
+-- replicas skip it, so each run happens once.
+SELECT * FROM report_schedules
+WHERE enabled = true AND next_run_at <= sqlc.arg('now')
+ORDER BY next_run_at ASC
+FOR UPDATE SKIP LOCKED
+LIMIT 1;
+
+-- name: AdvanceReportSchedule :exec
+UPDATE report_schedules
+SET next_run_at = sqlc.arg('next_run_at'),
+    last_run_at = sqlc.arg('last_run_at')
+WHERE id = sqlc.arg('id');
+
+-- name: DisableReportSchedule :exec
+-- Turns off a schedule whose next run cannot be computed, such as one with a
+-- timezone since removed from the tz database, so it stops blocking the claim
+-- of the schedules due after it.
+UPDATE report_schedules
+SET enabled = false,
+    last_error = sqlc.arg('last_error'),
+    updated_at = now()
+WHERE id = sqlc.arg('id');
+
+-- name: SetReportScheduleError :exec
+UPDATE report_schedules
+SET last_error = sqlc.narg('last_error')
+WHERE id = sqlc.arg('id');
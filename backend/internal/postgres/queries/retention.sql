@@ -0,0 +1,193 @@
+-- name: ListRetentionPolicies :many
+SELECT * FROM retention_policies
+ORDER BY device_id ASC NULLS FIRST;
+
+-- name: GetEffectiveRetentionPolicy :one
+SELECT * FROM effective_retention_policies
+WHERE device_id = $1;
+
+-- name: UpdateGlobalRetentionPolicy :one
+UPDATE retention_policies
+SET raw_retention_days = sqlc.narg('raw_retention_days'),
+    minute_rollup_retention_days = sqlc.narg('minute_rollup_retention_days'),
+    hour_rollup_retention_days = sqlc.narg('hour_rollup_retention_days'),
+    updated_at = now()
+WHERE device_id IS NULL
+RETURNING *;
+
+-- name: UpsertDeviceRetentionPolicy :one
+INSERT INTO retention_policies (device_id, raw_retention_days, minute_rollup_retention_days, hour_rollup_retention_days)
+VALUES (sqlc.arg('device_id'), sqlc.narg('raw_retention_days'), sqlc.narg('minute_rollup_retention_days'), sqlc.narg('hour_rollup_retention_days'))
+ON CONFLICT (device_id) DO UPDATE
+SET raw_retention_days = EXCLUDED.raw_retention_days,
+    minute_rollup_retention_days = EXCLUDED.minute_rollup_retention_days,
+    hour_rollup_retention_days = EXCLUDED.hour_rollup_retention_days,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteDeviceRetentionPolicy :execrows
+DELETE FROM retention_policies
+WHERE device_id = $1;
+
+-- name: GetRollupWatermark :one
+SELECT rolled_up_to FROM reading_rollup_watermarks
+WHERE tier = $1;
+
+-- name: SetRollupWatermark :exec
+UPDATE reading_rollup_watermarks
+SET rolled_up_to = sqlc.arg('rolled_up_to')
+WHERE tier = sqlc.arg('tier');
+
+-- name: RollupMinuteReadings :execrows
+-- Recomputes every minute bucket that received readings in the window, so
+-- late readings update buckets that were already rolled up.
+WITH touched AS (
+    SELECT DISTINCT device_id, date_trunc('minute', timestamp, 'UTC') AS bucket
+    FROM sensor_readings
+    WHERE received_at > sqlc.arg('received_after') AND received_at <= sqlc.arg('received_before')
+)
+INSERT INTO reading_rollups_1m (device_id, bucket, key, count, sum_value, min_value, max_value, last_value, updated_at)
+SELECT
+    r.device_id,
+    t.bucket,
+    p.key,
+    COUNT(*),
+    SUM(p.value::float8),
+    MIN(p.value::float8),
+    MAX(p.value::float8),
+    (array_agg(p.value::float8 ORDER BY r.timestamp DESC))[1],
+    now()
+FROM touched t
+JOIN sensor_readings r ON r.device_id = t.device_id AND r.timestamp >= t.bucket AND r.timestamp < t.bucket + INTERVAL '1 minute'
+CROSS JOIN LATERAL jsonb_each(r.payload) AS p
+WHERE jsonb_typeof(p.value) = 'number'
+  AND p.key NOT IN ('timestamp', 'ServerTS') -- repository.ReadingMetadataKeys
+GROUP BY r.device_id, t.bucket, p.key
+ON CONFLICT (device_id, bucket, key) DO UPDATE
+SET count = EXCLUDED.count,
+    sum_value = EXCLUDED.sum_value,
+    min_value = EXCLUDED.min_value,
+    max_value = EXCLUDED.max_value,
+    last_value = EXCLUDED.last_value,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: RollupHourReadings :execrows
+WITH touched AS (
+    SELECT DISTINCT device_id, date_trunc('hour', bucket, 'UTC') AS bucket
+    FROM reading_rollups_1m
+    WHERE updated_at > sqlc.arg('updated_after') AND updated_at <= sqlc.arg('updated_before')
+)
+INSERT INTO reading_rollups_1h (device_id, bucket, key, count, sum_value, min_value, max_value, last_value, updated_at)
+SELECT
+    m.device_id,
+    t.bucket,
+    m.key,
+    SUM(m.count),
+    SUM(m.sum_value),
+    MIN(m.min_value),
+    MAX(m.max_value),
+    (array_agg(m.last_value ORDER BY m.bucket DESC))[1],
+    now()
+FROM touched t
+JOIN reading_rollups_1m m ON m.device_id = t.device_id AND m.bucket >= t.bucket AND m.bucket < t.bucket + INTERVAL '1 hour'
+GROUP BY m.device_id, t.bucket, m.key
+ON CONFLICT (device_id, bucket, key) DO UPDATE
+SET count = EXCLUDED.count,
+    sum_value = EXCLUDED.sum_value,
+    min_value = EXCLUDED.min_value,
+    max_value = EXCLUDED.max_value,
+    last_value = EXCLUDED.last_value,
+    updated_at = EXCLUDED.updated_at;
+
+-- name: DeleteExpiredReadings :execrows
+-- Only readings the minute rollup has already covered are dropped.
+DELETE FROM sensor_readings
+WHERE (id, timestamp) IN (
+    SELECT r.id, r.timestamp
+    FROM sensor_readings r
+    JOIN effective_retention_policies e ON e.device_id = r.device_id
+    WHERE e.raw_retention_days IS NOT NULL
+      AND r.timestamp < now() - make_interval(days => e.raw_retention_days)
+      AND r.received_at <= sqlc.arg('rolled_up_to')
+    LIMIT sqlc.arg('batch_size')
+);
+
+-- name: DeleteExpiredReadingMessageIDs :execrows
+-- Message ids are forgotten once readings received with them are past raw
+-- retention.
+DELETE FROM reading_message_ids
+WHERE (device_id, message_id) IN (
+    SELECT m.device_id, m.message_id
+    FROM reading_message_ids m
+    JOIN effective_retention_policies e ON e.device_id = m.device_id
+    WHERE e.raw_retention_days IS NOT NULL
+      AND m.received_at < now() - make_interval(days => e.raw_retention_days)
+    LIMIT sqlc.arg('batch_size')
+);
+
+-- name: DeleteExpiredMinuteRollups :execrows
+DELETE FROM reading_rollups_1m m
+USING effective_retention_policies e
+WHERE e.device_id = m.device_id
+  AND e.minute_rollup_retention_days IS NOT NULL
+  AND m.bucket < now() - make_interval(days => e.minute_rollup_retention_days)
+  AND m.updated_at <= sqlc.arg('rolled_up_to');
+
+-- name: DeleteExpiredHourRollups :execrows
+DELETE FROM reading_rollups_1h h
+USING effective_retention_policies e
+WHERE e.device_id = h.device_id
+  AND e.hour_rollup_retention_days IS NOT NULL
+  AND h.bucket < now() - make_interval(days => e.hour_rollup_retention_days);
+
+-- name: AggregateMinuteRollups :many
+SELECT
+    to_timestamp(floor(extract(epoch FROM bucket) / sqlc.arg('bucket_seconds')::bigint) * sqlc.arg('bucket_seconds')::bigint)::timestamptz AS bucket,
+    key,
+    SUM(count)::bigint AS count,
+    (SUM(sum_value) / SUM(count))::float8 AS avg_value,
+    MIN(min_value)::float8 AS min_value,
+    MAX(max_value)::float8 AS max_value,
+    (array_agg(last_value ORDER BY bucket DESC))[1]::float8 AS last_value
+FROM reading_rollups_1m
+WHERE device_id = sqlc.arg('device_id')
+  AND bucket >= sqlc.arg('start_time')
+  AND bucket < sqlc.arg('end_time')
+  AND (sqlc.narg('keys')::text[] IS NULL OR key = ANY(sqlc.narg('keys')::text[]))
+GROUP BY 1, 2
+ORDER BY 1 ASC, 2 ASC;
+
+-- name: AggregateHourRollups :many
+SELECT
+    to_timestamp(floor(extract(epoch FROM bucket) / sqlc.arg('bucket_seconds')::bigint) * sqlc.arg('bucket_seconds')::bigint)::timestamptz AS bucket,
+    key,
+    SUM(count)::bigint AS count,
+    (SUM(sum_value) / SUM(count))::float8 AS avg_value,
+    MIN(min_value)::float8 AS min_value,
+    MAX(max_value)::float8 AS max_value,
+    (array_agg(last_value ORDER BY bucket DESC))[1]::float8 AS last_value
+FROM reading_rollups_1h
+WHERE device_id = sqlc.arg('device_id')
+  AND bucket >= sqlc.arg('start_time')
+  AND bucket < sqlc.arg('end_time')
+  AND (sqlc.narg('keys')::text[] IS NULL OR key = ANY(sqlc.narg('keys')::text[]))
+GROUP BY 1, 2
+ORDER BY 1 ASC, 2 ASC;
+
+-- name: ListMinuteRollupReadings :many
+SELECT bucket, jsonb_object_agg(key, sum_value / count)::jsonb AS payload
+FROM reading_rollups_1m
+WHERE device_id = sqlc.arg('device_id')
+  AND bucket >= sqlc.arg('start_time')
+  AND bucket < sqlc.arg('end_time')
+GROUP BY bucket
+ORDER BY bucket ASC;
+
+-- name: ListHourRollupReadings :many
+SELECT bucket, jsonb_object_agg(key, sum_value / count)::jsonb AS payload
+FROM reading_rollups_1h
+WHERE device_id = sqlc.arg('device_id')
+  AND bucket >= sqlc.arg('start_time')
+  AND bucket < sqlc.arg('end_time')
+GROUP BY bucket
+ORDER BY bucket ASC;
@@ -25,21 +25,21 @@ func NewReactorRepository(store *Store) *ReactorRepository {
 
 func (r *ReactorRepository) CreateReactor(ctx context.Context, reactor *repository.Reactor) (*repository.Reactor, error) {
 	createParams := generated.CreateReactorParams{
-		Name:    reactor.Name,
-		Status:  strings.ToLower(reactor.Status),
-		Pathway: pgtype.Text{Valid: false},
-		PdfUrl:  pgtype.Text{Valid: false},
+		Name:      reactor.Name,
+		Status:    strings.ToLower(reactor.Status),
+		Pathway:   pgtype.Text{Valid: false},
+		PdfFileID: uint32PtrToPgInt8(reactor.PdfFileID),
 	}
 
 	if reactor.Pathway != "" {
 		createParams.Pathway = pgtype.Text{String: reactor.Pathway, Valid: true}
 	}
-	if reactor.PdfUrl != "" {
-		createParams.PdfUrl = pgtype.Text{String: reactor.PdfUrl, Valid: true}
-	}
 
 	dbReactor, err := r.queries.CreateReactor(ctx, createParams)
 	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "file with id %d not found", *reactor.PdfFileID)
+		}
 		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create reactor: %v", err)
 	}
 
@@ -60,11 +60,11 @@ func (r *ReactorRepository) GetReactorByID(ctx context.Context, id uint32) (*rep
 
 func (r *ReactorRepository) UpdateReactor(ctx context.Context, updateReactor *repository.UpdateReactor) error {
 	updateParams := generated.UpdateReactorParams{
-		ID:      int64(updateReactor.ID),
-		Name:    pgtype.Text{Valid: false},
-		Status:  pgtype.Text{Valid: false},
-		Pathway: pgtype.Text{Valid: false},
-		PdfUrl:  pgtype.Text{Valid: false},
+		ID:        int64(updateReactor.ID),
+		Name:      pgtype.Text{Valid: false},
+		Status:    pgtype.Text{Valid: false},
+		Pathway:   pgtype.Text{Valid: false},
+		PdfFileID: uint32PtrToPgInt8(updateReactor.PdfFileID),
 	}
 
 	if updateReactor.Name != nil {
@@ -77,12 +77,12 @@ func (r *ReactorRepository) UpdateReactor(ctx context.Context, updateReactor *re
 	if updateReactor.Pathway != nil {
 		updateParams.Pathway = pgtype.Text{String: *updateReactor.Pathway, Valid: true}
 	}
-	if updateReactor.PdfUrl != nil {
-		updateParams.PdfUrl = pgtype.Text{String: *updateReactor.PdfUrl, Valid: true}
-	}
 
 	err := r.queries.UpdateReactor(ctx, updateParams)
 	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return pkg.Errorf(pkg.INVALID_ERROR, "file with id %d not found", *updateReactor.PdfFileID)
+		}
 		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update reactor: %v", err)
 	}
 
@@ -164,6 +164,7 @@ func mapDBReactorToReactor(dbReactor generated.Reactor) *repository.Reactor {
 		Name:      dbReactor.Name,
 		Status:    string(dbReactor.Status),
 		Pathway:   pathway,
+		PdfFileID: pgInt8ToUint32Ptr(dbReactor.PdfFileID),
 		PdfUrl:    pdfUrl,
 		DeletedAt: deletedAt,
 		CreatedAt: dbReactor.CreatedAt,
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"slices"
+	"time"
 
 	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
 	"github.com/Edwin9301/Zen/backend/internal/repository"
@@ -13,9 +15,20 @@ import (
 )
 
 func (r *DeviceRepository) AddReading(ctx context.Context, reading *repository.Reading) (*repository.Reading, error) {
-	// if err := reading.Payload.Validate(); err != nil {
-	// 	return nil, err
-	// }
+	channels, err := r.ListDeviceChannels(ctx, reading.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repository.ValidateReadingPayload(channels, reading.Payload); err != nil {
+		return nil, err
+	}
+
+	receivedAt := time.Now()
+	timestamp, err := r.readingTimestamp(reading.Timestamp, receivedAt)
+	if err != nil {
+		return nil, err
+	}
 
 	payloadBytes, err := json.Marshal(reading.Payload)
 	if err != nil {
@@ -23,27 +36,215 @@ func (r *DeviceRepository) AddReading(ctx context.Context, reading *repository.R
 	}
 
 	arg := generated.InsertReadingParams{
-		DeviceID: int64(reading.DeviceID),
-		Payload:  payloadBytes,
+		DeviceID:   int64(reading.DeviceID),
+		Payload:    payloadBytes,
+		Timestamp:  timestamp,
+		ReceivedAt: receivedAt,
+		MessageID:  stringToPgText(reading.MessageID),
 	}
-	dbReading, err := r.queries.InsertReading(ctx, arg)
+
+	var (
+		dbReading generated.SensorReading
+		duplicate bool
+	)
+	err = r.store.ExecTx(ctx, func(q *generated.Queries) error {
+		// the message id is claimed before the insert, so a concurrent retry
+		// waits on it and then finds the reading stored here
+		if arg.MessageID.Valid {
+			claimed, err := q.ClaimReadingMessageID(ctx, generated.ClaimReadingMessageIDParams{
+				DeviceID:   arg.DeviceID,
+				MessageID:  arg.MessageID.String,
+				ReceivedAt: receivedAt,
+			})
+			if err != nil {
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to claim reading message id: %s", err.Error())
+			}
+
+			if claimed == 0 {
+				duplicate = true
+				dbReading, err = q.GetReadingByMessageID(ctx, generated.GetReadingByMessageIDParams{
+					DeviceID:  arg.DeviceID,
+					MessageID: arg.MessageID,
+				})
+				if err != nil {
+					if errors.Is(err, sql.ErrNoRows) {
+						return pkg.Errorf(pkg.ALREADY_EXISTS_ERROR, "the reading with message_id %q was already stored and is past retention", reading.MessageID)
+					}
+					return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get existing reading: %s", err.Error())
+				}
+
+				return touchDeviceLastSeen(ctx, q, reading.DeviceID, receivedAt)
+			}
+		}
+
+		dbReading, err = q.InsertReading(ctx, arg)
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to add reading: %s", err.Error())
+		}
+
+		return touchDeviceLastSeen(ctx, q, reading.DeviceID, receivedAt)
+	})
 	if err != nil {
-		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to add reading: %s", err.Error())
+		return nil, err
 	}
 
-	var payload any
-	if len(dbReading.Payload) > 0 {
-		if err := json.Unmarshal(dbReading.Payload, &payload); err != nil {
-			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal reading payload: %s", err.Error())
+	createdReading, err := mapDBReadingToReading(dbReading)
+	if err != nil {
+		return nil, err
+	}
+	createdReading.Duplicate = duplicate
+
+	return createdReading, nil
+}
+
+// AddReadings bulk inserts readings for a single device with one COPY inside a
+// transaction. Readings that cannot be stored are reported back as rejections
+// together with their index in the batch. Readings whose message id was seen
+// earlier in the batch or is already stored are skipped and counted as
+// duplicates. Message ids are claimed in the same transaction as the COPY,
+// so a concurrent request with the same ids waits for it. COPY does not
+// return rows, so the stored readings come back without an ID.
+func (r *DeviceRepository) AddReadings(ctx context.Context, deviceID uint32, readings []*repository.Reading) (*repository.ReadingBatchResult, error) {
+	device, err := r.GetDeviceByID(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	channels := device.Channels
+
+	result := &repository.ReadingBatchResult{
+		Rejections: []repository.ReadingRejection{},
+	}
+
+	// message ids repeated within the batch
+	seen := map[string]bool{}
+
+	receivedAt := time.Now()
+	rows := make([]generated.InsertReadingsParams, 0, len(readings))
+	stored := make([]*repository.Reading, 0, len(readings))
+	for idx, reading := range readings {
+		if reading.MessageID != "" {
+			if seen[reading.MessageID] {
+				result.Duplicates++
+				continue
+			}
+			seen[reading.MessageID] = true
+		}
+
+		if err := repository.ValidateReadingPayload(channels, reading.Payload); err != nil {
+			result.Rejections = append(result.Rejections, repository.ReadingRejection{
+				Index:  idx,
+				Reason: pkg.ErrorMessage(err),
+			})
+			continue
+		}
+
+		timestamp, err := r.readingTimestamp(reading.Timestamp, receivedAt)
+		if err != nil {
+			result.Rejections = append(result.Rejections, repository.ReadingRejection{
+				Index:  idx,
+				Reason: pkg.ErrorMessage(err),
+			})
+			continue
+		}
+
+		payloadBytes, err := json.Marshal(reading.Payload)
+		if err != nil {
+			result.Rejections = append(result.Rejections, repository.ReadingRejection{
+				Index:  idx,
+				Reason: "failed to marshal reading payload: " + err.Error(),
+			})
+			continue
 		}
+
+		rows = append(rows, generated.InsertReadingsParams{
+			DeviceID:   int64(deviceID),
+			Payload:    payloadBytes,
+			Timestamp:  timestamp,
+			ReceivedAt: receivedAt,
+			MessageID:  stringToPgText(reading.MessageID),
+		})
+		stored = append(stored, &repository.Reading{
+			DeviceID:   deviceID,
+			Payload:    reading.Payload,
+			Timestamp:  timestamp,
+			ReceivedAt: receivedAt,
+			MessageID:  reading.MessageID,
+		})
 	}
 
-	return &repository.Reading{
-		ID:        uint32(dbReading.ID),
-		DeviceID:  uint32(dbReading.DeviceID),
-		Payload:   payload,
-		Timestamp: dbReading.Timestamp,
-	}, nil
+	if len(rows) > 0 {
+		err := r.store.ExecTx(ctx, func(q *generated.Queries) error {
+			unclaimed := len(rows)
+			rows, stored, err := claimReadingMessageIDs(ctx, q, deviceID, receivedAt, rows, stored)
+			if err != nil {
+				return err
+			}
+			result.Duplicates += unclaimed - len(rows)
+			if len(rows) == 0 {
+				return touchDeviceLastSeen(ctx, q, deviceID, receivedAt)
+			}
+
+			count, err := q.InsertReadings(ctx, rows)
+			if err != nil {
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to add readings: %s", err.Error())
+			}
+
+			result.Accepted = int(count)
+			result.Stored = stored
+
+			return touchDeviceLastSeen(ctx, q, deviceID, receivedAt)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Rejected = len(result.Rejections)
+
+	return result, nil
+}
+
+// claimReadingMessageIDs claims the message ids of the rows of a batch and
+// drops the rows, and their stored readings, whose id was claimed before.
+func claimReadingMessageIDs(ctx context.Context, q *generated.Queries, deviceID uint32, receivedAt time.Time, rows []generated.InsertReadingsParams, stored []*repository.Reading) ([]generated.InsertReadingsParams, []*repository.Reading, error) {
+	messageIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.MessageID.Valid {
+			messageIDs = append(messageIDs, row.MessageID.String)
+		}
+	}
+
+	if len(messageIDs) == 0 {
+		return rows, stored, nil
+	}
+	// batches sharing ids claim them in the same order rather than deadlock
+	slices.Sort(messageIDs)
+
+	claimedIDs, err := q.ClaimReadingMessageIDs(ctx, generated.ClaimReadingMessageIDsParams{
+		DeviceID:   int64(deviceID),
+		MessageIds: messageIDs,
+		ReceivedAt: receivedAt,
+	})
+	if err != nil {
+		return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to claim reading message ids: %s", err.Error())
+	}
+
+	claimed := make(map[string]bool, len(claimedIDs))
+	for _, messageID := range claimedIDs {
+		claimed[messageID] = true
+	}
+
+	keptRows := rows[:0]
+	keptStored := stored[:0]
+	for i, row := range rows {
+		if row.MessageID.Valid && !claimed[row.MessageID.String] {
+			continue
+		}
+		keptRows = append(keptRows, row)
+		keptStored = append(keptStored, stored[i])
+	}
+
+	return keptRows, keptStored, nil
 }
 
 func (r *DeviceRepository) GetReadingByID(ctx context.Context, id uint32) (*repository.Reading, error) {
@@ -55,59 +256,115 @@ func (r *DeviceRepository) GetReadingByID(ctx context.Context, id uint32) (*repo
 		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get reading: %s", err.Error())
 	}
 
-	var payload any
-	if len(dbReading.Payload) > 0 {
-		if err := json.Unmarshal(dbReading.Payload, &payload); err != nil {
-			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal reading payload: %s", err.Error())
-		}
-	}
-
-	return &repository.Reading{
-		ID:        uint32(dbReading.ID),
-		DeviceID:  uint32(dbReading.DeviceID),
-		Payload:   payload,
-		Timestamp: dbReading.Timestamp,
-	}, nil
+	return mapDBReadingToReading(dbReading)
 }
 
-func (r *DeviceRepository) ListReadingByDevice(ctx context.Context, filter *repository.ReadingFilter) ([]*repository.Reading, *pkg.Pagination, error) {
-	args := generated.GetDeviceReadingsParams{
-		DeviceID: int64(filter.DeviceID),
-		Limit:    int32(filter.Pagination.PageSize),
-		Offset:   pkg.Offset(filter.Pagination.Page, filter.Pagination.PageSize),
+// ListReadingByDevice pages through a device's readings newest first using a
+// (timestamp, id) keyset, so deep pages cost the same as the first one.
+func (r *DeviceRepository) ListReadingByDevice(ctx context.Context, filter *repository.ReadingFilter) ([]*repository.Reading, *repository.ReadingPage, error) {
+	if filter.Limit == 0 {
+		return nil, nil, pkg.Errorf(pkg.INVALID_ERROR, "limit must be greater than zero")
 	}
 
-	dbReadings, err := r.queries.GetDeviceReadings(ctx, args)
+	// one extra row tells whether there is another page after this one
+	limit := int32(filter.Limit) + 1
+
+	var (
+		dbReadings []generated.SensorReading
+		err        error
+	)
+	switch {
+	case filter.Cursor == nil:
+		dbReadings, err = r.queries.GetDeviceReadings(ctx, generated.GetDeviceReadingsParams{
+			DeviceID: int64(filter.DeviceID),
+			Limit:    limit,
+			Offset:   0,
+		})
+	case filter.Cursor.Backward:
+		dbReadings, err = r.queries.GetDeviceReadingsPagedAfter(ctx, generated.GetDeviceReadingsPagedAfterParams{
+			DeviceID:        int64(filter.DeviceID),
+			CursorTimestamp: filter.Cursor.Timestamp,
+			CursorID:        filter.Cursor.ID,
+			Limit:           limit,
+		})
+	default:
+		dbReadings, err = r.queries.GetDeviceReadingsPaged(ctx, generated.GetDeviceReadingsPagedParams{
+			DeviceID:        int64(filter.DeviceID),
+			CursorTimestamp: filter.Cursor.Timestamp,
+			CursorID:        filter.Cursor.ID,
+			Limit:           limit,
+		})
+	}
 	if err != nil {
 		return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list readings by device: %s", err.Error())
 	}
 
-	readings := make([]*repository.Reading, 0, len(dbReadings))
-	for _, dbReading := range dbReadings {
-		var payload any
-		if len(dbReading.Payload) > 0 {
-			if err := json.Unmarshal(dbReading.Payload, &payload); err != nil {
-				return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal reading payload: %s", err.Error())
-			}
-		}
+	hasMore := len(dbReadings) > int(filter.Limit)
+	if hasMore {
+		dbReadings = dbReadings[:filter.Limit]
+	}
 
-		readings = append(readings, &repository.Reading{
-			ID:        uint32(dbReading.ID),
-			DeviceID:  uint32(dbReading.DeviceID),
-			Payload:   payload,
-			Timestamp: dbReading.Timestamp,
-		})
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if backward {
+		slices.Reverse(dbReadings)
 	}
 
-	count, err := r.queries.CountDeviceReadings(ctx, int64(filter.DeviceID))
+	readings, err := mapDBReadingsToReadings(dbReadings)
 	if err != nil {
-		return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to count readings by device: %s", err.Error())
+		return nil, nil, err
+	}
+
+	page := &repository.ReadingPage{Limit: filter.Limit}
+	if len(dbReadings) > 0 {
+		first, last := dbReadings[0], dbReadings[len(dbReadings)-1]
+
+		// paging backward always came from an older page, and forward from a newer one
+		if hasMore || backward {
+			page.Next = pkg.Cursor{Timestamp: last.Timestamp, ID: last.ID}.Encode()
+		}
+		if filter.Cursor != nil && (hasMore || !backward) {
+			page.Prev = pkg.Cursor{Timestamp: first.Timestamp, ID: first.ID, Backward: true}.Encode()
+		}
+	}
+
+	switch filter.Count {
+	case repository.CountExact:
+		count, err := r.queries.CountDeviceReadings(ctx, int64(filter.DeviceID))
+		if err != nil {
+			return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to count readings by device: %s", err.Error())
+		}
+		page.Total = &count
+	case repository.CountEstimate:
+		estimate, err := r.queries.EstimateDeviceReadings(ctx, int64(filter.DeviceID))
+		if err != nil {
+			return nil, nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to estimate readings by device: %s", err.Error())
+		}
+		page.Total = &estimate
+		page.TotalEstimated = true
 	}
 
-	return readings, pkg.CalculatePagination(uint32(count), filter.Pagination.PageSize, filter.Pagination.Page), nil
+	return readings, page, nil
 }
 
 func (r *DeviceRepository) ListReadingByDate(ctx context.Context, filter *repository.ReadingFilter) ([]*repository.Reading, error) {
+	dayStart := time.Date(filter.Date.Year(), filter.Date.Month(), filter.Date.Day(), 0, 0, 0, 0, time.UTC)
+	ranges, err := r.readingRanges(ctx, filter.DeviceID, dayStart, dayStart.AddDate(0, 0, 1).Add(-time.Microsecond))
+	if err != nil {
+		return nil, err
+	}
+
+	// days past raw retention are served from rollups, newest first like raw
+	if len(ranges) > 1 || ranges[0].resolution != repository.ResolutionRaw {
+		readings, err := r.listRangeReadings(ctx, filter.DeviceID, ranges)
+		if err != nil {
+			return nil, err
+		}
+
+		slices.Reverse(readings)
+
+		return readings, nil
+	}
+
 	dbReadings, err := r.queries.GetReadingsByDate(ctx, generated.GetReadingsByDateParams{
 		DeviceID: int64(filter.DeviceID),
 		Column2: pgtype.Date{
@@ -119,58 +376,113 @@ func (r *DeviceRepository) ListReadingByDate(ctx context.Context, filter *reposi
 		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list readings by date: %s", err.Error())
 	}
 
-	readings := make([]*repository.Reading, 0, len(dbReadings))
-	for _, dbReading := range dbReadings {
-		var payload any
-		if len(dbReading.Payload) > 0 {
-			if err := json.Unmarshal(dbReading.Payload, &payload); err != nil {
-				return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal reading payload: %s", err.Error())
-			}
-		}
-
-		readings = append(readings, &repository.Reading{
-			ID:        uint32(dbReading.ID),
-			DeviceID:  uint32(dbReading.DeviceID),
-			Payload:   payload,
-			Timestamp: dbReading.Timestamp,
-		})
-	}
-
-	return readings, nil
+	return mapDBReadingsToReadings(dbReadings)
 }
 
+// ListReadingByTimeRange filters on the device measured-at timestamp, so
+// backfilled readings show up where they were taken rather than received.
+// Parts of the range past the device's raw retention come from rollups.
 func (r *DeviceRepository) ListReadingByTimeRange(ctx context.Context, filter *repository.ReadingFilter) ([]*repository.Reading, error) {
 	if filter.Start == nil || filter.End == nil {
 		return nil, pkg.Errorf(pkg.INVALID_ERROR, "start and end time must be provided")
 	}
 
-	args := generated.GetReadingsByTimeRangeParams{
-		DeviceID:    int64(filter.DeviceID),
-		Timestamp:   *filter.Start,
-		Timestamp_2: *filter.End,
+	ranges, err := r.readingRanges(ctx, filter.DeviceID, *filter.Start, *filter.End)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.listRangeReadings(ctx, filter.DeviceID, ranges)
+}
+
+// readingTimestamp resolves the measured-at time to store for a reading,
+// applying the configured clock skew policy to device supplied timestamps.
+func (r *DeviceRepository) readingTimestamp(measuredAt, receivedAt time.Time) (time.Time, error) {
+	if measuredAt.IsZero() {
+		return receivedAt, nil
 	}
 
-	dbReadings, err := r.queries.GetReadingsByTimeRange(ctx, args)
-	if err != nil {
-		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list readings by time range: %s", err.Error())
+	maxSkew := r.store.config.READING_MAX_CLOCK_SKEW
+	maxBackfill := r.store.config.READING_MAX_BACKFILL_AGE
+
+	skew := measuredAt.Sub(receivedAt)
+	inFuture := skew > maxSkew
+	tooOld := maxBackfill > 0 && -skew > maxBackfill
+	if !inFuture && !tooOld {
+		return r.partitionedTimestamp(measuredAt, receivedAt)
+	}
+
+	switch repository.ClockSkewPolicy(r.store.config.READING_CLOCK_SKEW_POLICY) {
+	case repository.ClockSkewAccept:
+		return r.partitionedTimestamp(measuredAt, receivedAt)
+	case repository.ClockSkewReject:
+		if inFuture {
+			return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "reading timestamp %s is %s ahead of server time", measuredAt.Format(time.RFC3339), skew.Round(time.Second))
+		}
+		return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "reading timestamp %s is older than the allowed backfill age of %s", measuredAt.Format(time.RFC3339), maxBackfill)
+	default:
+		// ClockSkewClamp, the only other policy pkg.LoadConfig accepts
+		if inFuture {
+			return receivedAt, nil
+		}
+		return receivedAt.Add(-maxBackfill), nil
+	}
+}
+
+// partitionedTimestamp rejects timestamps past the monthly partitions that
+// ensure_sensor_readings_partitions creates ahead of time. There is no
+// default partition, so the insert would otherwise fail, and with it the
+// whole batch the reading came in.
+func (r *DeviceRepository) partitionedTimestamp(measuredAt, receivedAt time.Time) (time.Time, error) {
+	now := receivedAt.UTC()
+	horizon := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).
+		AddDate(0, max(r.store.config.READING_PARTITION_MONTHS_AHEAD, 0)+1, 0)
+
+	if !measuredAt.Before(horizon) {
+		return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "reading timestamp %s is past %s, the latest time readings can be stored for", measuredAt.Format(time.RFC3339), horizon.Format(time.RFC3339))
 	}
 
+	return measuredAt, nil
+}
+
+func mapDBReadingToReading(dbReading generated.SensorReading) (*repository.Reading, error) {
+	var payload any
+	if len(dbReading.Payload) > 0 {
+		if err := json.Unmarshal(dbReading.Payload, &payload); err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal reading payload: %s", err.Error())
+		}
+	}
+
+	return &repository.Reading{
+		ID:         uint32(dbReading.ID),
+		DeviceID:   uint32(dbReading.DeviceID),
+		Payload:    payload,
+		Timestamp:  dbReading.Timestamp,
+		ReceivedAt: dbReading.ReceivedAt,
+		MessageID:  dbReading.MessageID.String,
+	}, nil
+}
+
+func mapDBReadingsToReadings(dbReadings []generated.SensorReading) ([]*repository.Reading, error) {
 	readings := make([]*repository.Reading, 0, len(dbReadings))
 	for _, dbReading := range dbReadings {
-		var payload any
-		if len(dbReading.Payload) > 0 {
-			if err := json.Unmarshal(dbReading.Payload, &payload); err != nil {
-				return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal reading payload: %s", err.Error())
-			}
+		reading, err := mapDBReadingToReading(dbReading)
+		if err != nil {
+			return nil, err
 		}
 
-		readings = append(readings, &repository.Reading{
-			ID:        uint32(dbReading.ID),
-			DeviceID:  uint32(dbReading.DeviceID),
-			Payload:   payload,
-			Timestamp: dbReading.Timestamp,
-		})
+		readings = append(readings, reading)
 	}
 
 	return readings, nil
 }
+
+// EnsureReadingPartitions creates the monthly sensor_readings partitions for
+// the current month and the next monthsAhead months if they are missing.
+func (r *DeviceRepository) EnsureReadingPartitions(ctx context.Context, monthsAhead int) error {
+	if err := r.queries.EnsureReadingPartitions(ctx, int32(monthsAhead)); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create reading partitions: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.ReportJobRepository = (*ReportJobRepository)(nil)
+
+type ReportJobRepository struct {
+	store   *Store
+	queries *generated.Queries
+}
+
+func NewReportJobRepository(store *Store) *ReportJobRepository {
+	return &ReportJobRepository{
+		store:   store,
+		queries: generated.New(store.pool),
+	}
+}
+
+func (r *ReportJobRepository) CreateReportJob(ctx context.Context, job *repository.ReportJob) (*repository.ReportJob, error) {
+	params := []byte(job.Params)
+	if len(params) == 0 {
+		params = []byte("{}")
+	}
+
+	dbJob, err := r.queries.CreateReportJob(ctx, generated.CreateReportJobParams{
+		Kind:          job.Kind,
+		Params:        params,
+		CreatedBy:     int64(job.CreatedBy),
+		NotifyEmail:   stringToPgText(job.NotifyEmail),
+		DownloadToken: job.DownloadToken,
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "user with id %d not found", job.CreatedBy)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create report job: %s", err.Error())
+	}
+
+	return mapDBReportJobToReportJob(dbJob), nil
+}
+
+func (r *ReportJobRepository) GetReportJobByID(ctx context.Context, id uint32) (*repository.ReportJob, error) {
+	dbJob, err := r.queries.GetReportJobByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "report job with id %d not found", id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get report job: %s", err.Error())
+	}
+
+	return mapDBReportJobToReportJob(dbJob), nil
+}
+
+func (r *ReportJobRepository) ListReportJobsByUser(ctx context.Context, userID uint32, limit int32) ([]*repository.ReportJob, error) {
+	dbJobs, err := r.queries.ListReportJobsByUser(ctx, generated.ListReportJobsByUserParams{
+		CreatedBy: int64(userID),
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list report jobs: %s", err.Error())
+	}
+
+	jobs := make([]*repository.ReportJob, 0, len(dbJobs))
+	for _, dbJob := range dbJobs {
+		jobs = append(jobs, mapDBReportJobToReportJob(dbJob))
+	}
+
+	return jobs, nil
+}
+
+func (r *ReportJobRepository) ClaimReportJob(ctx context.Context, lease time.Duration) (*repository.ReportJob, error) {
+	dbJob, err := r.queries.ClaimReportJob(ctx, lease.Seconds())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to claim report job: %s", err.Error())
+	}
+
+	return mapDBReportJobToReportJob(dbJob), nil
+}
+
+func (r *ReportJobRepository) RenewReportJobLease(ctx context.Context, id uint32, attempts int32, lease time.Duration) error {
+	renewed, err := r.queries.RenewReportJobLease(ctx, generated.RenewReportJobLeaseParams{
+		LeaseSeconds: lease.Seconds(),
+		ID:           int64(id),
+		Attempts:     attempts,
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to renew report job lease: %s", err.Error())
+	}
+
+	if renewed == 0 {
+		return reportJobLeaseLost(id)
+	}
+
+	return nil
+}
+
+func (r *ReportJobRepository) UpdateReportJobProgress(ctx context.Context, id uint32, attempts int32, progress int32) error {
+	err := r.queries.UpdateReportJobProgress(ctx, generated.UpdateReportJobProgressParams{
+		Progress: progress,
+		ID:       int64(id),
+		Attempts: attempts,
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update report job progress: %s", err.Error())
+	}
+
+	return nil
+}
+
+// CompleteReportJob writes the file and marks the job done in one
+// transaction, so a succeeded job always has a file to download.
+func (r *ReportJobRepository) CompleteReportJob(ctx context.Context, id uint32, attempts int32, file *repository.ReportJobFile, ttl time.Duration) (*repository.ReportJob, error) {
+	var dbJob generated.ReportJob
+	err := r.store.ExecTx(ctx, func(q *generated.Queries) error {
+		if err := q.CreateReportJobFile(ctx, generated.CreateReportJobFileParams{
+			JobID: int64(id),
+			Data:  file.Data,
+		}); err != nil {
+			return err
+		}
+
+		var err error
+		dbJob, err = q.CompleteReportJob(ctx, generated.CompleteReportJobParams{
+			FileName:    stringToPgText(file.FileName),
+			ContentType: stringToPgText(file.ContentType),
+			FileSize:    pgtype.Int8{Int64: int64(len(file.Data)), Valid: true},
+			ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true},
+			ID:          int64(id),
+			Attempts:    attempts,
+		})
+		return err
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "report job with id %d not found", id)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, reportJobLeaseLost(id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to complete report job: %s", err.Error())
+	}
+
+	return mapDBReportJobToReportJob(dbJob), nil
+}
+
+func (r *ReportJobRepository) FailReportJob(ctx context.Context, id uint32, attempts int32, message string, ttl time.Duration) (*repository.ReportJob, error) {
+	dbJob, err := r.queries.FailReportJob(ctx, generated.FailReportJobParams{
+		Error:     stringToPgText(message),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true},
+		ID:        int64(id),
+		Attempts:  attempts,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, reportJobLeaseLost(id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to fail report job: %s", err.Error())
+	}
+
+	return mapDBReportJobToReportJob(dbJob), nil
+}
+
+// GetReportJobFile returns the file of a finished job. A wrong token, an
+// unfinished job and an expired one all look the same to the caller.
+func (r *ReportJobRepository) GetReportJobFile(ctx context.Context, id uint32, token string) (*repository.ReportJobFile, error) {
+	row, err := r.queries.GetReportJobFile(ctx, generated.GetReportJobFileParams{
+		ID:            int64(id),
+		DownloadToken: token,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "report file not found or expired")
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get report file: %s", err.Error())
+	}
+
+	return &repository.ReportJobFile{
+		FileName:    row.FileName.String,
+		ContentType: row.ContentType.String,
+		Data:        row.Data,
+	}, nil
+}
+
+func (r *ReportJobRepository) DeleteExpiredReportJobs(ctx context.Context) (int64, error) {
+	deleted, err := r.queries.DeleteExpiredReportJobs(ctx)
+	if err != nil {
+		return 0, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete expired report jobs: %s", err.Error())
+	}
+
+	return deleted, nil
+}
+
+// reportJobLeaseLost is returned when the job is gone, finished or claimed
+// again by another worker since this one claimed it.
+func reportJobLeaseLost(id uint32) error {
+	return pkg.Errorf(pkg.NOT_FOUND_ERROR, "report job %d is no longer leased to this worker", id)
+}
+
+func mapDBReportJobToReportJob(dbJob generated.ReportJob) *repository.ReportJob {
+	return &repository.ReportJob{
+		ID:            uint32(dbJob.ID),
+		Kind:          dbJob.Kind,
+		Params:        dbJob.Params,
+		Status:        dbJob.Status,
+		Progress:      dbJob.Progress,
+		Error:         dbJob.Error.String,
+		Attempts:      dbJob.Attempts,
+		CreatedBy:     uint32(dbJob.CreatedBy),
+		NotifyEmail:   dbJob.NotifyEmail.String,
+		FileName:      dbJob.FileName.String,
+		ContentType:   dbJob.ContentType.String,
+		FileSize:      dbJob.FileSize.Int64,
+		StartedAt:     pgTimestamptzToTimePtr(dbJob.StartedAt),
+		FinishedAt:    pgTimestamptzToTimePtr(dbJob.FinishedAt),
+		ExpiresAt:     pgTimestamptzToTimePtr(dbJob.ExpiresAt),
+		CreatedAt:     dbJob.CreatedAt,
+		DownloadToken: dbJob.DownloadToken,
+	}
+}
@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.ReportScheduleRepository = (*ReportScheduleRepository)(nil)
+
+type ReportScheduleRepository struct {
+	store   *Store
+	queries *generated.Queries
+}
+
+func NewReportScheduleRepository(store *Store) *ReportScheduleRepository {
+	return &ReportScheduleRepository{
+		store:   store,
+		queries: generated.New(store.pool),
+	}
+}
+
+func (r *ReportScheduleRepository) CreateReportSchedule(ctx context.Context, schedule *repository.ReportSchedule) (*repository.ReportSchedule, error) {
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	nextRunAt, err := schedule.Next(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	dbSchedule, err := r.queries.CreateReportSchedule(ctx, generated.CreateReportScheduleParams{
+		Name:           schedule.Name,
+		CronExpression: schedule.CronExpression,
+		Timezone:       schedule.Timezone,
+		Kind:           schedule.Kind,
+		ReactorID:      uint32PtrToPgInt8(schedule.ReactorID),
+		DeviceIds:      uint32sToInt64s(schedule.DeviceIDs),
+		WindowSeconds:  schedule.WindowSeconds,
+		Recipients:     schedule.Recipients,
+		Enabled:        schedule.Enabled,
+		NextRunAt:      nextRunAt,
+		CreatedBy:      int64(schedule.CreatedBy),
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "reactor or user of the report schedule not found")
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create report schedule: %s", err.Error())
+	}
+
+	return mapDBReportScheduleToReportSchedule(dbSchedule), nil
+}
+
+func (r *ReportScheduleRepository) GetReportScheduleByID(ctx context.Context, id uint32) (*repository.ReportSchedule, error) {
+	dbSchedule, err := r.queries.GetReportScheduleByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "report schedule with id %d not found", id)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get report schedule: %s", err.Error())
+	}
+
+	return mapDBReportScheduleToReportSchedule(dbSchedule), nil
+}
+
+func (r *ReportScheduleRepository) ListReportSchedules(ctx context.Context) ([]*repository.ReportSchedule, error) {
+	dbSchedules, err := r.queries.ListReportSchedules(ctx)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list report schedules: %s", err.Error())
+	}
+
+	schedules := make([]*repository.ReportSchedule, 0, len(dbSchedules))
+	for _, dbSchedule := range dbSchedules {
+		schedules = append(schedules, mapDBReportScheduleToReportSchedule(dbSchedule))
+	}
+
+	return schedules, nil
+}
+
+// UpdateReportSchedule replaces the schedule and plans its next run from now,
+// so a changed cron expression takes effect straight away.
+func (r *ReportScheduleRepository) UpdateReportSchedule(ctx context.Context, schedule *repository.ReportSchedule) (*repository.ReportSchedule, error) {
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	nextRunAt, err := schedule.Next(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	dbSchedule, err := r.queries.UpdateReportSchedule(ctx, generated.UpdateReportScheduleParams{
+		Name:           schedule.Name,
+		CronExpression: schedule.CronExpression,
+		Timezone:       schedule.Timezone,
+		Kind:           schedule.Kind,
+		ReactorID:      uint32PtrToPgInt8(schedule.ReactorID),
+		DeviceIds:      uint32sToInt64s(schedule.DeviceIDs),
+		WindowSeconds:  schedule.WindowSeconds,
+		Recipients:     schedule.Recipients,
+		Enabled:        schedule.Enabled,
+		NextRunAt:      nextRunAt,
+		ID:             int64(schedule.ID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "report schedule with id %d not found", schedule.ID)
+		}
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "reactor with id %d not found", *schedule.ReactorID)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update report schedule: %s", err.Error())
+	}
+
+	return mapDBReportScheduleToReportSchedule(dbSchedule), nil
+}
+
+func (r *ReportScheduleRepository) DeleteReportSchedule(ctx context.Context, id uint32) error {
+	deleted, err := r.queries.DeleteReportSchedule(ctx, int64(id))
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete report schedule: %s", err.Error())
+	}
+
+	if deleted == 0 {
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "report schedule with id %d not found", id)
+	}
+
+	return nil
+}
+
+// ClaimDueReportSchedule locks a due schedule with SKIP LOCKED and moves its
+// next run forward in the same transaction. A server that was down for a
+// while runs a missed schedule once rather than once per missed run. A
+// schedule whose next run cannot be computed is disabled with the reason as
+// its last error, and the next due schedule is claimed instead.
+func (r *ReportScheduleRepository) ClaimDueReportSchedule(ctx context.Context, now time.Time) (*repository.ReportSchedule, error) {
+	var claimed *repository.ReportSchedule
+	err := r.store.ExecTx(ctx, func(q *generated.Queries) error {
+		for {
+			dbSchedule, err := q.LockDueReportSchedule(ctx, now)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil
+				}
+				return err
+			}
+
+			schedule := mapDBReportScheduleToReportSchedule(dbSchedule)
+			nextRunAt, err := schedule.Next(now)
+			if err != nil {
+				if err := q.DisableReportSchedule(ctx, generated.DisableReportScheduleParams{
+					LastError: stringToPgText("disabled: " + pkg.ErrorMessage(err)),
+					ID:        dbSchedule.ID,
+				}); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := q.AdvanceReportSchedule(ctx, generated.AdvanceReportScheduleParams{
+				NextRunAt: nextRunAt,
+				LastRunAt: pgtype.Timestamptz{Time: now, Valid: true},
+				ID:        dbSchedule.ID,
+			}); err != nil {
+				return err
+			}
+
+			claimed = schedule
+			return nil
+		}
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to claim report schedule: %s", err.Error())
+	}
+
+	return claimed, nil
+}
+
+func (r *ReportScheduleRepository) SetReportScheduleError(ctx context.Context, id uint32, message string) error {
+	err := r.queries.SetReportScheduleError(ctx, generated.SetReportScheduleErrorParams{
+		LastError: stringToPgText(message),
+		ID:        int64(id),
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to set report schedule error: %s", err.Error())
+	}
+
+	return nil
+}
+
+func uint32sToInt64s(values []uint32) []int64 {
+	converted := make([]int64, 0, len(values))
+	for _, value := range values {
+		converted = append(converted, int64(value))
+	}
+
+	return converted
+}
+
+func mapDBReportScheduleToReportSchedule(dbSchedule generated.ReportSchedule) *repository.ReportSchedule {
+	var reactorID *uint32
+	if dbSchedule.ReactorID.Valid {
+		id := uint32(dbSchedule.ReactorID.Int64)
+		reactorID = &id
+	}
+
+	deviceIDs := make([]uint32, 0, len(dbSchedule.DeviceIds))
+	for _, id := range dbSchedule.DeviceIds {
+		deviceIDs = append(deviceIDs, uint32(id))
+	}
+
+	return &repository.ReportSchedule{
+		ID:             uint32(dbSchedule.ID),
+		Name:           dbSchedule.Name,
+		CronExpression: dbSchedule.CronExpression,
+		Timezone:       dbSchedule.Timezone,
+		Kind:           dbSchedule.Kind,
+		ReactorID:      reactorID,
+		DeviceIDs:      deviceIDs,
+		WindowSeconds:  dbSchedule.WindowSeconds,
+		Recipients:     dbSchedule.Recipients,
+		Enabled:        dbSchedule.Enabled,
+		NextRunAt:      dbSchedule.NextRunAt,
+		LastRunAt:      pgTimestamptzToTimePtr(dbSchedule.LastRunAt),
+		LastError:      dbSchedule.LastError.String,
+		CreatedBy:      uint32(dbSchedule.CreatedBy),
+		CreatedAt:      dbSchedule.CreatedAt,
+		UpdatedAt:      dbSchedule.UpdatedAt,
+	}
+}
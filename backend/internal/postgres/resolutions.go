@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// readingRange is a part of a requested time range that is served from one
+// resolution.
+type readingRange struct {
+	resolution string
+	start      time.Time
+	end        time.Time
+}
+
+// readingRanges splits [start, end) by the device's retention policy: the
+// part older than the minute rollup retention comes from hourly rollups, the
+// part older than the raw retention from minute rollups and the rest from raw
+// readings. Ranges are returned oldest first.
+func (r *DeviceRepository) readingRanges(ctx context.Context, deviceID uint32, start, end time.Time) ([]readingRange, error) {
+	policy, err := r.queries.GetEffectiveRetentionPolicy(ctx, int64(deviceID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return []readingRange{{resolution: repository.ResolutionRaw, start: start, end: end}}, nil
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get retention policy: %s", err.Error())
+	}
+
+	now := time.Now().UTC()
+	ranges := []readingRange{}
+	from := start
+
+	addRange := func(resolution string, until time.Time) {
+		if until.After(end) {
+			until = end
+		}
+
+		if until.After(from) {
+			ranges = append(ranges, readingRange{resolution: resolution, start: from, end: until})
+			from = until
+		}
+	}
+
+	// cutoffs are aligned to the coarser tier so buckets do not straddle them
+	if policy.MinuteRollupRetentionDays.Valid {
+		addRange(repository.ResolutionHour, now.AddDate(0, 0, -int(policy.MinuteRollupRetentionDays.Int32)).Truncate(time.Hour))
+	}
+	if policy.RawRetentionDays.Valid {
+		addRange(repository.ResolutionMinute, now.AddDate(0, 0, -int(policy.RawRetentionDays.Int32)).Truncate(time.Minute))
+	}
+
+	if len(ranges) == 0 || end.After(from) {
+		ranges = append(ranges, readingRange{resolution: repository.ResolutionRaw, start: from, end: end})
+	}
+
+	return ranges, nil
+}
+
+// listRangeReadings loads the readings of every range in ascending time order.
+// Rollup buckets are returned as readings holding the average of each key.
+func (r *DeviceRepository) listRangeReadings(ctx context.Context, deviceID uint32, ranges []readingRange) ([]*repository.Reading, error) {
+	readings := []*repository.Reading{}
+
+	for _, rg := range ranges {
+		switch rg.resolution {
+		case repository.ResolutionRaw:
+			dbReadings, err := r.queries.GetReadingsByTimeRange(ctx, generated.GetReadingsByTimeRangeParams{
+				DeviceID:    int64(deviceID),
+				Timestamp:   rg.start,
+				Timestamp_2: rg.end,
+			})
+			if err != nil {
+				return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list readings by time range: %s", err.Error())
+			}
+
+			rawReadings, err := mapDBReadingsToReadings(dbReadings)
+			if err != nil {
+				return nil, err
+			}
+
+			readings = append(readings, rawReadings...)
+
+		case repository.ResolutionMinute:
+			rows, err := r.queries.ListMinuteRollupReadings(ctx, generated.ListMinuteRollupReadingsParams{
+				DeviceID:  int64(deviceID),
+				StartTime: rg.start,
+				EndTime:   rg.end,
+			})
+			if err != nil {
+				return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list minute rollups: %s", err.Error())
+			}
+
+			rollupReadings, err := mapRollupRowsToReadings(deviceID, rg.resolution, rows)
+			if err != nil {
+				return nil, err
+			}
+
+			readings = append(readings, rollupReadings...)
+
+		case repository.ResolutionHour:
+			rows, err := r.queries.ListHourRollupReadings(ctx, generated.ListHourRollupReadingsParams{
+				DeviceID:  int64(deviceID),
+				StartTime: rg.start,
+				EndTime:   rg.end,
+			})
+			if err != nil {
+				return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list hour rollups: %s", err.Error())
+			}
+
+			minuteRows := make([]generated.ListMinuteRollupReadingsRow, 0, len(rows))
+			for _, row := range rows {
+				minuteRows = append(minuteRows, generated.ListMinuteRollupReadingsRow(row))
+			}
+
+			rollupReadings, err := mapRollupRowsToReadings(deviceID, rg.resolution, minuteRows)
+			if err != nil {
+				return nil, err
+			}
+
+			readings = append(readings, rollupReadings...)
+		}
+	}
+
+	return readings, nil
+}
+
+func mapRollupRowsToReadings(deviceID uint32, resolution string, rows []generated.ListMinuteRollupReadingsRow) ([]*repository.Reading, error) {
+	readings := make([]*repository.Reading, 0, len(rows))
+	for _, row := range rows {
+		var payload any
+		if err := json.Unmarshal(row.Payload, &payload); err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to unmarshal rollup payload: %s", err.Error())
+		}
+
+		readings = append(readings, &repository.Reading{
+			DeviceID:   deviceID,
+			Payload:    payload,
+			Timestamp:  row.Bucket,
+			ReceivedAt: row.Bucket,
+			Resolution: resolution,
+		})
+	}
+
+	return readings, nil
+}
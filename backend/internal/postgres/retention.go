@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/postgres/generated"
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var _ repository.RetentionRepository = (*RetentionRepository)(nil)
+
+type RetentionRepository struct {
+	store   *Store
+	queries *generated.Queries
+}
+
+func NewRetentionRepository(store *Store) *RetentionRepository {
+	return &RetentionRepository{
+		store:   store,
+		queries: generated.New(store.pool),
+	}
+}
+
+func (r *RetentionRepository) ListRetentionPolicies(ctx context.Context) ([]*repository.RetentionPolicy, error) {
+	dbPolicies, err := r.queries.ListRetentionPolicies(ctx)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to list retention policies: %s", err.Error())
+	}
+
+	policies := make([]*repository.RetentionPolicy, 0, len(dbPolicies))
+	for _, dbPolicy := range dbPolicies {
+		policies = append(policies, mapDBRetentionPolicyToRetentionPolicy(dbPolicy))
+	}
+
+	return policies, nil
+}
+
+func (r *RetentionRepository) UpdateGlobalRetentionPolicy(ctx context.Context, policy *repository.RetentionPolicy) (*repository.RetentionPolicy, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	dbPolicy, err := r.queries.UpdateGlobalRetentionPolicy(ctx, generated.UpdateGlobalRetentionPolicyParams{
+		RawRetentionDays:          int32PtrToPgInt4(policy.RawRetentionDays),
+		MinuteRollupRetentionDays: int32PtrToPgInt4(policy.MinuteRollupRetentionDays),
+		HourRollupRetentionDays:   int32PtrToPgInt4(policy.HourRollupRetentionDays),
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to update global retention policy: %s", err.Error())
+	}
+
+	return mapDBRetentionPolicyToRetentionPolicy(dbPolicy), nil
+}
+
+func (r *RetentionRepository) UpsertDeviceRetentionPolicy(ctx context.Context, policy *repository.RetentionPolicy) (*repository.RetentionPolicy, error) {
+	if policy.DeviceID == nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "device id is required")
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	dbPolicy, err := r.queries.UpsertDeviceRetentionPolicy(ctx, generated.UpsertDeviceRetentionPolicyParams{
+		DeviceID:                  pgtype.Int8{Int64: int64(*policy.DeviceID), Valid: true},
+		RawRetentionDays:          int32PtrToPgInt4(policy.RawRetentionDays),
+		MinuteRollupRetentionDays: int32PtrToPgInt4(policy.MinuteRollupRetentionDays),
+		HourRollupRetentionDays:   int32PtrToPgInt4(policy.HourRollupRetentionDays),
+	})
+	if err != nil {
+		if pkg.PgxErrorCode(err) == pkg.FOREIGN_KEY_VIOLATION {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "device with id %d not found", *policy.DeviceID)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to save device retention policy: %s", err.Error())
+	}
+
+	return mapDBRetentionPolicyToRetentionPolicy(dbPolicy), nil
+}
+
+func (r *RetentionRepository) DeleteDeviceRetentionPolicy(ctx context.Context, deviceID uint32) error {
+	rows, err := r.queries.DeleteDeviceRetentionPolicy(ctx, pgtype.Int8{Int64: int64(deviceID), Valid: true})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete device retention policy: %s", err.Error())
+	}
+
+	if rows == 0 {
+		return pkg.Errorf(pkg.NOT_FOUND_ERROR, "device %d has no retention policy", deviceID)
+	}
+
+	return nil
+}
+
+// RollupReadings brings the minute rollups up to date with the raw readings
+// and then the hourly rollups up to date with the minute ones. Each tier
+// advances its watermark in steps of at most window, stopping lag before now
+// so transactions still in flight are not skipped.
+func (r *RetentionRepository) RollupReadings(ctx context.Context, lag, window time.Duration) error {
+	err := r.rollupTier(ctx, repository.ResolutionMinute, lag, window, func(q *generated.Queries, after, before time.Time) error {
+		_, err := q.RollupMinuteReadings(ctx, generated.RollupMinuteReadingsParams{
+			ReceivedAfter:  after,
+			ReceivedBefore: before,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.rollupTier(ctx, repository.ResolutionHour, lag, window, func(q *generated.Queries, after, before time.Time) error {
+		_, err := q.RollupHourReadings(ctx, generated.RollupHourReadingsParams{
+			UpdatedAfter:  after,
+			UpdatedBefore: before,
+		})
+		return err
+	})
+}
+
+func (r *RetentionRepository) rollupTier(ctx context.Context, tier string, lag, window time.Duration, rollup func(q *generated.Queries, after, before time.Time) error) error {
+	upTo := time.Now().Add(-lag)
+
+	for ctx.Err() == nil {
+		rolledUpTo, err := r.queries.GetRollupWatermark(ctx, tier)
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get %s rollup watermark: %s", tier, err.Error())
+		}
+
+		if !rolledUpTo.Before(upTo) {
+			return nil
+		}
+
+		before := rolledUpTo.Add(window)
+		if before.After(upTo) {
+			before = upTo
+		}
+
+		err = r.store.ExecTx(ctx, func(q *generated.Queries) error {
+			if err := rollup(q, rolledUpTo, before); err != nil {
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to roll up %s readings: %s", tier, err.Error())
+			}
+
+			if err := q.SetRollupWatermark(ctx, generated.SetRollupWatermarkParams{
+				RolledUpTo: before,
+				Tier:       tier,
+			}); err != nil {
+				return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to set %s rollup watermark: %s", tier, err.Error())
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// ApplyRetention deletes readings and rollups that are past their policy.
+// Raw readings and minute rollups are only deleted once the next tier has
+// been built from them.
+func (r *RetentionRepository) ApplyRetention(ctx context.Context, batchSize int) (*repository.RetentionResult, error) {
+	result := &repository.RetentionResult{}
+
+	minuteRolledUpTo, err := r.queries.GetRollupWatermark(ctx, repository.ResolutionMinute)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get minute rollup watermark: %s", err.Error())
+	}
+
+	// raw readings can be many, so they go in batches to keep each delete short
+	for ctx.Err() == nil {
+		deleted, err := r.queries.DeleteExpiredReadings(ctx, generated.DeleteExpiredReadingsParams{
+			RolledUpTo: minuteRolledUpTo,
+			BatchSize:  int32(batchSize),
+		})
+		if err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete expired readings: %s", err.Error())
+		}
+
+		result.Readings += deleted
+		if deleted < int64(batchSize) {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		deleted, err := r.queries.DeleteExpiredReadingMessageIDs(ctx, int32(batchSize))
+		if err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete expired reading message ids: %s", err.Error())
+		}
+
+		if deleted < int64(batchSize) {
+			break
+		}
+	}
+
+	hourRolledUpTo, err := r.queries.GetRollupWatermark(ctx, repository.ResolutionHour)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get hour rollup watermark: %s", err.Error())
+	}
+
+	result.MinuteRollups, err = r.queries.DeleteExpiredMinuteRollups(ctx, hourRolledUpTo)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete expired minute rollups: %s", err.Error())
+	}
+
+	result.HourRollups, err = r.queries.DeleteExpiredHourRollups(ctx)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete expired hour rollups: %s", err.Error())
+	}
+
+	return result, nil
+}
+
+func mapDBRetentionPolicyToRetentionPolicy(dbPolicy generated.RetentionPolicy) *repository.RetentionPolicy {
+	policy := &repository.RetentionPolicy{
+		ID:                        uint32(dbPolicy.ID),
+		RawRetentionDays:          pgInt4ToInt32Ptr(dbPolicy.RawRetentionDays),
+		MinuteRollupRetentionDays: pgInt4ToInt32Ptr(dbPolicy.MinuteRollupRetentionDays),
+		HourRollupRetentionDays:   pgInt4ToInt32Ptr(dbPolicy.HourRollupRetentionDays),
+		CreatedAt:                 dbPolicy.CreatedAt,
+		UpdatedAt:                 dbPolicy.UpdatedAt,
+	}
+
+	if dbPolicy.DeviceID.Valid {
+		deviceID := uint32(dbPolicy.DeviceID.Int64)
+		policy.DeviceID = &deviceID
+	}
+
+	return policy
+}
@@ -245,6 +245,9 @@ func (u *UserRepository) GetDashboardData(ctx context.Context, isAdmin bool) (*r
 	dashboardStats.TotalDevices = uint32(dbDeviceStats.TotalDevices)
 	dashboardStats.ActiveDevices = uint32(dbDeviceStats.ActiveDevices)
 	dashboardStats.InactiveDevices = uint32(dbDeviceStats.InactiveDevices)
+	dashboardStats.OnlineDevices = uint32(dbDeviceStats.OnlineDevices)
+	dashboardStats.StaleDevices = uint32(dbDeviceStats.StaleDevices)
+	dashboardStats.OfflineDevices = uint32(dbDeviceStats.OfflineDevices)
 
 	dbReactorStats, err := u.queries.CountActiveInactiveReactors(ctx)
 	if err != nil {
@@ -254,6 +257,12 @@ func (u *UserRepository) GetDashboardData(ctx context.Context, isAdmin bool) (*r
 	dashboardStats.ActiveReactors = uint32(dbReactorStats.ActiveReactors)
 	dashboardStats.InactiveReactors = uint32(dbReactorStats.InactiveReactors)
 
+	runningExperiments, err := u.queries.CountRunningExperiments(ctx)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get running experiments: %v", err)
+	}
+	dashboardStats.RunningExperiments = uint32(runningExperiments)
+
 	experimentsDoneToday, err := u.queries.CountExperimentsRunToday(ctx)
 	if err != nil {
 		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get experiments run today: %v", err)
@@ -0,0 +1,355 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// CompareExperiments lines up the parameters and analytical tests of the
+// experiments and resamples the curves of every device attached during each
+// run on a shared time since start axis. The axis spans the longest run in at
+// most maxCurvePoints buckets.
+func (r *ReportService) CompareExperiments(ctx context.Context, options services.ExperimentComparisonOptions) (*repository.ExperimentComparison, error) {
+	if len(options.ExperimentIDs) == 0 {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "at least one experiment is required")
+	}
+
+	experiments := make([]*repository.Experiment, 0, len(options.ExperimentIDs))
+	for _, id := range options.ExperimentIDs {
+		experiment, err := r.store.ExperimentRepository.GetExperimentByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, experiment)
+	}
+
+	curves, err := r.compareCurves(ctx, experiments, options.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.ExperimentComparison{
+		Experiments:     experiments,
+		Fields:          repository.CompareExperimentFields(experiments),
+		AnalyticalTests: repository.CompareAnalyticalTests(experiments),
+		Curves:          curves,
+	}, nil
+}
+
+func (r *ReportService) compareCurves(ctx context.Context, experiments []*repository.Experiment, keys []string) (*repository.ComparedCurves, error) {
+	starts := make([]time.Time, len(experiments))
+	durations := make([]time.Duration, len(experiments))
+	var longest time.Duration
+	for i, experiment := range experiments {
+		start, end, err := experiment.Window()
+		if err != nil {
+			return nil, err
+		}
+		starts[i], durations[i] = start, end.Sub(start)
+		longest = max(longest, durations[i])
+	}
+
+	step := max(longest/maxCurvePoints, time.Second)
+	buckets := func(d time.Duration) int {
+		return min(int((d+step-1)/step), maxCurvePoints)
+	}
+	total := buckets(longest)
+
+	curves := &repository.ComparedCurves{
+		StepSeconds: step.Seconds(),
+		Offsets:     make([]float64, total),
+		Series:      []*repository.ComparedCurve{},
+	}
+	for i := range curves.Offsets {
+		curves.Offsets[i] = (time.Duration(i)*step + step/2).Seconds()
+	}
+
+	var keySet map[string]bool
+	if len(keys) > 0 {
+		keySet = make(map[string]bool, len(keys))
+		for _, key := range keys {
+			keySet[key] = true
+		}
+	}
+
+	for i, experiment := range experiments {
+		devices, err := r.store.ExperimentRepository.ListExperimentDevices(ctx, experiment)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, stays := range groupExperimentDevices(devices) {
+			channels, err := r.store.DeviceRepository.ListDeviceChannels(ctx, stays[0].DeviceID)
+			if err != nil {
+				return nil, err
+			}
+
+			device := newDeviceCurves(starts[i], step, buckets(durations[i]), stays[0], channels)
+			device.keys = keySet
+			if err := r.streamExperimentDevice(ctx, stays, device.add); err != nil {
+				return nil, err
+			}
+
+			for _, curve := range device.ordered() {
+				series := &repository.ComparedCurve{
+					ExperimentID: experiment.ID,
+					DeviceID:     stays[0].DeviceID,
+					DeviceName:   stays[0].DeviceName,
+					Key:          curve.key,
+					Label:        curve.title,
+					Unit:         curve.unit,
+					Values:       make([]*float64, total),
+				}
+				for bucket := range curve.counts {
+					if value, ok := curve.value(bucket); ok {
+						series.Values[bucket] = &value
+					}
+				}
+				curves.Series = append(curves.Series, series)
+			}
+		}
+	}
+
+	return curves, nil
+}
+
+// GenerateExperimentComparisonReport writes a comparison as a workbook with
+// an overview of the runs, the parameters, the analytical tests and the
+// overlaid curves, charted per payload key.
+func (r *ReportService) GenerateExperimentComparisonReport(ctx context.Context, options services.ExperimentComparisonOptions) ([]byte, error) {
+	comparison, err := r.CompareExperiments(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &comparisonReport{
+		excelGenerator: newExcelGenerator(),
+		comparison:     comparison,
+	}
+
+	return report.generateExcel()
+}
+
+const (
+	comparisonExperimentsSheet = "Experiments"
+	comparisonParametersSheet  = "Parameters"
+	comparisonTestsSheet       = "Analytical Tests"
+	comparisonCurvesSheet      = "Curves"
+)
+
+// comparisonReport writes one sheet per part of an experiment comparison.
+type comparisonReport struct {
+	*excelGenerator
+	comparison *repository.ExperimentComparison
+}
+
+func (r *comparisonReport) generateExcel() ([]byte, error) {
+	// the default sheet becomes the overview so it opens first
+	if err := r.file.SetSheetName("Sheet1", comparisonExperimentsSheet); err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error naming the experiments sheet: %v", err)
+	}
+
+	for _, write := range []func() error{r.writeExperimentsSheet, r.writeParametersSheet, r.writeTestsSheet, r.writeCurvesSheet} {
+		if err := write(); err != nil {
+			return nil, err
+		}
+	}
+
+	buffer, err := r.file.WriteToBuffer()
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error writing to buffer excel: %s", err)
+	}
+
+	if err := r.closeExcel(); err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error closing excel file: %v", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// experimentLabel names an experiment in column headers.
+func experimentLabel(experiment *repository.Experiment) string {
+	return fmt.Sprintf("%s / %s", experiment.BatchID, experiment.BlockID)
+}
+
+func (r *comparisonReport) experimentColumns(before []string, after ...string) []string {
+	columns := append([]string{}, before...)
+	for _, experiment := range r.comparison.Experiments {
+		columns = append(columns, experimentLabel(experiment))
+	}
+
+	return append(columns, after...)
+}
+
+func (r *comparisonReport) writeExperimentsSheet() error {
+	r.useSheet(comparisonExperimentsSheet)
+
+	headerColumns := []string{"ID", "Batch ID", "Block ID", "Reactor ID", "Operator", "Status", "Start", "End", "Duration (min)"}
+	if err := r.setColumns(len(headerColumns), 20); err != nil {
+		return err
+	}
+	if err := r.setColStyle("G:H", r.createDateStyle()); err != nil {
+		return err
+	}
+	if err := r.writeHeader(headerColumns, r.createHeaderStyle()); err != nil {
+		return err
+	}
+
+	for i, experiment := range r.comparison.Experiments {
+		rowData := []interface{}{experiment.ID, experiment.BatchID, experiment.BlockID, experiment.ReactorID, experiment.Operator, experiment.Status}
+		if start, end, err := experiment.Window(); err == nil {
+			rowData = append(rowData, start, end, end.Sub(start).Minutes())
+		}
+		if err := r.writeRow(i+2, rowData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeParametersSheet writes quantities in the base unit of their parameter
+// so the cells can be compared directly; other values are written as entered.
+func (r *comparisonReport) writeParametersSheet() error {
+	if err := r.createSheet(comparisonParametersSheet); err != nil {
+		return err
+	}
+
+	headerColumns := r.experimentColumns([]string{"Section", "Parameter", "Base unit"}, "Differs")
+	if err := r.setColumns(len(headerColumns), 20); err != nil {
+		return err
+	}
+	if err := r.writeHeader(headerColumns, r.createHeaderStyle()); err != nil {
+		return err
+	}
+
+	for i, field := range r.comparison.Fields {
+		rowData := []interface{}{field.Section, field.Field, field.BaseUnit}
+		for j, value := range field.Values {
+			if field.BaseValues != nil && field.BaseValues[j] != nil {
+				rowData = append(rowData, *field.BaseValues[j])
+			} else {
+				rowData = append(rowData, value)
+			}
+		}
+		if err := r.writeRow(i+2, append(rowData, yesNo(field.Differs))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *comparisonReport) writeTestsSheet() error {
+	if err := r.createSheet(comparisonTestsSheet); err != nil {
+		return err
+	}
+
+	headerColumns := r.experimentColumns([]string{"Test"}, "Differs")
+	if err := r.setColumns(len(headerColumns), 30); err != nil {
+		return err
+	}
+	if err := r.writeHeader(headerColumns, r.createHeaderStyle()); err != nil {
+		return err
+	}
+
+	for i, test := range r.comparison.AnalyticalTests {
+		rowData := []interface{}{test.Name}
+		for _, samples := range test.Tests {
+			described := make([]string, 0, len(samples))
+			for _, sample := range samples {
+				described = append(described, fmt.Sprintf("%s (%s)", sample.SampleID, sample.Date.Format("2006-01-02")))
+			}
+			rowData = append(rowData, strings.Join(described, ", "))
+		}
+		if err := r.writeRow(i+2, append(rowData, yesNo(test.Differs))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCurvesSheet writes one column per curve against the minutes since the
+// start of each run, with a chart per payload key overlaying the runs.
+// Empty buckets are left blank so the charts show gaps.
+func (r *comparisonReport) writeCurvesSheet() error {
+	if err := r.createSheet(comparisonCurvesSheet); err != nil {
+		return err
+	}
+	curves := r.comparison.Curves
+
+	experiments := make(map[uint32]*repository.Experiment, len(r.comparison.Experiments))
+	for _, experiment := range r.comparison.Experiments {
+		experiments[experiment.ID] = experiment
+	}
+
+	headerColumns := []string{"Minutes since start"}
+	keys := []string{}
+	seriesByKey := map[string][]lineSeries{}
+	for i, series := range curves.Series {
+		name := fmt.Sprintf("%s - %s - %s", experimentLabel(experiments[series.ExperimentID]), series.DeviceName, series.Label)
+		headerColumns = append(headerColumns, name)
+
+		if _, ok := seriesByKey[series.Key]; !ok {
+			keys = append(keys, series.Key)
+		}
+		seriesByKey[series.Key] = append(seriesByKey[series.Key], lineSeries{name: name, column: i + 2})
+	}
+
+	if err := r.setColumns(len(headerColumns), 20); err != nil {
+		return err
+	}
+	if err := r.writeHeader(headerColumns, r.createHeaderStyle()); err != nil {
+		return err
+	}
+
+	for i, offset := range curves.Offsets {
+		row := i + 2
+		if err := r.writeRow(row, []interface{}{offset / 60}); err != nil {
+			return err
+		}
+		for j, series := range curves.Series {
+			if value := series.Values[i]; value != nil {
+				if err := r.writeCell(j+2, row, *value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(curves.Offsets) == 0 {
+		return nil
+	}
+
+	// leave one empty column between the data and the charts
+	chartColumn := len(headerColumns) + 2
+	for i, key := range keys {
+		chart := &lineChart{
+			title:   key,
+			yTitle:  curves.Series[seriesByKey[key][0].column-2].Unit,
+			xFormat: "0",
+			lastRow: len(curves.Offsets) + 1,
+			series:  seriesByKey[key],
+		}
+		if err := r.addLineChart(chartColumn, 2+i*chartRowSpan, chart); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -2,10 +2,16 @@ package reports
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/Edwin9301/Zen/backend/pkg"
 	"github.com/xuri/excelize/v2"
 )
 
+// maxSheetRows is the number of rows an Excel sheet holds, header included.
+const maxSheetRows = excelize.TotalRows
+
 type excelGenerator struct {
 	file         *excelize.File
 	currentSheet string
@@ -17,8 +23,17 @@ func newExcelGenerator() *excelGenerator {
 	}
 }
 
-func (e *excelGenerator) createSheet(name string) {
-	e.file.NewSheet(name)
+func (e *excelGenerator) createSheet(name string) error {
+	if _, err := e.file.NewSheet(name); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error creating sheet %s: %v", name, err)
+	}
+	e.currentSheet = name
+
+	return nil
+}
+
+// useSheet makes an existing sheet the target of later writes.
+func (e *excelGenerator) useSheet(name string) {
 	e.currentSheet = name
 }
 
@@ -26,21 +41,175 @@ func (e *excelGenerator) closeExcel() error {
 	return e.file.Close()
 }
 
-func (e *excelGenerator) writeHeader(columns []string, styleID int) {
-	end, _ := excelize.CoordinatesToCellName(len(columns), 1) // lastHeader1
-	e.file.SetCellStyle(e.currentSheet, "A1", end, styleID)
+// setColumns sets the width of columns A to the last of count columns.
+func (e *excelGenerator) setColumns(count int, width float64) error {
+	lastColumn, err := excelize.ColumnNumberToName(count)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error sizing the columns of %s: %v", e.currentSheet, err)
+	}
 
-	for i, col := range columns {
-		cell := fmt.Sprintf("%s1", string(rune(65+i)))
-		e.file.SetCellValue(e.currentSheet, cell, col)
+	if err := e.file.SetColWidth(e.currentSheet, "A", lastColumn, width); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error sizing the columns of %s: %v", e.currentSheet, err)
 	}
+
+	return nil
 }
 
-func (e *excelGenerator) writeRow(row int, data []interface{}) {
-	for i, val := range data {
-		cell := fmt.Sprintf("%s%d", string(rune(65+i)), row)
-		e.file.SetCellValue(e.currentSheet, cell, val)
+// setColStyle styles whole columns, such as "A" or "G:H".
+func (e *excelGenerator) setColStyle(columns string, styleID int) error {
+	if err := e.file.SetColStyle(e.currentSheet, columns, styleID); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error styling columns %s of %s: %v", columns, e.currentSheet, err)
 	}
+
+	return nil
+}
+
+func (e *excelGenerator) writeHeader(columns []string, styleID int) error {
+	end, err := excelize.CoordinatesToCellName(len(columns), 1) // lastHeader1
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error writing the header of %s: %v", e.currentSheet, err)
+	}
+
+	if err := e.file.SetCellStyle(e.currentSheet, "A1", end, styleID); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error styling the header of %s: %v", e.currentSheet, err)
+	}
+
+	if err := e.file.SetSheetRow(e.currentSheet, "A1", &columns); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error writing the header of %s: %v", e.currentSheet, err)
+	}
+
+	return nil
+}
+
+// writeRow writes data from column A onwards. Cell names come from excelize
+// so columns past Z become AA, AB and so on. Rows past maxSheetRows are
+// rejected.
+func (e *excelGenerator) writeRow(row int, data []interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(1, row)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error writing row %d of %s: %v", row, e.currentSheet, err)
+	}
+
+	if err := e.file.SetSheetRow(e.currentSheet, cell, &data); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error writing row %d of %s: %v", row, e.currentSheet, err)
+	}
+
+	return nil
+}
+
+// excelEpoch is day zero of Excel serial dates.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// excelTime converts t to an Excel serial date at its wall clock time, as
+// excelize does. Writing the number to a cell styled with a date format
+// avoids excelize adding a cell style for every time.Time it writes, which
+// runs into the style limit of Excel on large sheets.
+func excelTime(t time.Time) float64 {
+	_, offset := t.Zone()
+	seconds := t.Unix() + int64(offset) - excelEpoch.Unix()
+
+	return (float64(seconds) + float64(t.Nanosecond())/1e9) / 86400
+}
+
+// writeCell writes a single value at a column and row, both from 1.
+func (e *excelGenerator) writeCell(column, row int, value interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(column, row)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error writing to %s: %v", e.currentSheet, err)
+	}
+
+	if err := e.file.SetCellValue(e.currentSheet, cell, value); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error writing %s of %s: %v", cell, e.currentSheet, err)
+	}
+
+	return nil
+}
+
+// lineSeries is one plotted column of a line chart.
+type lineSeries struct {
+	name   string
+	column int
+}
+
+// lineChart plots columns of the current sheet against column A, from row 2
+// to lastRow. Column A holds timestamps unless xFormat gives another number
+// format for it.
+type lineChart struct {
+	title   string
+	yTitle  string
+	xFormat string
+	lastRow int
+	series  []lineSeries
+}
+
+// addLineChart places a native Excel line chart with its top left corner at
+// a column and row, both from 1. The series reference the sheet data, so the
+// chart follows any edits made to the values after download.
+func (e *excelGenerator) addLineChart(column, row int, chart *lineChart) error {
+	cell, err := excelize.CoordinatesToCellName(column, row)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error placing chart %s on %s: %v", chart.title, e.currentSheet, err)
+	}
+
+	sheet := "'" + strings.ReplaceAll(e.currentSheet, "'", "''") + "'"
+	columnRange := func(column int) (string, error) {
+		name, err := excelize.ColumnNumberToName(column)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s!$%s$2:$%s$%d", sheet, name, name, chart.lastRow), nil
+	}
+
+	categories, err := columnRange(1)
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error adding chart %s to %s: %v", chart.title, e.currentSheet, err)
+	}
+
+	series := make([]excelize.ChartSeries, 0, len(chart.series))
+	for _, s := range chart.series {
+		header, err := excelize.CoordinatesToCellName(s.column, 1, true)
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error adding chart %s to %s: %v", chart.title, e.currentSheet, err)
+		}
+		values, err := columnRange(s.column)
+		if err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "error adding chart %s to %s: %v", chart.title, e.currentSheet, err)
+		}
+
+		series = append(series, excelize.ChartSeries{
+			Name:       sheet + "!" + header,
+			Categories: categories,
+			Values:     values,
+			Line:       excelize.ChartLine{Width: 1.25},
+			Marker:     excelize.ChartMarker{Symbol: "none"},
+		})
+	}
+
+	xFormat := chart.xFormat
+	if xFormat == "" {
+		xFormat = "yyyy-mm-dd hh:mm"
+	}
+
+	err = e.file.AddChart(e.currentSheet, cell, &excelize.Chart{
+		Type:      excelize.Line,
+		Series:    series,
+		Title:     []excelize.RichTextRun{{Text: chart.title}},
+		Dimension: excelize.ChartDimension{Width: 720, Height: 320},
+		Legend:    excelize.ChartLegend{Position: "bottom"},
+		XAxis: excelize.ChartAxis{
+			NumFmt: excelize.ChartNumFmt{CustomNumFmt: xFormat},
+		},
+		YAxis: excelize.ChartAxis{
+			MajorGridLines: true,
+			Title:          []excelize.RichTextRun{{Text: chart.yTitle}},
+		},
+		ShowBlanksAs: "gap",
+	})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error adding chart %s to %s: %v", chart.title, e.currentSheet, err)
+	}
+
+	return nil
 }
 
 func (e *excelGenerator) createHeaderStyle() int {
@@ -90,6 +259,16 @@ func (e *excelGenerator) createDateStyle() int {
 	return dateStyle
 }
 
+func (e *excelGenerator) createDateTimeStyle() int {
+	dateTimeStyle, _ := e.file.NewStyle(&excelize.Style{
+		NumFmt: 22,
+		Alignment: &excelize.Alignment{
+			Horizontal: "right",
+		},
+	})
+	return dateTimeStyle
+}
+
 func (e *excelGenerator) createPercentageStyle() int {
 	percentageStyle, _ := e.file.NewStyle(&excelize.Style{
 		NumFmt: 9,
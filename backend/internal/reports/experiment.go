@@ -0,0 +1,289 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+)
+
+// maxCurvePoints bounds the points drawn per sensor curve. Readings are
+// averaged into this many buckets across the run window.
+const maxCurvePoints = 600
+
+// experimentReport lays out the partner PDF of one experiment: metadata,
+// feedstock, exposure conditions, analytical tests and sensor curves.
+type experimentReport struct {
+	*pdfGenerator
+	experiment *repository.Experiment
+	reactor    *repository.Reactor
+	files      map[uint32]*repository.File // analytical test reports by ID
+	start      time.Time
+	end        time.Time
+}
+
+func newExperimentReport(experiment *repository.Experiment, reactor *repository.Reactor) (*experimentReport, error) {
+	start, end, err := experiment.Window()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &experimentReport{
+		pdfGenerator: newPDFGenerator(),
+		experiment:   experiment,
+		reactor:      reactor,
+		files:        map[uint32]*repository.File{},
+		start:        start,
+		end:          end,
+	}
+	r.setFooter(fmt.Sprintf("Experiment %s / %s", experiment.BatchID, experiment.BlockID))
+	r.pdf.AddPage()
+
+	return r, nil
+}
+
+func (r *experimentReport) writeMetadata() {
+	e := r.experiment
+
+	r.writeTitle(
+		fmt.Sprintf("Experiment Report - Batch %s", e.BatchID),
+		fmt.Sprintf("Generated %s", time.Now().UTC().Format("2006-01-02 15:04 MST")),
+	)
+
+	r.writeSection("Batch / Block")
+	r.writeFields([][2]string{
+		{"Batch ID", e.BatchID},
+		{"Block ID", e.BlockID},
+		{"Reactor", r.reactor.Name},
+		{"Pathway", r.reactor.Pathway},
+		{"Operator", e.Operator},
+		{"Date", e.Date.Format("2006-01-02")},
+		{"Planned window", fmt.Sprintf("%s - %s UTC", e.TimeStart, e.TimeEnd)},
+		{"Status", e.Status},
+		{"Run window", fmt.Sprintf("%s - %s", r.start.Format("2006-01-02 15:04 MST"), r.end.Format("2006-01-02 15:04 MST"))},
+	})
+
+	// migrated text that is not a quantity is shown as it was entered
+	quantity := func(name string, q *repository.Quantity) string {
+		if q == nil {
+			return e.LegacyParameters[name]
+		}
+		return q.String()
+	}
+
+	m := e.MaterialFeedstock
+	r.writeSection("Material Feedstock")
+	r.writeFields([][2]string{
+		{"Mix design", m.MixDesign},
+		{"Cement", quantity("cement", m.Cement)},
+		{"Fine aggregate", quantity("fineAggregate", m.FineAggregate)},
+		{"Coarse aggregate", quantity("coarseAggregate", m.CoarseAggregate)},
+		{"Water", quantity("water", m.Water)},
+		{"Water/cement ratio", quantity("waterCementRatio", m.WaterCementRatio)},
+		{"Block size (L x W x H)", fmt.Sprintf("%s x %s x %s", quantity("blockSizeLength", m.BlockSizeLength), quantity("blockSizeWidth", m.BlockSizeWidth), quantity("blockSizeHeight", m.BlockSizeHeight))},
+	})
+
+	c := e.ExposureConditions
+	r.writeSection("Exposure Conditions")
+	r.writeFields([][2]string{
+		{"CO2 form", c.Co2Form},
+		{"CO2 mass", quantity("co2Mass", c.Co2Mass)},
+		{"Injection pressure", quantity("injectionPressure", c.InjectionPressure)},
+		{"Head space", quantity("headSpace", c.HeadSpace)},
+		{"Reaction time", quantity("reactionTime", c.ReactionTime)},
+	})
+
+	r.writeSection("Analytical Tests")
+	if len(e.AnalyticalTests) == 0 {
+		r.writeText("No analytical tests recorded.")
+		return
+	}
+
+	rows := make([][]string, 0, len(e.AnalyticalTests))
+	links := make([]string, 0, len(e.AnalyticalTests))
+	for _, test := range e.AnalyticalTests {
+		// uploaded reports need a session to download, so only their name is
+		// shown; legacy links are kept clickable
+		report, link := "", ""
+		switch {
+		case test.FileID != nil && r.files[*test.FileID] != nil:
+			report = r.files[*test.FileID].FileName
+		case test.PdfUrl != "":
+			report, link = "Open report", test.PdfUrl
+		}
+		rows = append(rows, []string{test.Name, test.SampleID, test.Date.Format("2006-01-02"), report})
+		links = append(links, link)
+	}
+	r.writeTable([]string{"Test", "Sample ID", "Date", "Report"}, []float64{4, 3, 2, 2}, rows, links)
+}
+
+func (r *experimentReport) writeRunStats(stats *repository.ExperimentRunStats) {
+	r.writeSection("Run Statistics")
+	if len(stats.Devices) == 0 {
+		r.writeText("No devices were attached to this reactor during the run.")
+		return
+	}
+
+	value := func(value *float64, channel *repository.ChannelRunStats) string {
+		if value == nil {
+			return "-"
+		}
+		return strings.TrimSpace(fmt.Sprintf("%.2f %s", *value, channel.Unit))
+	}
+
+	rows := make([][]string, 0, len(stats.Devices))
+	for _, device := range stats.Devices {
+		rows = append(rows, []string{
+			device.DeviceName,
+			fmt.Sprintf("%d", device.Readings),
+			value(device.Co2Uptake, device.Co2),
+			value(device.PeakPressure, device.Pressure),
+			value(device.TemperatureRange, device.Temperature),
+		})
+	}
+	r.writeTable([]string{"Device", "Readings", "CO2 uptake", "Peak pressure", "Temperature range"}, []float64{3, 2, 2, 2, 2}, rows, nil)
+}
+
+// sensorCurve averages the values of one channel into fixed time buckets
+// over the run window, so memory does not grow with the number of readings.
+type sensorCurve struct {
+	key    string
+	title  string
+	unit   string
+	sums   []float64
+	counts []int
+}
+
+// value is the average of a bucket, or false when it holds no readings.
+func (c *sensorCurve) value(bucket int) (float64, bool) {
+	if c.counts[bucket] == 0 {
+		return 0, false
+	}
+
+	return c.sums[bucket] / float64(c.counts[bucket]), true
+}
+
+// deviceCurves collects the curves of one device in buckets of width from
+// start. Declared channels keep their order, other numeric payload keys follow
+// alphabetically. When keys is set only those payload keys are collected.
+type deviceCurves struct {
+	start    time.Time
+	width    time.Duration
+	buckets  int
+	keys     map[string]bool
+	device   *repository.ExperimentDevice
+	channels []*repository.DeviceChannel
+	curves   map[string]*sensorCurve
+}
+
+func newDeviceCurves(start time.Time, width time.Duration, buckets int, device *repository.ExperimentDevice, channels []*repository.DeviceChannel) *deviceCurves {
+	return &deviceCurves{
+		start:    start,
+		width:    width,
+		buckets:  buckets,
+		device:   device,
+		channels: channels,
+		curves:   map[string]*sensorCurve{},
+	}
+}
+
+func (r *experimentReport) newDeviceCurves(device *repository.ExperimentDevice, channels []*repository.DeviceChannel) *deviceCurves {
+	return newDeviceCurves(r.start, r.bucketWidth(), maxCurvePoints, device, channels)
+}
+
+func (r *experimentReport) bucketWidth() time.Duration {
+	return r.end.Sub(r.start) / maxCurvePoints
+}
+
+func (d *deviceCurves) add(reading *repository.Reading) error {
+	payload, ok := reading.Payload.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	offset := reading.Timestamp.Sub(d.start)
+	if offset < 0 {
+		return nil
+	}
+	bucket := int(offset / d.width)
+	if bucket >= d.buckets {
+		return nil
+	}
+
+	for key, raw := range payload {
+		value, ok := raw.(float64)
+		if !ok || repository.ReadingMetadataKeys[key] || (d.keys != nil && !d.keys[key]) {
+			continue
+		}
+
+		curve, ok := d.curves[key]
+		if !ok {
+			curve = &sensorCurve{
+				key:    key,
+				title:  key,
+				sums:   make([]float64, d.buckets),
+				counts: make([]int, d.buckets),
+			}
+			d.curves[key] = curve
+		}
+
+		curve.sums[bucket] += value
+		curve.counts[bucket]++
+	}
+
+	return nil
+}
+
+func (d *deviceCurves) ordered() []*sensorCurve {
+	ordered := make([]*sensorCurve, 0, len(d.curves))
+	seen := map[string]bool{}
+	for _, channel := range d.channels {
+		if curve, ok := d.curves[channel.Name]; ok {
+			curve.title = channel.Label()
+			curve.unit = channel.Unit
+			ordered = append(ordered, curve)
+			seen[channel.Name] = true
+		}
+	}
+
+	keys := make([]string, 0, len(d.curves))
+	for key := range d.curves {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ordered = append(ordered, d.curves[key])
+	}
+
+	return ordered
+}
+
+func (r *experimentReport) writeDeviceCurves(d *deviceCurves) {
+	curves := d.ordered()
+	if len(curves) == 0 {
+		r.writeFields([][2]string{{d.device.DeviceName, "no numeric readings in the run window"}})
+		return
+	}
+
+	width := d.width
+	for _, curve := range curves {
+		points := make([]chartPoint, 0, d.buckets)
+		for i := range curve.counts {
+			value, ok := curve.value(i)
+			if !ok {
+				continue
+			}
+			points = append(points, chartPoint{
+				at:    r.start.Add(time.Duration(i)*width + width/2),
+				value: value,
+			})
+		}
+
+		// lines are broken where more than two buckets in a row are empty
+		r.writeLineChart(fmt.Sprintf("%s - %s", d.device.DeviceName, curve.title), points, r.start, r.end, 3*width)
+	}
+}
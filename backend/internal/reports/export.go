@@ -0,0 +1,244 @@
+package reports
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// exportFlushEvery is how many rows are buffered before they are pushed to
+// the client.
+const exportFlushEvery = 500
+
+// flusher is implemented by http response writers that support chunked
+// responses.
+type flusher interface {
+	Flush()
+}
+
+// ExportReadings streams a device's readings to w as CSV or NDJSON, one row
+// at a time, so the export size is not bounded by memory.
+func (r *ReportService) ExportReadings(ctx context.Context, w io.Writer, options services.ReadingExportOptions) error {
+	columns := options.Columns
+	if len(columns) == 0 {
+		channels, err := r.store.DeviceRepository.ListDeviceChannels(ctx, options.DeviceID)
+		if err != nil {
+			return err
+		}
+
+		for _, channel := range channels {
+			columns = append(columns, channel.Name)
+		}
+	}
+
+	location := options.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	var encoder readingEncoder
+	switch options.Format {
+	case services.ExportFormatCSV:
+		encoder = newCSVEncoder(w, columns, location)
+	case services.ExportFormatNDJSON:
+		encoder = newNDJSONEncoder(w, columns, location)
+	default:
+		return pkg.Errorf(pkg.INVALID_ERROR, "unsupported export format %q", options.Format)
+	}
+
+	filter := &repository.ReadingFilter{
+		DeviceID: options.DeviceID,
+		Start:    &options.Start,
+		End:      &options.End,
+	}
+
+	rows := 0
+	err := r.store.DeviceRepository.StreamReadingsByTimeRange(ctx, filter, func(reading *repository.Reading) error {
+		if err := encoder.encode(reading); err != nil {
+			return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to write reading: %s", err.Error())
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			return flush(w, encoder)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush(w, encoder)
+}
+
+func flush(w io.Writer, encoder readingEncoder) error {
+	if err := encoder.flush(); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to write readings: %s", err.Error())
+	}
+
+	if f, ok := w.(flusher); ok {
+		f.Flush()
+	}
+
+	return nil
+}
+
+type readingEncoder interface {
+	encode(reading *repository.Reading) error
+	flush() error
+}
+
+// csvEncoder writes a header of timestamp plus the columns, then one line
+// per reading. Without columns the keys of the first reading are used.
+type csvEncoder struct {
+	writer        *csv.Writer
+	columns       []string
+	location      *time.Location
+	headerWritten bool
+}
+
+func newCSVEncoder(w io.Writer, columns []string, location *time.Location) *csvEncoder {
+	return &csvEncoder{
+		writer:   csv.NewWriter(w),
+		columns:  columns,
+		location: location,
+	}
+}
+
+func (e *csvEncoder) encode(reading *repository.Reading) error {
+	values := flattenPayload(reading.Payload)
+
+	if !e.headerWritten {
+		if len(e.columns) == 0 {
+			for key := range values {
+				e.columns = append(e.columns, key)
+			}
+			sort.Strings(e.columns)
+		}
+
+		if err := e.writer.Write(append([]string{"timestamp"}, e.columns...)); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+
+	record := make([]string, 0, len(e.columns)+1)
+	record = append(record, reading.Timestamp.In(e.location).Format(time.RFC3339Nano))
+	for _, column := range e.columns {
+		record = append(record, formatExportValue(values[column]))
+	}
+
+	return e.writer.Write(record)
+}
+
+func (e *csvEncoder) flush() error {
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
+
+// ndjsonEncoder writes one JSON object per line with the timestamp and the
+// flattened payload, limited to the columns when they are set.
+type ndjsonEncoder struct {
+	writer   *bufio.Writer
+	encoder  *json.Encoder
+	columns  []string
+	location *time.Location
+}
+
+func newNDJSONEncoder(w io.Writer, columns []string, location *time.Location) *ndjsonEncoder {
+	writer := bufio.NewWriter(w)
+
+	return &ndjsonEncoder{
+		writer:   writer,
+		encoder:  json.NewEncoder(writer),
+		columns:  columns,
+		location: location,
+	}
+}
+
+func (e *ndjsonEncoder) encode(reading *repository.Reading) error {
+	values := flattenPayload(reading.Payload)
+	if len(e.columns) > 0 {
+		selected := make(map[string]any, len(e.columns))
+		for _, column := range e.columns {
+			selected[column] = values[column]
+		}
+		values = selected
+	}
+
+	line := map[string]any{
+		"timestamp": reading.Timestamp.In(e.location).Format(time.RFC3339Nano),
+		"device_id": reading.DeviceID,
+		"values":    values,
+	}
+	if reading.Resolution != "" {
+		line["resolution"] = reading.Resolution
+	}
+
+	return e.encoder.Encode(line)
+}
+
+func (e *ndjsonEncoder) flush() error {
+	return e.writer.Flush()
+}
+
+// flattenPayload turns nested objects and arrays into dotted keys, so
+// {"probe": {"temp": 21}} becomes {"probe.temp": 21}.
+func flattenPayload(payload any) map[string]any {
+	values := map[string]any{}
+	flattenInto(values, "", payload)
+
+	return values
+}
+
+func flattenInto(values map[string]any, prefix string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			flattenInto(values, joinKey(prefix, key), nested)
+		}
+	case []any:
+		for i, nested := range v {
+			flattenInto(values, joinKey(prefix, strconv.Itoa(i)), nested)
+		}
+	default:
+		if prefix != "" {
+			values[prefix] = v
+		}
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+func formatExportValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,63 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+const (
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	pdfContentType  = "application/pdf"
+)
+
+// RunReportJob builds the file a job asks for from its stored params.
+func (r *ReportService) RunReportJob(ctx context.Context, job *repository.ReportJob, progress func(percent int32)) (*repository.ReportJobFile, error) {
+	switch job.Kind {
+	case repository.ReportJobKindReadingsXLSX:
+		var options services.ReadingReportOptions
+		if err := json.Unmarshal(job.Params, &options); err != nil {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid report job params: %v", err)
+		}
+
+		// the last few percent are left for writing the workbook out
+		options.Progress = func(done, total int) {
+			progress(int32(done * 95 / total))
+		}
+
+		data, err := r.GenerateReadingsReport(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return &repository.ReportJobFile{
+			FileName:    fmt.Sprintf("readings_report%s-%s.xlsx", options.Start.Format("2006-01-02"), options.End.Format("2006-01-02")),
+			ContentType: xlsxContentType,
+			Data:        data,
+		}, nil
+
+	case repository.ReportJobKindExperimentPDF:
+		var options services.ExperimentReportOptions
+		if err := json.Unmarshal(job.Params, &options); err != nil {
+			return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid report job params: %v", err)
+		}
+
+		data, err := r.GenerateExperimentReport(ctx, options.ExperimentID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &repository.ReportJobFile{
+			FileName:    fmt.Sprintf("experiment_%d_report.pdf", options.ExperimentID),
+			ContentType: pdfContentType,
+			Data:        data,
+		}, nil
+
+	default:
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "unknown report job kind %q", job.Kind)
+	}
+}
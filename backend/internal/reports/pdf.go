@@ -0,0 +1,240 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pdfFont       = "Helvetica"
+	pdfLineHeight = 6.0
+	pdfChartH     = 60.0
+)
+
+type pdfGenerator struct {
+	pdf *gofpdf.Fpdf
+	// tr converts UTF-8 text to the cp1252 encoding of the core fonts.
+	tr func(string) string
+}
+
+func newPDFGenerator() *pdfGenerator {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+
+	return &pdfGenerator{
+		pdf: pdf,
+		tr:  pdf.UnicodeTranslatorFromDescriptor(""),
+	}
+}
+
+// contentWidth is the page width between the margins.
+func (p *pdfGenerator) contentWidth() float64 {
+	pageWidth, _ := p.pdf.GetPageSize()
+	left, _, right, _ := p.pdf.GetMargins()
+	return pageWidth - left - right
+}
+
+// ensureSpace starts a new page when fewer than height millimetres are left
+// and reports whether it did.
+func (p *pdfGenerator) ensureSpace(height float64) bool {
+	_, pageHeight := p.pdf.GetPageSize()
+	_, _, _, bottom := p.pdf.GetMargins()
+	if p.pdf.GetY()+height > pageHeight-bottom {
+		p.pdf.AddPage()
+		return true
+	}
+
+	return false
+}
+
+func (p *pdfGenerator) writeTitle(title, subtitle string) {
+	p.pdf.SetFont(pdfFont, "B", 18)
+	p.pdf.CellFormat(0, 10, p.tr(title), "", 1, "L", false, 0, "")
+	p.pdf.SetFont(pdfFont, "", 10)
+	p.pdf.SetTextColor(100, 100, 100)
+	p.pdf.CellFormat(0, pdfLineHeight, p.tr(subtitle), "", 1, "L", false, 0, "")
+	p.pdf.SetTextColor(0, 0, 0)
+	p.pdf.Ln(4)
+}
+
+func (p *pdfGenerator) writeSection(title string) {
+	p.ensureSpace(3 * pdfLineHeight)
+	p.pdf.Ln(2)
+	p.pdf.SetFont(pdfFont, "B", 13)
+	p.pdf.SetFillColor(0, 148, 183)
+	p.pdf.SetTextColor(255, 255, 255)
+	p.pdf.CellFormat(0, 8, p.tr(title), "", 1, "L", true, 0, "")
+	p.pdf.SetTextColor(0, 0, 0)
+	p.pdf.Ln(2)
+}
+
+// writeFields writes label/value pairs as a two column table. Empty values
+// are shown as a dash so missing metadata stands out.
+func (p *pdfGenerator) writeFields(fields [][2]string) {
+	labelWidth := 60.0
+	valueWidth := p.contentWidth() - labelWidth
+
+	for _, field := range fields {
+		value := field[1]
+		if value == "" {
+			value = "-"
+		}
+
+		p.ensureSpace(pdfLineHeight)
+		p.pdf.SetFont(pdfFont, "B", 10)
+		p.pdf.CellFormat(labelWidth, pdfLineHeight, p.tr(field[0]), "B", 0, "L", false, 0, "")
+		p.pdf.SetFont(pdfFont, "", 10)
+		p.pdf.CellFormat(valueWidth, pdfLineHeight, p.tr(value), "B", 1, "L", false, 0, "")
+	}
+}
+
+// writeTable writes a header row and the rows below it. Columns share the
+// content width according to widths, which are relative. links holds an
+// optional URL per row for the last column.
+func (p *pdfGenerator) writeTable(columns []string, widths []float64, rows [][]string, links []string) {
+	total := 0.0
+	for _, width := range widths {
+		total += width
+	}
+	scale := p.contentWidth() / total
+
+	header := func() {
+		p.pdf.SetFont(pdfFont, "B", 10)
+		p.pdf.SetFillColor(223, 242, 255)
+		for i, column := range columns {
+			p.pdf.CellFormat(widths[i]*scale, 7, p.tr(column), "1", 0, "L", true, 0, "")
+		}
+		p.pdf.Ln(-1)
+	}
+
+	p.ensureSpace(2 * 7)
+	header()
+
+	p.pdf.SetFont(pdfFont, "", 9)
+	for r, row := range rows {
+		if p.ensureSpace(7) {
+			header()
+			p.pdf.SetFont(pdfFont, "", 9)
+		}
+
+		for i, value := range row {
+			link := ""
+			if i == len(row)-1 && r < len(links) {
+				link = links[r]
+			}
+			if link != "" {
+				p.pdf.SetTextColor(0, 0, 238)
+			}
+			p.pdf.CellFormat(widths[i]*scale, 7, p.tr(value), "1", 0, "L", false, 0, link)
+			p.pdf.SetTextColor(0, 0, 0)
+		}
+		p.pdf.Ln(-1)
+	}
+}
+
+func (p *pdfGenerator) writeText(text string) {
+	p.pdf.SetFont(pdfFont, "I", 10)
+	p.pdf.SetTextColor(100, 100, 100)
+	p.pdf.MultiCell(0, pdfLineHeight, p.tr(text), "", "L", false)
+	p.pdf.SetTextColor(0, 0, 0)
+}
+
+// chartPoint is one sample of a plotted curve.
+type chartPoint struct {
+	at    time.Time
+	value float64
+}
+
+// writeLineChart draws points over the window from start to end with
+// labelled axes. Gaps of more than gapAfter between points break the line.
+func (p *pdfGenerator) writeLineChart(title string, points []chartPoint, start, end time.Time, gapAfter time.Duration) {
+	p.ensureSpace(pdfChartH + 12)
+
+	p.pdf.SetFont(pdfFont, "B", 10)
+	p.pdf.CellFormat(0, pdfLineHeight, p.tr(title), "", 1, "L", false, 0, "")
+
+	left, _, _, _ := p.pdf.GetMargins()
+	axisWidth := 16.0
+	x0, y0 := left+axisWidth, p.pdf.GetY()+2
+	width, height := p.contentWidth()-axisWidth, pdfChartH-10
+
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, point := range points {
+		minValue = math.Min(minValue, point.value)
+		maxValue = math.Max(maxValue, point.value)
+	}
+	if minValue == maxValue {
+		minValue, maxValue = minValue-1, maxValue+1
+	}
+	padding := (maxValue - minValue) * 0.05
+	minValue, maxValue = minValue-padding, maxValue+padding
+
+	span := end.Sub(start).Seconds()
+	xOf := func(at time.Time) float64 { return x0 + width*at.Sub(start).Seconds()/span }
+	yOf := func(value float64) float64 { return y0 + height - height*(value-minValue)/(maxValue-minValue) }
+
+	// grid and axis labels
+	p.pdf.SetFont(pdfFont, "", 7)
+	p.pdf.SetDrawColor(220, 220, 220)
+	p.pdf.SetLineWidth(0.1)
+	const ticks = 5
+	for i := 0; i <= ticks; i++ {
+		value := minValue + (maxValue-minValue)*float64(i)/ticks
+		y := yOf(value)
+		p.pdf.Line(x0, y, x0+width, y)
+		p.pdf.SetXY(left, y-2)
+		p.pdf.CellFormat(axisWidth-1, 4, fmt.Sprintf("%.4g", value), "", 0, "R", false, 0, "")
+
+		at := start.Add(time.Duration(float64(end.Sub(start)) * float64(i) / ticks))
+		x := xOf(at)
+		p.pdf.Line(x, y0, x, y0+height)
+		p.pdf.SetXY(x-10, y0+height+1)
+		p.pdf.CellFormat(20, 4, at.Format("15:04"), "", 0, "C", false, 0, "")
+	}
+
+	p.pdf.SetDrawColor(0, 0, 0)
+	p.pdf.Rect(x0, y0, width, height, "D")
+
+	p.pdf.SetDrawColor(0, 148, 183)
+	p.pdf.SetLineWidth(0.3)
+	for i := 1; i < len(points); i++ {
+		previous, current := points[i-1], points[i]
+		if current.at.Sub(previous.at) > gapAfter {
+			continue
+		}
+		p.pdf.Line(xOf(previous.at), yOf(previous.value), xOf(current.at), yOf(current.value))
+	}
+
+	p.pdf.SetDrawColor(0, 0, 0)
+	p.pdf.SetLineWidth(0.2)
+	p.pdf.SetXY(left, y0+height+6)
+	p.pdf.Ln(2)
+}
+
+// setFooter adds text and the page number to the bottom of every page. It
+// must be called before the first page is added.
+func (p *pdfGenerator) setFooter(text string) {
+	p.pdf.SetFooterFunc(func() {
+		p.pdf.SetY(-12)
+		p.pdf.SetFont(pdfFont, "I", 8)
+		p.pdf.SetTextColor(100, 100, 100)
+		p.pdf.CellFormat(p.contentWidth()/2, 8, p.tr(text), "", 0, "L", false, 0, "")
+		p.pdf.CellFormat(0, 8, fmt.Sprintf("Page %d of {nb}", p.pdf.PageNo()), "", 0, "R", false, 0, "")
+		p.pdf.SetTextColor(0, 0, 0)
+	})
+}
+
+func (p *pdfGenerator) generatePDF() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := p.pdf.Output(&buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -1,47 +1,267 @@
 package reports
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
 	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
 	"github.com/Edwin9301/Zen/backend/pkg"
 )
 
+const (
+	summarySheet = "Summary"
+
+	// maxSheetNameLength is the longest sheet name Excel accepts.
+	maxSheetNameLength = 31
+
+	// chartRowSpan is the number of rows left for each stacked chart.
+	chartRowSpan = 18
+)
+
+// readingReport writes one sheet of readings per device and a summary sheet
+// with the min, max and average of every numeric channel. When charts is set
+// the selected channels are also plotted on each device sheet.
 type readingReport struct {
 	*excelGenerator
-	data []*repository.Reading
+	summaries []*channelSummary
+	charts    *services.ReadingChartOptions
+}
+
+// channelSummary accumulates the numeric values of one payload key.
+type channelSummary struct {
+	device string
+	key    string
+	unit   string
+	count  int
+	min    float64
+	max    float64
+	sum    float64
+}
+
+func (c *channelSummary) add(value float64) {
+	if c.count == 0 {
+		c.min, c.max = value, value
+	}
+
+	c.count++
+	c.min = math.Min(c.min, value)
+	c.max = math.Max(c.max, value)
+	c.sum += value
 }
 
-func newReadingReport(data []*repository.Reading) *readingReport {
+func newReadingReport(charts *services.ReadingChartOptions) *readingReport {
 	return &readingReport{
 		excelGenerator: newExcelGenerator(),
-		data:           data,
+		charts:         charts,
 	}
 }
 
-func (r *readingReport) generateExcel(sheetName string) ([]byte, error) {
-	r.createSheet(sheetName)
+// deviceSheet holds what is needed to write the readings of one device.
+type deviceSheet struct {
+	device   *repository.Device
+	channels []*repository.DeviceChannel
+	readings func(fn func(reading *repository.Reading) error) error
+}
+
+// columns returns the payload keys to write and their header labels. Declared
+// channels give a stable order and units, otherwise the keys of the first
+// reading other than metadata are used in alphabetical order.
+func (s *deviceSheet) columns(first *repository.Reading) ([]string, []string) {
+	if len(s.channels) > 0 {
+		keys := make([]string, 0, len(s.channels))
+		labels := make([]string, 0, len(s.channels))
+		for _, channel := range s.channels {
+			keys = append(keys, channel.Name)
+			labels = append(labels, channel.Label())
+		}
+
+		return keys, labels
+	}
+
+	keys := []string{}
+	if first != nil {
+		if reading, ok := first.Payload.(map[string]interface{}); ok {
+			for key := range reading {
+				if !repository.ReadingMetadataKeys[key] {
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, keys
+}
 
-	columns := []string{}
-	reading := r.data[0].Payload.(map[string]interface{})
-	for key, _ := range reading {
-		columns = append(columns, key)
+// writeDeviceSheet writes the readings of a device. A device with more
+// readings than a sheet holds continues on further sheets, each with its own
+// header and charts.
+func (r *readingReport) writeDeviceSheet(sheet *deviceSheet) error {
+	units := map[string]string{}
+	for _, channel := range sheet.channels {
+		units[channel.Name] = channel.Unit
 	}
 
-	headerColumns := append([]string{"Timestamp"}, columns...)
+	var (
+		columns   []string
+		labels    []string
+		summaries map[string]*channelSummary
+		part      = 0
+		row       = 1
+	)
+	// startSheet opens the next sheet of the device. The columns are taken
+	// from the first reading and kept on continuation sheets.
+	startSheet := func(first *repository.Reading) error {
+		if part == 0 {
+			columns, labels = sheet.columns(first)
+
+			summaries = make(map[string]*channelSummary, len(columns))
+			for _, column := range columns {
+				summary := &channelSummary{device: sheet.device.Name, key: column, unit: units[column]}
+				summaries[column] = summary
+				r.summaries = append(r.summaries, summary)
+			}
+		}
+		part++
+		row = 1
+
+		if err := r.createSheet(deviceSheetName(sheet.device, part)); err != nil {
+			return err
+		}
+
+		headerColumns := append([]string{"Timestamp"}, labels...)
+		if err := r.setColumns(len(headerColumns), 20); err != nil {
+			return err
+		}
+		if err := r.setColStyle("A", r.createDateTimeStyle()); err != nil {
+			return err
+		}
 
-	r.file.SetColWidth(r.currentSheet, "A", string(rune(65+len(headerColumns)-1)), 20)
-	r.file.SetColStyle(r.currentSheet, "A", r.createDateStyle())
+		return r.writeHeader(headerColumns, r.createHeaderStyle())
+	}
 
-	r.writeHeader(headerColumns, r.createHeaderStyle())
+	err := sheet.readings(func(record *repository.Reading) error {
+		if part == 0 {
+			if err := startSheet(record); err != nil {
+				return err
+			}
+		} else if row == maxSheetRows {
+			if err := r.writeDeviceCharts(sheet.device, columns, labels, units, row); err != nil {
+				return err
+			}
+			if err := startSheet(record); err != nil {
+				return err
+			}
+		}
+		row++
 
-	for i, record := range r.data {
 		rowData := []interface{}{
-			record.Timestamp,
+			excelTime(record.Timestamp),
 		}
-		payload := record.Payload.(map[string]interface{})
+		payload, _ := record.Payload.(map[string]interface{})
 		for _, col := range columns {
 			rowData = append(rowData, payload[col])
+			if value, ok := payload[col].(float64); ok {
+				summaries[col].add(value)
+			}
+		}
+
+		return r.writeRow(row, rowData)
+	})
+	if err != nil {
+		return err
+	}
+
+	if part == 0 {
+		return startSheet(nil)
+	}
+
+	return r.writeDeviceCharts(sheet.device, columns, labels, units, row)
+}
+
+// writeDeviceCharts plots the selected channels found on the current sheet.
+// Channels the device does not report are skipped. Charts go to the right of
+// the data, stacked when each channel has its own axis.
+func (r *readingReport) writeDeviceCharts(device *repository.Device, columns, labels []string, units map[string]string, lastRow int) error {
+	if r.charts == nil || len(r.charts.Channels) == 0 {
+		return nil
+	}
+
+	positions := make(map[string]int, len(columns))
+	for i, column := range columns {
+		positions[column] = i
+	}
+
+	series := make([]lineSeries, 0, len(r.charts.Channels))
+	for _, channel := range r.charts.Channels {
+		if i, ok := positions[channel]; ok {
+			series = append(series, lineSeries{name: labels[i], column: i + 2})
+		}
+	}
+	if len(series) == 0 {
+		return nil
+	}
+
+	// leave one empty column between the data and the charts
+	chartColumn := len(columns) + 3
+
+	charts := []*lineChart{}
+	if r.charts.SharedAxis {
+		charts = append(charts, &lineChart{title: device.Name, lastRow: lastRow, series: series})
+	} else {
+		for _, s := range series {
+			charts = append(charts, &lineChart{
+				title:   fmt.Sprintf("%s - %s", device.Name, s.name),
+				yTitle:  units[columns[s.column-2]],
+				lastRow: lastRow,
+				series:  []lineSeries{s},
+			})
+		}
+	}
+
+	for i, chart := range charts {
+		if err := r.addLineChart(chartColumn, 2+i*chartRowSpan, chart); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *readingReport) writeSummarySheet() error {
+	// the default sheet becomes the summary so it opens first
+	if err := r.file.SetSheetName("Sheet1", summarySheet); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "error naming the summary sheet: %v", err)
+	}
+	r.useSheet(summarySheet)
+
+	headerColumns := []string{"Device", "Channel", "Unit", "Count", "Min", "Max", "Average"}
+	if err := r.setColumns(len(headerColumns), 20); err != nil {
+		return err
+	}
+	if err := r.writeHeader(headerColumns, r.createHeaderStyle()); err != nil {
+		return err
+	}
+
+	for i, summary := range r.summaries {
+		rowData := []interface{}{summary.device, summary.key, summary.unit, summary.count}
+		if summary.count > 0 {
+			rowData = append(rowData, summary.min, summary.max, summary.sum/float64(summary.count))
 		}
-		r.writeRow(i+2, rowData)
+		if err := r.writeRow(i+2, rowData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *readingReport) generateExcel() ([]byte, error) {
+	if err := r.writeSummarySheet(); err != nil {
+		return nil, err
 	}
 
 	buffer, err := r.file.WriteToBuffer()
@@ -54,10 +274,29 @@ func (r *readingReport) generateExcel(sheetName string) ([]byte, error) {
 	}
 
 	return buffer.Bytes(), err
+}
 
-	// if err := r.file.SaveAs("readings_report.xlsx"); err != nil {
-	// 	return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to generate Excel report")
-	// }
+// deviceSheetName builds a sheet name Excel accepts: no []:*?/\ and at most
+// 31 characters. The device id keeps names unique and continuation sheets
+// end with their part number, such as "7 Reactor A (2)".
+func deviceSheetName(device *repository.Device, part int) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, device.Name)
+
+	prefix := fmt.Sprintf("%d ", device.ID)
+	suffix := ""
+	if part > 1 {
+		suffix = fmt.Sprintf(" (%d)", part)
+	}
+
+	nameRunes := []rune(name)
+	if maxName := maxSheetNameLength - len(prefix) - len(suffix); len(nameRunes) > maxName {
+		nameRunes = nameRunes[:maxName]
+	}
 
-	// return nil, r.closeExcel()
+	return prefix + string(nameRunes) + suffix
 }
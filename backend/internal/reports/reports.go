@@ -2,11 +2,11 @@ package reports
 
 import (
 	"context"
-	"time"
 
 	"github.com/Edwin9301/Zen/backend/internal/postgres"
 	"github.com/Edwin9301/Zen/backend/internal/repository"
 	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
 )
 
 var _ services.ReportService = (*ReportService)(nil)
@@ -21,19 +21,155 @@ func NewReportService(store *postgres.PostgresRepo) *ReportService {
 	}
 }
 
-func (r *ReportService) GenerateReadingsReport(ctx context.Context, deviceID uint32, startDate, endDate time.Time) ([]byte, error) {
-	filter := &repository.ReadingFilter{
-		DeviceID: deviceID,
-		Start:    &startDate,
-		End:      &endDate,
+// GenerateReadingsReport builds a workbook with one sheet per device and a
+// summary sheet, optionally with line charts of the selected channels.
+// Readings are streamed into the sheets rather than loaded up front.
+func (r *ReportService) GenerateReadingsReport(ctx context.Context, options services.ReadingReportOptions) ([]byte, error) {
+	devices, err := r.reportDevices(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newReadingReport(options.Charts)
+	for i, device := range devices {
+		channels, err := r.store.DeviceRepository.ListDeviceChannels(ctx, device.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		filter := &repository.ReadingFilter{
+			DeviceID: device.ID,
+			Start:    &options.Start,
+			End:      &options.End,
+		}
+
+		err = report.writeDeviceSheet(&deviceSheet{
+			device:   device,
+			channels: channels,
+			readings: func(fn func(reading *repository.Reading) error) error {
+				return r.store.DeviceRepository.StreamReadingsByTimeRange(ctx, filter, fn)
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if options.Progress != nil {
+			options.Progress(i+1, len(devices))
+		}
+	}
+
+	return report.generateExcel()
+}
+
+// GenerateExperimentReport builds the partner PDF of an experiment with the
+// run statistics and curves of every device attached to its reactor during
+// the run window.
+func (r *ReportService) GenerateExperimentReport(ctx context.Context, experimentID uint32) ([]byte, error) {
+	experiment, err := r.store.ExperimentRepository.GetExperimentByID(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	reactor, err := r.store.ReactorRepository.GetReactorByID(ctx, experiment.ReactorID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := newExperimentReport(experiment, reactor)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, test := range experiment.AnalyticalTests {
+		if test.FileID == nil {
+			continue
+		}
+		file, err := r.store.FileRepository.GetFileByID(ctx, *test.FileID)
+		if err != nil {
+			return nil, err
+		}
+		report.files[file.ID] = file
+	}
+	report.writeMetadata()
+
+	stats, err := r.ExperimentRunStats(ctx, experimentID, false)
+	if err != nil {
+		return nil, err
 	}
+	report.writeRunStats(stats)
 
-	readings, err := r.store.DeviceRepository.ListReadingByTimeRange(ctx, filter)
+	devices, err := r.store.ExperimentRepository.ListExperimentDevices(ctx, experiment)
 	if err != nil {
 		return nil, err
 	}
 
-	generator := newReadingReport(readings)
+	report.writeSection("Sensor Curves")
+	if len(devices) == 0 {
+		report.writeText("No devices were attached to this reactor during the run.")
+	}
+
+	for _, stays := range groupExperimentDevices(devices) {
+		channels, err := r.store.DeviceRepository.ListDeviceChannels(ctx, stays[0].DeviceID)
+		if err != nil {
+			return nil, err
+		}
+
+		curves := report.newDeviceCurves(stays[0], channels)
+		if err := r.streamExperimentDevice(ctx, stays, curves.add); err != nil {
+			return nil, err
+		}
+
+		report.writeDeviceCurves(curves)
+	}
+
+	data, err := report.generatePDF()
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "error writing experiment report: %v", err)
+	}
+
+	return data, nil
+}
+
+// reportDevices resolves the devices of a report from the reactor or the
+// device ids, in that order of preference.
+func (r *ReportService) reportDevices(ctx context.Context, options services.ReadingReportOptions) ([]*repository.Device, error) {
+	if options.ReactorID != nil {
+		if _, err := r.store.ReactorRepository.GetReactorByID(ctx, *options.ReactorID); err != nil {
+			return nil, err
+		}
+
+		devices, err := r.store.DeviceRepository.ListDevicesByReactor(ctx, *options.ReactorID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(devices) == 0 {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "reactor %d has no devices", *options.ReactorID)
+		}
+
+		return devices, nil
+	}
+
+	if len(options.DeviceIDs) == 0 {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "a reactor or at least one device is required")
+	}
+
+	devices := make([]*repository.Device, 0, len(options.DeviceIDs))
+	seen := make(map[uint32]bool, len(options.DeviceIDs))
+	for _, deviceID := range options.DeviceIDs {
+		if seen[deviceID] {
+			continue
+		}
+		seen[deviceID] = true
+
+		device, err := r.store.DeviceRepository.GetDeviceByID(ctx, deviceID)
+		if err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, device)
+	}
 
-	return generator.generateExcel("Sheet1")
+	return devices, nil
 }
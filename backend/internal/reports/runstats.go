@@ -0,0 +1,238 @@
+package reports
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+)
+
+// ExperimentRunStats returns the run statistics of an experiment. Stored
+// statistics are returned as they are unless refresh is set; statistics of an
+// experiment that has not been stopped yet are computed on every call and not
+// stored.
+func (r *ReportService) ExperimentRunStats(ctx context.Context, experimentID uint32, refresh bool) (*repository.ExperimentRunStats, error) {
+	experiment, err := r.store.ExperimentRepository.GetExperimentByID(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if experiment.RunStats != nil && !refresh {
+		return experiment.RunStats, nil
+	}
+
+	start, end, err := experiment.Window()
+	if err != nil {
+		return nil, err
+	}
+
+	stays, err := r.store.ExperimentRepository.ListExperimentDevices(ctx, experiment)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &repository.ExperimentRunStats{
+		Start:   start,
+		End:     end,
+		Devices: []*repository.DeviceRunStats{},
+	}
+
+	for _, device := range groupExperimentDevices(stays) {
+		channels, err := r.store.DeviceRepository.ListDeviceChannels(ctx, device[0].DeviceID)
+		if err != nil {
+			return nil, err
+		}
+
+		accumulator := newRunStats(device[0], channels)
+		if err := r.streamExperimentDevice(ctx, device, accumulator.add); err != nil {
+			return nil, err
+		}
+
+		stats.Devices = append(stats.Devices, accumulator.result())
+	}
+	stats.ComputedAt = time.Now().UTC()
+
+	if experiment.Finished() {
+		if err := r.store.ExperimentRepository.SetExperimentRunStats(ctx, experimentID, stats); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+// groupExperimentDevices groups the stays of each device together, keeping
+// the order of the device ids.
+func groupExperimentDevices(stays []*repository.ExperimentDevice) [][]*repository.ExperimentDevice {
+	groups := [][]*repository.ExperimentDevice{}
+	for _, stay := range stays {
+		last := len(groups) - 1
+		if last >= 0 && groups[last][0].DeviceID == stay.DeviceID {
+			groups[last] = append(groups[last], stay)
+			continue
+		}
+		groups = append(groups, []*repository.ExperimentDevice{stay})
+	}
+
+	return groups
+}
+
+// streamExperimentDevice streams the readings of one device over each of its
+// stays on the reactor of an experiment.
+func (r *ReportService) streamExperimentDevice(ctx context.Context, stays []*repository.ExperimentDevice, fn func(reading *repository.Reading) error) error {
+	for _, stay := range stays {
+		filter := &repository.ReadingFilter{
+			DeviceID: stay.DeviceID,
+			Start:    &stay.Start,
+			End:      &stay.End,
+		}
+		if err := r.store.DeviceRepository.StreamReadingsByTimeRange(ctx, filter, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// channelRunStats accumulates the statistics of one payload key.
+type channelRunStats struct {
+	stats   repository.ChannelRunStats
+	sum     float64
+	firstAt time.Time
+	lastAt  time.Time
+}
+
+func (c *channelRunStats) add(at time.Time, value float64) {
+	s := &c.stats
+	if s.Samples == 0 || at.Before(c.firstAt) {
+		s.First, c.firstAt = value, at
+	}
+	if s.Samples == 0 || !at.Before(c.lastAt) {
+		s.Last, c.lastAt = value, at
+	}
+	if s.Samples == 0 || value < s.Min {
+		s.Min = value
+	}
+	if s.Samples == 0 || value > s.Max {
+		s.Max, s.MaxAt = value, at
+	}
+
+	s.Samples++
+	c.sum += value
+}
+
+// runStats accumulates the run statistics of one device over all numeric
+// payload keys; the CO2, pressure and temperature keys are picked at the end.
+type runStats struct {
+	device   *repository.ExperimentDevice
+	channels []*repository.DeviceChannel
+	readings int64
+	keys     map[string]*channelRunStats
+}
+
+func newRunStats(device *repository.ExperimentDevice, channels []*repository.DeviceChannel) *runStats {
+	return &runStats{
+		device:   device,
+		channels: channels,
+		keys:     map[string]*channelRunStats{},
+	}
+}
+
+func (r *runStats) add(reading *repository.Reading) error {
+	r.readings++
+
+	payload, ok := reading.Payload.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for key, raw := range payload {
+		value, ok := raw.(float64)
+		if !ok || repository.ReadingMetadataKeys[key] {
+			continue
+		}
+
+		channel, ok := r.keys[key]
+		if !ok {
+			channel = &channelRunStats{}
+			channel.stats.Key = key
+			r.keys[key] = channel
+		}
+		channel.add(reading.Timestamp, value)
+	}
+
+	return nil
+}
+
+// orderedKeys lists the keys seen in declared channel order, followed by the
+// undeclared ones alphabetically.
+func (r *runStats) orderedKeys() []string {
+	ordered := make([]string, 0, len(r.keys))
+	seen := map[string]bool{}
+	for _, channel := range r.channels {
+		if _, ok := r.keys[channel.Name]; ok {
+			ordered = append(ordered, channel.Name)
+			seen[channel.Name] = true
+		}
+	}
+
+	rest := []string{}
+	for key := range r.keys {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(ordered, rest...)
+}
+
+func (r *runStats) result() *repository.DeviceRunStats {
+	result := &repository.DeviceRunStats{
+		DeviceID:   r.device.DeviceID,
+		DeviceName: r.device.DeviceName,
+		Readings:   r.readings,
+	}
+
+	units := map[string]string{}
+	for _, channel := range r.channels {
+		units[channel.Name] = channel.Unit
+	}
+
+	pick := func(key string) *repository.ChannelRunStats {
+		channel := r.keys[key]
+		stats := channel.stats
+		stats.Unit = units[key]
+		stats.Mean = channel.sum / float64(stats.Samples)
+		return &stats
+	}
+
+	for _, key := range r.orderedKeys() {
+		name := strings.ToLower(key)
+		switch {
+		case result.Co2 == nil && strings.Contains(name, "co2"):
+			result.Co2 = pick(key)
+		case result.Pressure == nil && strings.Contains(name, "pressure"):
+			result.Pressure = pick(key)
+		case result.Temperature == nil && strings.Contains(name, "temp"):
+			result.Temperature = pick(key)
+		}
+	}
+
+	if result.Co2 != nil {
+		uptake := result.Co2.First - result.Co2.Last
+		result.Co2Uptake = &uptake
+	}
+	if result.Pressure != nil {
+		peak := result.Pressure.Max
+		result.PeakPressure = &peak
+	}
+	if result.Temperature != nil {
+		spread := result.Temperature.Max - result.Temperature.Min
+		result.TemperatureRange = &spread
+	}
+
+	return result
+}
@@ -0,0 +1,93 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+const csvContentType = "text/csv"
+
+// RenderScheduledReport builds the attachments of one run of a schedule: a
+// workbook for the whole scope, or one CSV file per device.
+func (r *ReportService) RenderScheduledReport(ctx context.Context, schedule *repository.ReportSchedule, start, end time.Time) ([]*repository.ReportJobFile, error) {
+	options := services.ReadingReportOptions{
+		ReactorID: schedule.ReactorID,
+		DeviceIDs: schedule.DeviceIDs,
+		Start:     start,
+		End:       end,
+	}
+
+	// an empty scope covers every device
+	if options.ReactorID == nil && len(options.DeviceIDs) == 0 {
+		devices, err := r.store.DeviceRepository.ListDevice(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, device := range devices {
+			options.DeviceIDs = append(options.DeviceIDs, device.ID)
+		}
+
+		if len(options.DeviceIDs) == 0 {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "there are no devices to report on")
+		}
+	}
+
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "unknown timezone %q", schedule.Timezone)
+	}
+	period := fmt.Sprintf("%s_%s", start.In(location).Format("2006-01-02T1504"), end.In(location).Format("2006-01-02T1504"))
+
+	switch schedule.Kind {
+	case repository.ReportScheduleKindReadingsXLSX:
+		data, err := r.GenerateReadingsReport(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*repository.ReportJobFile{{
+			FileName:    fmt.Sprintf("readings_report_%s.xlsx", period),
+			ContentType: xlsxContentType,
+			Data:        data,
+		}}, nil
+
+	case repository.ReportScheduleKindReadingsCSV:
+		devices, err := r.reportDevices(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+
+		files := make([]*repository.ReportJobFile, 0, len(devices))
+		for _, device := range devices {
+			var buffer bytes.Buffer
+			err := r.ExportReadings(ctx, &buffer, services.ReadingExportOptions{
+				DeviceID: device.ID,
+				Start:    start,
+				End:      end,
+				Format:   services.ExportFormatCSV,
+				Location: location,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, &repository.ReportJobFile{
+				FileName:    fmt.Sprintf("%s_%s.csv", deviceSheetName(device, 1), period),
+				ContentType: csvContentType,
+				Data:        buffer.Bytes(),
+			})
+		}
+
+		return files, nil
+
+	default:
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "unknown report schedule kind %q", schedule.Kind)
+	}
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// ALERT RULES
+// An alert rule watches one payload key of a device. A reading breaches the
+// rule when "value <comparator> threshold" holds; an incident is opened once
+// the breach has lasted for the rule duration.
+type AlertRule struct {
+	ID              uint32     `json:"id"`
+	DeviceID        uint32     `json:"deviceId"`
+	Name            string     `json:"name"`
+	Key             string     `json:"key"`
+	Comparator      string     `json:"comparator"`
+	Threshold       float64    `json:"threshold"`
+	DurationSeconds int32      `json:"durationSeconds"`
+	Severity        string     `json:"severity"`
+	NotifyEmails    []string   `json:"notifyEmails"`
+	Enabled         bool       `json:"enabled"`
+	BreachStartedAt *time.Time `json:"breachStartedAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+const (
+	ComparatorGreaterThan      = "gt"
+	ComparatorGreaterThanEqual = "gte"
+	ComparatorLessThan         = "lt"
+	ComparatorLessThanEqual    = "lte"
+	ComparatorEqual            = "eq"
+	ComparatorNotEqual         = "neq"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Breached reports whether value is out of range for the rule.
+func (r *AlertRule) Breached(value float64) bool {
+	switch r.Comparator {
+	case ComparatorGreaterThan:
+		return value > r.Threshold
+	case ComparatorGreaterThanEqual:
+		return value >= r.Threshold
+	case ComparatorLessThan:
+		return value < r.Threshold
+	case ComparatorLessThanEqual:
+		return value <= r.Threshold
+	case ComparatorEqual:
+		return value == r.Threshold
+	case ComparatorNotEqual:
+		return value != r.Threshold
+	default:
+		return false
+	}
+}
+
+// ALERT INCIDENTS
+type AlertIncident struct {
+	ID             uint32     `json:"id"`
+	RuleID         uint32     `json:"ruleId"`
+	DeviceID       uint32     `json:"deviceId"`
+	Severity       string     `json:"severity"`
+	Status         string     `json:"status"`
+	TriggerValue   float64    `json:"triggerValue"`
+	OpenedAt       time.Time  `json:"openedAt"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt"`
+	AcknowledgedBy *uint32    `json:"acknowledgedBy"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+}
+
+const (
+	IncidentStatusOpen         = "open"
+	IncidentStatusAcknowledged = "acknowledged"
+	IncidentStatusResolved     = "resolved"
+)
+
+type FilterAlertIncidents struct {
+	Pagination *pkg.Pagination
+	Status     *string
+	DeviceID   *uint32
+}
+
+type AlertRepository interface {
+	CreateAlertRule(ctx context.Context, rule *AlertRule) (*AlertRule, error)
+	GetAlertRuleByID(ctx context.Context, id uint32) (*AlertRule, error)
+	ListAlertRules(ctx context.Context, deviceID *uint32) ([]*AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule *AlertRule) (*AlertRule, error)
+	DeleteAlertRule(ctx context.Context, id uint32) error
+
+	ListAlertIncidents(ctx context.Context, filter *FilterAlertIncidents) ([]*AlertIncident, *pkg.Pagination, error)
+	GetAlertIncidentByID(ctx context.Context, id uint32) (*AlertIncident, error)
+	AcknowledgeAlertIncident(ctx context.Context, id uint32, userID uint32) (*AlertIncident, error)
+	ResolveAlertIncident(ctx context.Context, id uint32, resolvedAt time.Time) (*AlertIncident, error)
+
+	// EvaluateDeviceAlertRules locks the enabled rules of a device and passes
+	// them to fn along with writes bound to the same transaction, which commits
+	// when fn returns nil.
+	EvaluateDeviceAlertRules(ctx context.Context, deviceID uint32, fn func(rules []*AlertRule, tx AlertEvaluationTx) error) error
+}
+
+// AlertEvaluationTx is used by the alert evaluator while it holds the rules of
+// a device.
+type AlertEvaluationTx interface {
+	SetAlertRuleBreachStartedAt(ctx context.Context, id uint32, startedAt *time.Time) error
+	GetActiveAlertIncident(ctx context.Context, ruleID uint32) (*AlertIncident, error)
+	OpenAlertIncident(ctx context.Context, rule *AlertRule, value float64, openedAt time.Time) (*AlertIncident, error)
+	ResolveAlertIncident(ctx context.Context, id uint32, resolvedAt time.Time) (*AlertIncident, error)
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"math"
+	"strings"
+)
+
+// EXPERIMENT COMPARISON
+// An experiment comparison lines up several runs side by side. Every slice
+// of values holds one entry per experiment, in the order of Experiments.
+type ExperimentComparison struct {
+	Experiments     []*Experiment             `json:"experiments"`
+	Fields          []*ComparedField          `json:"fields"`
+	AnalyticalTests []*ComparedAnalyticalTest `json:"analyticalTests"`
+	Curves          *ComparedCurves           `json:"curves"`
+}
+
+// Sections of compared fields, named after the JSON of the experiment.
+const (
+	ComparisonSectionMaterialFeedstock  = "materialFeedstock"
+	ComparisonSectionExposureConditions = "exposureConditions"
+)
+
+// ComparedField is one parameter across the compared experiments. Values are
+// as entered; quantities are also converted to the base unit of their
+// dimension so that 500 g and 0.5 kg count as the same. Differs is set when
+// the values are not all the same.
+type ComparedField struct {
+	Section    string     `json:"section"`
+	Field      string     `json:"field"`
+	Values     []string   `json:"values"`
+	BaseValues []*float64 `json:"baseValues,omitempty"`
+	BaseUnit   string     `json:"baseUnit,omitempty"`
+	Differs    bool       `json:"differs"`
+}
+
+// ComparedAnalyticalTest groups the analytical tests of the same name. An
+// experiment may have several samples of a test, or none, in which case the
+// test differs.
+type ComparedAnalyticalTest struct {
+	Name    string              `json:"name"`
+	Tests   [][]AnalyticalTests `json:"tests"`
+	Differs bool                `json:"differs"`
+}
+
+// ComparedCurves holds sensor curves resampled on a shared time since start
+// axis so runs can be overlaid. Offsets are the middle of each bucket in
+// seconds since the start of each run; a value is null where a run has no
+// readings in a bucket or has already ended.
+type ComparedCurves struct {
+	StepSeconds float64          `json:"stepSeconds"`
+	Offsets     []float64        `json:"offsets"`
+	Series      []*ComparedCurve `json:"series"`
+}
+
+// ComparedCurve is the curve of one payload key of one device during one of
+// the compared experiments.
+type ComparedCurve struct {
+	ExperimentID uint32     `json:"experimentId"`
+	DeviceID     uint32     `json:"deviceId"`
+	DeviceName   string     `json:"deviceName"`
+	Key          string     `json:"key"`
+	Label        string     `json:"label"`
+	Unit         string     `json:"unit,omitempty"`
+	Values       []*float64 `json:"values"`
+}
+
+// exposureParameters are the ExperimentParameters recorded under the exposure
+// conditions; the others describe the feedstock.
+var exposureParameters = map[string]bool{
+	"co2Mass":           true,
+	"injectionPressure": true,
+	"headSpace":         true,
+	"reactionTime":      true,
+}
+
+// CompareExperimentFields lines up the feedstock and exposure conditions of
+// experiments in the order they appear on the experiment.
+func CompareExperimentFields(experiments []*Experiment) []*ComparedField {
+	text := func(section, field string, value func(e *Experiment) string) *ComparedField {
+		compared := &ComparedField{Section: section, Field: field, Values: make([]string, len(experiments))}
+		for i, experiment := range experiments {
+			compared.Values[i] = value(experiment)
+			if !strings.EqualFold(strings.TrimSpace(compared.Values[i]), strings.TrimSpace(compared.Values[0])) {
+				compared.Differs = true
+			}
+		}
+		return compared
+	}
+
+	quantity := func(section string, parameter ExperimentParameter) *ComparedField {
+		compared := &ComparedField{
+			Section:    section,
+			Field:      parameter.Name,
+			Values:     make([]string, len(experiments)),
+			BaseValues: make([]*float64, len(experiments)),
+			BaseUnit:   baseUnit(parameter.Dimension),
+		}
+		for i, experiment := range experiments {
+			q := experiment.parameters()[parameter.Name]
+			if q == nil {
+				// migrated text that is not a quantity is shown as it was entered
+				compared.Values[i] = experiment.LegacyParameters[parameter.Name]
+			} else {
+				compared.Values[i] = q.String()
+				normalised := *q
+				if value, err := normalised.normalise(parameter); err == nil {
+					compared.BaseValues[i] = &value
+				}
+			}
+
+			if !sameBaseValue(compared.BaseValues[i], compared.BaseValues[0]) ||
+				(compared.BaseValues[i] == nil && compared.Values[i] != compared.Values[0]) {
+				compared.Differs = true
+			}
+		}
+		return compared
+	}
+
+	fields := []*ComparedField{
+		text(ComparisonSectionMaterialFeedstock, "mixDesign", func(e *Experiment) string { return e.MaterialFeedstock.MixDesign }),
+	}
+	for _, parameter := range ExperimentParameters {
+		if !exposureParameters[parameter.Name] {
+			fields = append(fields, quantity(ComparisonSectionMaterialFeedstock, parameter))
+		}
+	}
+
+	fields = append(fields, text(ComparisonSectionExposureConditions, "co2Form", func(e *Experiment) string { return e.ExposureConditions.Co2Form }))
+	for _, parameter := range ExperimentParameters {
+		if exposureParameters[parameter.Name] {
+			fields = append(fields, quantity(ComparisonSectionExposureConditions, parameter))
+		}
+	}
+
+	return fields
+}
+
+// CompareAnalyticalTests groups the analytical tests of experiments by name,
+// in the order the names first appear.
+func CompareAnalyticalTests(experiments []*Experiment) []*ComparedAnalyticalTest {
+	compared := []*ComparedAnalyticalTest{}
+	byName := map[string]*ComparedAnalyticalTest{}
+	for i, experiment := range experiments {
+		for _, test := range experiment.AnalyticalTests {
+			name := strings.ToLower(strings.TrimSpace(test.Name))
+			group, ok := byName[name]
+			if !ok {
+				group = &ComparedAnalyticalTest{Name: test.Name, Tests: make([][]AnalyticalTests, len(experiments))}
+				byName[name] = group
+				compared = append(compared, group)
+			}
+			group.Tests[i] = append(group.Tests[i], test)
+		}
+	}
+
+	for _, group := range compared {
+		for i := range group.Tests {
+			if group.Tests[i] == nil {
+				group.Tests[i] = []AnalyticalTests{}
+				group.Differs = true
+			}
+		}
+	}
+
+	return compared
+}
+
+func baseUnit(dimension string) string {
+	for _, u := range units {
+		if u.dimension == dimension && u.factor == 1 {
+			return u.name
+		}
+	}
+
+	return ""
+}
+
+// sameBaseValue compares values converted between units, which are rarely
+// exactly equal.
+func sameBaseValue(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return math.Abs(*a-*b) <= 1e-9*math.Max(math.Abs(*a), math.Abs(*b))
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"github.com/Edwin9301/Zen/backend/pkg"
@@ -14,20 +15,199 @@ type Device struct {
 	ReactorID uint32    `json:"reactorId"`
 	Status    bool      `json:"status"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	// Connectivity is worked out from LastSeenAt and the expected reporting
+	// interval, independently of the admin controlled Status flag.
+	// LastSeenAt trails the latest reading by up to half of that interval.
+	LastSeenAt              *time.Time `json:"lastSeenAt"`
+	ExpectedIntervalSeconds int32      `json:"expectedIntervalSeconds"`
+	Connectivity            string     `json:"connectivity"`
+
+	Channels []*DeviceChannel `json:"channels,omitempty"`
 }
 
 type DeviceUpdate struct {
-	ReactorID *uint32 `json:"reactorId"`
-	Name      *string `json:"name"`
-	Status    *bool   `json:"status"`
+	ReactorID               *uint32 `json:"reactorId"`
+	Name                    *string `json:"name"`
+	Status                  *bool   `json:"status"`
+	ExpectedIntervalSeconds *int32  `json:"expectedIntervalSeconds" binding:"omitempty,min=1"`
+}
+
+const (
+	ConnectivityOnline  = "online"
+	ConnectivityStale   = "stale"
+	ConnectivityOffline = "offline"
+)
+
+// ConnectivityChange is a device whose computed connectivity changed.
+type ConnectivityChange struct {
+	DeviceID     uint32
+	Connectivity string
+}
+
+// DEVICE CHANNELS
+// A device channel declares one key of the reading payload. When a device has
+// channels every incoming payload is validated against them.
+type DeviceChannel struct {
+	ID        uint32    `json:"id"`
+	DeviceID  uint32    `json:"deviceId"`
+	Name      string    `json:"name"`
+	Unit      string    `json:"unit"`
+	DataType  string    `json:"dataType"`
+	Min       *float64  `json:"min"`
+	Max       *float64  `json:"max"`
+	Required  bool      `json:"required"`
+	Position  int32     `json:"position"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const (
+	ChannelTypeFloat   = "float"
+	ChannelTypeInteger = "integer"
+	ChannelTypeBoolean = "boolean"
+)
+
+// ReadingMetadataKeys are payload keys devices may always send, regardless of
+// the channels declared for them. They are not measurements, so they are left
+// out of aggregates and rollups; the queries in postgres/queries list them too.
+var ReadingMetadataKeys = map[string]bool{
+	"timestamp": true,
+	"ServerTS":  true,
+}
+
+// Label returns the channel name with its unit, e.g. "co2 (ppm)".
+func (c *DeviceChannel) Label() string {
+	if c.Unit == "" {
+		return c.Name
+	}
+
+	return c.Name + " (" + c.Unit + ")"
+}
+
+func (c *DeviceChannel) validate(value any) error {
+	if c.DataType == ChannelTypeBoolean {
+		if _, ok := value.(bool); !ok {
+			return pkg.Errorf(pkg.INVALID_ERROR, "channel %s must be a boolean", c.Name)
+		}
+		return nil
+	}
+
+	number, ok := value.(float64)
+	if !ok {
+		return pkg.Errorf(pkg.INVALID_ERROR, "channel %s must be a number", c.Name)
+	}
+
+	if c.DataType == ChannelTypeInteger && number != math.Trunc(number) {
+		return pkg.Errorf(pkg.INVALID_ERROR, "channel %s must be an integer", c.Name)
+	}
+
+	if c.Min != nil && number < *c.Min {
+		return pkg.Errorf(pkg.INVALID_ERROR, "channel %s value %v is below the minimum of %v", c.Name, number, *c.Min)
+	}
+
+	if c.Max != nil && number > *c.Max {
+		return pkg.Errorf(pkg.INVALID_ERROR, "channel %s value %v is above the maximum of %v", c.Name, number, *c.Max)
+	}
+
+	return nil
+}
+
+// ValidateReadingPayload checks a decoded JSON payload against the channels
+// declared for its device. Payloads of devices without channels are accepted.
+func ValidateReadingPayload(channels []*DeviceChannel, payload any) error {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	values, ok := payload.(map[string]any)
+	if !ok {
+		return pkg.Errorf(pkg.INVALID_ERROR, "reading payload must be a JSON object")
+	}
+
+	declared := make(map[string]*DeviceChannel, len(channels))
+	for _, channel := range channels {
+		declared[channel.Name] = channel
+
+		value, exists := values[channel.Name]
+		if !exists || value == nil {
+			if channel.Required {
+				return pkg.Errorf(pkg.INVALID_ERROR, "channel %s is required", channel.Name)
+			}
+			continue
+		}
+
+		if err := channel.validate(value); err != nil {
+			return err
+		}
+	}
+
+	for key := range values {
+		if _, ok := declared[key]; !ok && !ReadingMetadataKeys[key] {
+			return pkg.Errorf(pkg.INVALID_ERROR, "unknown channel %s", key)
+		}
+	}
+
+	return nil
+}
+
+// DEVICE API KEYS
+type DeviceAPIKey struct {
+	ID         uint32     `json:"id"`
+	DeviceID   uint32     `json:"deviceId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"` // plaintext key, only set when issued
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
 }
 
 // SENSOR READINGS
 type Reading struct {
-	ID        uint32    `json:"id"`
-	DeviceID  uint32    `json:"device_id"`
-	Payload   any       `json:"payload"` // jsonb
-	Timestamp time.Time `json:"timestamp"`
+	ID         uint32    `json:"id"`
+	DeviceID   uint32    `json:"device_id"`
+	Payload    any       `json:"payload"`     // jsonb
+	Timestamp  time.Time `json:"timestamp"`   // measured at, as reported by the device
+	ReceivedAt time.Time `json:"received_at"` // when the server received the reading
+	MessageID  string    `json:"message_id,omitempty"`
+	Resolution string    `json:"resolution,omitempty"` // rollup tier for readings past raw retention, empty for raw
+
+	// Duplicate is set when AddReading found an earlier reading with the
+	// same message id and returned it instead of inserting.
+	Duplicate bool `json:"-"`
+}
+
+// MessageIDKey is the payload key devices use to send an idempotency key or
+// sequence number. It is moved out of the payload into Reading.MessageID.
+const MessageIDKey = "message_id"
+
+// ClockSkewPolicy decides what happens to a reading whose device timestamp is
+// further in the future than the allowed skew, or older than the backfill age.
+// Timestamps past the reading partitions created ahead of time are refused
+// under every policy. pkg.LoadConfig refuses other values.
+type ClockSkewPolicy string
+
+const (
+	ClockSkewAccept ClockSkewPolicy = "accept" // store the device timestamp as is
+	ClockSkewClamp  ClockSkewPolicy = "clamp"  // move the timestamp to the closest allowed time
+	ClockSkewReject ClockSkewPolicy = "reject" // refuse the reading
+)
+
+// ReadingBatchResult reports the outcome of a bulk ingestion request.
+type ReadingBatchResult struct {
+	Accepted   int                `json:"accepted"`
+	Duplicates int                `json:"duplicates"` // already stored or repeated within the batch
+	Rejected   int                `json:"rejected"`
+	Rejections []ReadingRejection `json:"rejections"`
+
+	// Stored are the readings inserted by the batch, with their timestamp
+	// resolved, so they can be evaluated after the insert.
+	Stored []*Reading `json:"-"`
+}
+
+type ReadingRejection struct {
+	Index  int    `json:"index"` // position of the reading in the submitted batch
+	Reason string `json:"reason"`
 }
 
 // type ReadingPayload struct {
@@ -47,11 +227,66 @@ type Reading struct {
 // }
 
 type ReadingFilter struct {
-	DeviceID   uint32
-	Pagination *pkg.Pagination
-	Start      *time.Time // optional timeslot start
-	End        *time.Time // optional timeslot end
-	Date       *time.Time // optional "single day" filter
+	DeviceID uint32
+	Start    *time.Time // optional timeslot start
+	End      *time.Time // optional timeslot end, exclusive
+	Date     *time.Time // optional "single day" filter
+
+	// keyset paging by device
+	Limit  uint32
+	Cursor *pkg.Cursor // nil for the newest page
+	Count  string      // one of the Count* modes
+}
+
+// How the total of a reading page is counted.
+const (
+	CountNone     = "none"
+	CountEstimate = "estimate"
+	CountExact    = "exact"
+)
+
+// ReadingPage holds the cursors around a page of readings. Next pages
+// towards older readings and Prev towards newer ones; either is empty at the
+// end of the list.
+type ReadingPage struct {
+	Next           string `json:"next"`
+	Prev           string `json:"prev"`
+	Limit          uint32 `json:"limit"`
+	Total          *int64 `json:"total,omitempty"`
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+}
+
+// AGGREGATION
+const (
+	AggregateAvg   = "avg"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	AggregateCount = "count"
+	AggregateLast  = "last"
+)
+
+// AggregateBuckets maps the supported bucket names to their size.
+var AggregateBuckets = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+type AggregateFilter struct {
+	DeviceID  uint32
+	Start     time.Time
+	End       time.Time
+	Bucket    time.Duration
+	Keys      []string // payload keys to include, all numeric keys when empty
+	Functions []string // subset of avg, min, max, count, last
+}
+
+// ReadingBucket holds the aggregated values of one time bucket, keyed by
+// payload key and then by aggregate function.
+type ReadingBucket struct {
+	Bucket time.Time                     `json:"bucket"`
+	Values map[string]map[string]float64 `json:"values"`
 }
 
 type DeviceRepository interface {
@@ -61,14 +296,33 @@ type DeviceRepository interface {
 	UpdateDevice(ctx context.Context, id uint32, update *DeviceUpdate) (*Device, error)
 	DeleteDevice(ctx context.Context, id uint32) error
 	ListDevice(ctx context.Context) ([]*Device, error)
+	ListDevicesByReactor(ctx context.Context, reactorID uint32) ([]*Device, error)
 	GetDeviceStats(ctx context.Context) (*DeviceStats, error)
+	UpdateDevicesConnectivity(ctx context.Context, staleAfter, offlineAfter float64) ([]ConnectivityChange, error)
+
+	// Channels
+	CreateDeviceChannel(ctx context.Context, channel *DeviceChannel) (*DeviceChannel, error)
+	ListDeviceChannels(ctx context.Context, deviceID uint32) ([]*DeviceChannel, error)
+	UpdateDeviceChannel(ctx context.Context, channel *DeviceChannel) (*DeviceChannel, error)
+	DeleteDeviceChannel(ctx context.Context, deviceID, channelID uint32) error
+
+	// API keys
+	CreateDeviceAPIKey(ctx context.Context, deviceID uint32, name string) (*DeviceAPIKey, error)
+	ListDeviceAPIKeys(ctx context.Context, deviceID uint32) ([]*DeviceAPIKey, error)
+	RotateDeviceAPIKey(ctx context.Context, deviceID, keyID uint32) (*DeviceAPIKey, error)
+	RevokeDeviceAPIKey(ctx context.Context, deviceID, keyID uint32) error
+	AuthenticateDeviceAPIKey(ctx context.Context, key string) (uint32, error)
 
 	// Readings
 	AddReading(ctx context.Context, reading *Reading) (*Reading, error)
+	AddReadings(ctx context.Context, deviceID uint32, readings []*Reading) (*ReadingBatchResult, error)
 	GetReadingByID(ctx context.Context, id uint32) (*Reading, error)
-	ListReadingByDevice(ctx context.Context, filter *ReadingFilter) ([]*Reading, *pkg.Pagination, error)
+	ListReadingByDevice(ctx context.Context, filter *ReadingFilter) ([]*Reading, *ReadingPage, error)
 	ListReadingByDate(ctx context.Context, filter *ReadingFilter) ([]*Reading, error)
 	ListReadingByTimeRange(ctx context.Context, filter *ReadingFilter) ([]*Reading, error)
+	StreamReadingsByTimeRange(ctx context.Context, filter *ReadingFilter, fn func(reading *Reading) error) error
+	AggregateReadings(ctx context.Context, filter *AggregateFilter) ([]*ReadingBucket, error)
+	EnsureReadingPartitions(ctx context.Context, monthsAhead int) error
 }
 
 // Optional stats result object
@@ -76,5 +330,8 @@ type DeviceStats struct {
 	TotalDevices        int64 `json:"total_devices"`
 	ActiveDevices       int64 `json:"active_devices"`
 	InactiveDevices     int64 `json:"inactive_devices"`
+	OnlineDevices       int64 `json:"online_devices"`
+	StaleDevices        int64 `json:"stale_devices"`
+	OfflineDevices      int64 `json:"offline_devices"`
 	TotalSensorReadings int64 `json:"total_sensor_readings"`
 }
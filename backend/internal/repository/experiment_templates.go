@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// EXPERIMENT TEMPLATES
+// A template holds the feedstock and exposure conditions of a mix design so
+// they need not be re-entered for every batch. SourceExperimentID is the
+// experiment the template was saved from, if any; it is cleared when that
+// experiment is removed for good.
+type ExperimentTemplate struct {
+	ID                 uint32             `json:"id"`
+	Name               string             `json:"name"`
+	Description        string             `json:"description"`
+	MaterialFeedstock  MaterialFeedstock  `json:"materialFeedstock"`
+	ExposureConditions ExposureConditions `json:"exposureConditions"`
+	SourceExperimentID *uint32            `json:"sourceExperimentId"`
+	CreatedBy          uint32             `json:"createdBy"`
+	CreatedAt          time.Time          `json:"createdAt"`
+	UpdatedAt          time.Time          `json:"updatedAt"`
+}
+
+// maxExperimentTemplateName matches the batch and block IDs of experiments.
+const maxExperimentTemplateName = 100
+
+// Validate checks the name and the quantities of the template, filling in
+// default units like an experiment.
+func (t *ExperimentTemplate) Validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return pkg.Errorf(pkg.INVALID_ERROR, "name is required")
+	}
+	if len(t.Name) > maxExperimentTemplateName {
+		return pkg.Errorf(pkg.INVALID_ERROR, "name must be at most %d characters", maxExperimentTemplateName)
+	}
+
+	// the quantities are shared, so their default units are kept
+	experiment := Experiment{MaterialFeedstock: t.MaterialFeedstock, ExposureConditions: t.ExposureConditions}
+	_, err := experiment.NormaliseParameters()
+
+	return err
+}
+
+// Prefill sets the feedstock and exposure conditions the experiment leaves
+// empty to the given ones, as when creating an experiment from a template or
+// cloning one. Quantities are copied rather than shared.
+func (e *Experiment) Prefill(feedstock MaterialFeedstock, exposure ExposureConditions) {
+	text := func(value *string, from string) {
+		if *value == "" {
+			*value = from
+		}
+	}
+	quantity := func(value **Quantity, from *Quantity) {
+		if *value == nil && from != nil {
+			copied := *from
+			*value = &copied
+		}
+	}
+
+	m, c := &e.MaterialFeedstock, &e.ExposureConditions
+	text(&m.MixDesign, feedstock.MixDesign)
+	quantity(&m.Cement, feedstock.Cement)
+	quantity(&m.FineAggregate, feedstock.FineAggregate)
+	quantity(&m.CoarseAggregate, feedstock.CoarseAggregate)
+	quantity(&m.Water, feedstock.Water)
+	quantity(&m.WaterCementRatio, feedstock.WaterCementRatio)
+	quantity(&m.BlockSizeLength, feedstock.BlockSizeLength)
+	quantity(&m.BlockSizeWidth, feedstock.BlockSizeWidth)
+	quantity(&m.BlockSizeHeight, feedstock.BlockSizeHeight)
+
+	text(&c.Co2Form, exposure.Co2Form)
+	quantity(&c.Co2Mass, exposure.Co2Mass)
+	quantity(&c.InjectionPressure, exposure.InjectionPressure)
+	quantity(&c.HeadSpace, exposure.HeadSpace)
+	quantity(&c.ReactionTime, exposure.ReactionTime)
+}
+
+type ExperimentTemplateRepository interface {
+	CreateExperimentTemplate(ctx context.Context, template *ExperimentTemplate) (*ExperimentTemplate, error)
+	GetExperimentTemplateByID(ctx context.Context, id uint32) (*ExperimentTemplate, error)
+	// ListExperimentTemplates returns the templates by name, those whose
+	// name contains search when it is set.
+	ListExperimentTemplates(ctx context.Context, search *string) ([]*ExperimentTemplate, error)
+	UpdateExperimentTemplate(ctx context.Context, template *ExperimentTemplate) (*ExperimentTemplate, error)
+	DeleteExperimentTemplate(ctx context.Context, id uint32) error
+}
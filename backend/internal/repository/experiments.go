@@ -8,46 +8,234 @@ import (
 )
 
 type Experiment struct {
-	ID                 uint32             `json:"id"`
-	BatchID            string             `json:"batchId"`
-	ReactorID          uint32             `json:"reactorId"`
-	Operator           string             `json:"operator"`
-	Date               time.Time          `json:"date"`
-	BlockID            string             `json:"blockId"`
-	TimeStart          string             `json:"timeStart"`
-	TimeEnd            string             `json:"timeEnd"`
-	MaterialFeedstock  MaterialFeedstock  `json:"materialFeedstock"`
-	ExposureConditions ExposureConditions `json:"exposureConditions"`
-	AnalyticalTests    []AnalyticalTests  `json:"analyticalTests"`
-	DeletedAt          *time.Time         `json:"deletedAt"`
-	CreatedAt          time.Time          `json:"createdAt"`
+	ID                 uint32              `json:"id"`
+	BatchID            string              `json:"batchId"`
+	ReactorID          uint32              `json:"reactorId"`
+	Operator           string              `json:"operator"`
+	Date               time.Time           `json:"date"`
+	BlockID            string              `json:"blockId"`
+	TimeStart          string              `json:"timeStart"`
+	TimeEnd            string              `json:"timeEnd"`
+	MaterialFeedstock  MaterialFeedstock   `json:"materialFeedstock"`
+	ExposureConditions ExposureConditions  `json:"exposureConditions"`
+	AnalyticalTests    []AnalyticalTests   `json:"analyticalTests"`
+	RunStats           *ExperimentRunStats `json:"runStats"`
+	Status             string              `json:"status"`
+	StartedAt          *time.Time          `json:"startedAt"`
+	EndedAt            *time.Time          `json:"endedAt"`
+	DeletedAt          *time.Time          `json:"deletedAt"`
+	CreatedAt          time.Time           `json:"createdAt"`
+
+	// LegacyParameters keeps values recorded as free text that could not be
+	// read as quantities, keyed by parameter name, until they are re-entered.
+	LegacyParameters map[string]string `json:"legacyParameters,omitempty"`
+}
+
+// EXPERIMENT STATUS
+// An experiment is planned when created, running once started and completed
+// or aborted once stopped. No other transitions are allowed.
+const (
+	ExperimentStatusPlanned   = "planned"
+	ExperimentStatusRunning   = "running"
+	ExperimentStatusCompleted = "completed"
+	ExperimentStatusAborted   = "aborted"
+)
+
+var experimentTransitions = map[string][]string{
+	ExperimentStatusPlanned: {ExperimentStatusRunning},
+	ExperimentStatusRunning: {ExperimentStatusCompleted, ExperimentStatusAborted},
+}
+
+// CanTransition reports whether an experiment may move from one status to
+// another.
+func CanTransition(from, to string) bool {
+	for _, status := range experimentTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsExperimentStatus(status string) bool {
+	switch status {
+	case ExperimentStatusPlanned, ExperimentStatusRunning, ExperimentStatusCompleted, ExperimentStatusAborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// Finished reports whether the experiment has been stopped, after which its
+// readings no longer change.
+func (e *Experiment) Finished() bool {
+	return e.Status == ExperimentStatusCompleted || e.Status == ExperimentStatusAborted
+}
+
+// Window returns the run window of the experiment. Once started it runs from
+// StartedAt to EndedAt, or to now while running. Before that it is the
+// planned window: the experiment date with its HH:MM start and end times,
+// which are stored without a zone and read as UTC like the date.
+func (e *Experiment) Window() (time.Time, time.Time, error) {
+	if e.StartedAt != nil {
+		end := time.Now().UTC()
+		if e.EndedAt != nil {
+			end = *e.EndedAt
+		}
+		return *e.StartedAt, end, nil
+	}
+
+	clock := func(value string) (time.Duration, error) {
+		parsed, err := time.Parse("15:04", value)
+		if err != nil {
+			return 0, pkg.Errorf(pkg.INVALID_ERROR, "experiment %d has an invalid time %q", e.ID, value)
+		}
+		return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+	}
+
+	startOffset, err := clock(e.TimeStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	endOffset, err := clock(e.TimeEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	date := e.Date.UTC()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	start, end := day.Add(startOffset), day.Add(endOffset)
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "experiment %d ends before it starts", e.ID)
+	}
+
+	return start, end, nil
 }
 
+// MaterialFeedstock describes the block mix. Numeric fields are quantities
+// and are null when not recorded.
 type MaterialFeedstock struct {
-	MixDesign        string `json:"mixDesign"`
-	Cement           string `json:"cement"`
-	FineAggregate    string `json:"fineAggregate"`
-	CoarseAggregate  string `json:"coarseAggregate"`
-	Water            string `json:"water"`
-	WaterCementRatio string `json:"waterCementRatio"`
-	BlockSizeLength  string `json:"blockSizeLength"`
-	BlockSizeWidth   string `json:"blockSizeWidth"`
-	BlockSizeHeight  string `json:"blockSizeHeight"`
+	MixDesign        string    `json:"mixDesign"`
+	Cement           *Quantity `json:"cement"`
+	FineAggregate    *Quantity `json:"fineAggregate"`
+	CoarseAggregate  *Quantity `json:"coarseAggregate"`
+	Water            *Quantity `json:"water"`
+	WaterCementRatio *Quantity `json:"waterCementRatio"`
+	BlockSizeLength  *Quantity `json:"blockSizeLength"`
+	BlockSizeWidth   *Quantity `json:"blockSizeWidth"`
+	BlockSizeHeight  *Quantity `json:"blockSizeHeight"`
 }
 
 type ExposureConditions struct {
-	Co2Form           string `json:"co2Form"`
-	Co2Mass           string `json:"co2Mass"`
-	InjectionPressure string `json:"injectionPressure"`
-	HeadSpace         string `json:"headSpace"`
-	ReactionTime      string `json:"reactionTime"`
+	Co2Form           string    `json:"co2Form"`
+	Co2Mass           *Quantity `json:"co2Mass"`
+	InjectionPressure *Quantity `json:"injectionPressure"`
+	HeadSpace         *Quantity `json:"headSpace"`
+	ReactionTime      *Quantity `json:"reactionTime"`
 }
 
+// parameters maps the names of ExperimentParameters to the quantities of the
+// experiment.
+func (e *Experiment) parameters() map[string]*Quantity {
+	m, c := &e.MaterialFeedstock, &e.ExposureConditions
+
+	return map[string]*Quantity{
+		"cement":            m.Cement,
+		"fineAggregate":     m.FineAggregate,
+		"coarseAggregate":   m.CoarseAggregate,
+		"water":             m.Water,
+		"waterCementRatio":  m.WaterCementRatio,
+		"blockSizeLength":   m.BlockSizeLength,
+		"blockSizeWidth":    m.BlockSizeWidth,
+		"blockSizeHeight":   m.BlockSizeHeight,
+		"co2Mass":           c.Co2Mass,
+		"injectionPressure": c.InjectionPressure,
+		"headSpace":         c.HeadSpace,
+		"reactionTime":      c.ReactionTime,
+	}
+}
+
+// NormaliseParameters validates the units and values of the numeric
+// parameters, filling in default units. It returns the recorded parameters
+// in the base unit of their dimension, keyed by name.
+func (e *Experiment) NormaliseParameters() (map[string]float64, error) {
+	quantities := e.parameters()
+	values := map[string]float64{}
+	for _, parameter := range ExperimentParameters {
+		quantity := quantities[parameter.Name]
+		if quantity == nil {
+			continue
+		}
+
+		value, err := quantity.normalise(parameter)
+		if err != nil {
+			return nil, err
+		}
+		values[parameter.Name] = value
+	}
+
+	return values, nil
+}
+
+// AnalyticalTests reference their report as an uploaded file. PdfUrl is the
+// link of a report attached before files were stored by the API; it is kept
+// for those tests but can no longer be set.
 type AnalyticalTests struct {
 	Name     string    `json:"name"`
 	SampleID string    `json:"sampleId"`
 	Date     time.Time `json:"date"`
-	PdfUrl   string    `json:"pdfUrl"`
+	FileID   *uint32   `json:"fileId"`
+	PdfUrl   string    `json:"pdfUrl,omitempty"`
+}
+
+// ExperimentDevice is a device that was attached to the reactor of an
+// experiment, with Start and End clipped to the part of the run window it was
+// attached for. A device moved away and back again appears once per stay.
+type ExperimentDevice struct {
+	DeviceID   uint32    `json:"deviceId"`
+	DeviceName string    `json:"deviceName"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+}
+
+// ExperimentRunStats are run statistics derived from the readings recorded
+// during an experiment, one entry per device.
+type ExperimentRunStats struct {
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Devices    []*DeviceRunStats `json:"devices"`
+	ComputedAt time.Time         `json:"computedAt"`
+}
+
+// DeviceRunStats holds the run statistics of one device. Channels are picked by
+// name: the first key containing "co2", "pressure" or "temp" respectively.
+// Co2Uptake is the drop of the CO2 channel from the first to the last reading
+// of the run, in the unit of that channel.
+type DeviceRunStats struct {
+	DeviceID         uint32           `json:"deviceId"`
+	DeviceName       string           `json:"deviceName"`
+	Readings         int64            `json:"readings"`
+	Co2              *ChannelRunStats `json:"co2"`
+	Pressure         *ChannelRunStats `json:"pressure"`
+	Temperature      *ChannelRunStats `json:"temperature"`
+	Co2Uptake        *float64         `json:"co2Uptake"`
+	PeakPressure     *float64         `json:"peakPressure"`
+	TemperatureRange *float64         `json:"temperatureRange"`
+}
+
+// ChannelRunStats summarises one payload key over a run.
+type ChannelRunStats struct {
+	Key     string    `json:"key"`
+	Unit    string    `json:"unit,omitempty"`
+	Samples int64     `json:"samples"`
+	First   float64   `json:"first"`
+	Last    float64   `json:"last"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	Mean    float64   `json:"mean"`
+	MaxAt   time.Time `json:"maxAt"`
 }
 
 type FilterExperiments struct {
@@ -55,6 +243,8 @@ type FilterExperiments struct {
 	Search     *string
 	ReactorID  *uint32
 	Date       *time.Time
+	Status     *string
+	Parameters []*ParameterFilter
 }
 
 type ExperimentRepository interface {
@@ -63,4 +253,15 @@ type ExperimentRepository interface {
 	UpdateExperiment(ctx context.Context, experiment *Experiment) error
 	ListExperiments(ctx context.Context, filter *FilterExperiments) ([]*Experiment, *pkg.Pagination, error)
 	DeleteExperiment(ctx context.Context, id uint32) error
+
+	// StartExperiment moves a planned experiment to running and
+	// StopExperiment a running one to completed or aborted, recording the
+	// server time of each.
+	StartExperiment(ctx context.Context, id uint32) (*Experiment, error)
+	StopExperiment(ctx context.Context, id uint32, status string) (*Experiment, error)
+
+	// ListExperimentDevices resolves the devices attached to the reactor of
+	// the experiment during its run window from the assignment history.
+	ListExperimentDevices(ctx context.Context, experiment *Experiment) ([]*ExperimentDevice, error)
+	SetExperimentRunStats(ctx context.Context, id uint32, stats *ExperimentRunStats) error
 }
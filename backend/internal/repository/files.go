@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// FILES
+// Uploaded files such as analytical test reports and reactor documents. The
+// content is kept by the storage backend under StorageKey; Checksum is the
+// hex SHA-256 of the content, computed when it was uploaded.
+type File struct {
+	ID          uint32    `json:"id"`
+	StorageKey  string    `json:"-"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreatedBy   uint32    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+const FileContentTypePDF = "application/pdf"
+
+type FileRepository interface {
+	CreateFile(ctx context.Context, file *File) (*File, error)
+	GetFileByID(ctx context.Context, id uint32) (*File, error)
+}
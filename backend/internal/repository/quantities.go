@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// QUANTITIES
+// Numeric experiment parameters are stored as a value with the unit it was
+// entered in. Each parameter has a dimension; values are also converted to
+// the base unit of that dimension so experiments can be filtered by range
+// whatever unit they were recorded in.
+type Quantity struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// Dimensions of experiment parameters and their base unit.
+const (
+	DimensionMass     = "mass"     // kg
+	DimensionLength   = "length"   // mm
+	DimensionPressure = "pressure" // bar
+	DimensionVolume   = "volume"   // L
+	DimensionDuration = "duration" // min
+	DimensionRatio    = "ratio"    // unitless
+)
+
+type unit struct {
+	name      string
+	dimension string
+	factor    float64 // multiplier to the base unit of the dimension
+}
+
+// units is the source of truth for units. Migration 000019 holds a copy in
+// its quantity_units table to convert the text recorded before quantities;
+// a unit added here only needs a new migration if older text should be read
+// with it.
+var units = []unit{
+	{"g", DimensionMass, 0.001},
+	{"kg", DimensionMass, 1},
+	{"t", DimensionMass, 1000},
+	{"lb", DimensionMass, 0.45359237},
+
+	{"mm", DimensionLength, 1},
+	{"cm", DimensionLength, 10},
+	{"m", DimensionLength, 1000},
+	{"in", DimensionLength, 25.4},
+
+	{"bar", DimensionPressure, 1},
+	{"mbar", DimensionPressure, 0.001},
+	{"Pa", DimensionPressure, 0.00001},
+	{"kPa", DimensionPressure, 0.01},
+	{"MPa", DimensionPressure, 10},
+	{"psi", DimensionPressure, 0.0689475729},
+	{"atm", DimensionPressure, 1.01325},
+
+	{"mL", DimensionVolume, 0.001},
+	{"L", DimensionVolume, 1},
+	{"cm3", DimensionVolume, 0.001},
+	{"m3", DimensionVolume, 1000},
+
+	{"s", DimensionDuration, 1.0 / 60},
+	{"min", DimensionDuration, 1},
+	{"h", DimensionDuration, 60},
+	{"d", DimensionDuration, 24 * 60},
+
+	{"", DimensionRatio, 1},
+	{"%", DimensionRatio, 0.01},
+}
+
+// unitAliases maps other spellings of a unit to its name. Unit names are
+// matched by case, as a case-insensitive match would read mPa as MPa or Mm
+// as mm, so only spellings that cannot be mistaken are listed here.
+// Migration 000019 applies the same aliases.
+var unitAliases = map[string]string{
+	"l":  "L",
+	"ml": "mL",
+}
+
+// lookupUnit finds a unit of a dimension by its name or an alias.
+func lookupUnit(dimension, name string) (unit, bool) {
+	if alias, ok := unitAliases[name]; ok {
+		name = alias
+	}
+
+	for _, u := range units {
+		if u.dimension == dimension && u.name == name {
+			return u, true
+		}
+	}
+
+	return unit{}, false
+}
+
+func dimensionUnits(dimension string) []string {
+	names := []string{}
+	for _, u := range units {
+		if u.dimension == dimension && u.name != "" {
+			names = append(names, u.name)
+		}
+	}
+
+	return names
+}
+
+// ExperimentParameter describes a numeric parameter of an experiment. A value
+// entered without a unit is read in DefaultUnit.
+type ExperimentParameter struct {
+	Name        string
+	Dimension   string
+	DefaultUnit string
+}
+
+// ExperimentParameters lists the numeric parameters of an experiment by their
+// JSON name, material feedstock first. Migration 000019 holds a copy in its
+// quantity_fields table.
+var ExperimentParameters = []ExperimentParameter{
+	{"cement", DimensionMass, "kg"},
+	{"fineAggregate", DimensionMass, "kg"},
+	{"coarseAggregate", DimensionMass, "kg"},
+	{"water", DimensionMass, "kg"},
+	{"waterCementRatio", DimensionRatio, ""},
+	{"blockSizeLength", DimensionLength, "mm"},
+	{"blockSizeWidth", DimensionLength, "mm"},
+	{"blockSizeHeight", DimensionLength, "mm"},
+
+	{"co2Mass", DimensionMass, "kg"},
+	{"injectionPressure", DimensionPressure, "bar"},
+	{"headSpace", DimensionVolume, "L"},
+	{"reactionTime", DimensionDuration, "min"},
+}
+
+func lookupExperimentParameter(name string) (ExperimentParameter, bool) {
+	for _, parameter := range ExperimentParameters {
+		if strings.EqualFold(parameter.Name, name) {
+			return parameter, true
+		}
+	}
+
+	return ExperimentParameter{}, false
+}
+
+var quantityPattern = regexp.MustCompile(`^\s*([-+]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][-+]?[0-9]+)?)\s*(\S*)\s*$`)
+
+// ParseQuantity reads a value with an optional unit, such as "5kg", "5 kg"
+// or "0.45". The unit is checked when the quantity is normalised.
+func ParseQuantity(value string) (*Quantity, error) {
+	match := quantityPattern.FindStringSubmatch(value)
+	if match == nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid quantity %q, expected a number with an optional unit such as 5kg", value)
+	}
+
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid quantity %q: %v", value, err)
+	}
+
+	return &Quantity{Value: number, Unit: match[2]}, nil
+}
+
+// UnmarshalJSON accepts {"value": 5, "unit": "kg"}, a bare number or a
+// string such as "5 kg". An unset quantity is null.
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch value := raw.(type) {
+	case float64:
+		*q = Quantity{Value: value}
+	case string:
+		parsed, err := ParseQuantity(value)
+		if err != nil {
+			return err
+		}
+		*q = *parsed
+	case map[string]interface{}:
+		type plain Quantity
+		var object plain
+		if err := json.Unmarshal(data, &object); err != nil {
+			return err
+		}
+		*q = Quantity(object)
+	default:
+		return fmt.Errorf("invalid quantity %s", string(data))
+	}
+
+	return nil
+}
+
+func (q *Quantity) String() string {
+	if q == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(strconv.FormatFloat(q.Value, 'f', -1, 64) + " " + q.Unit)
+}
+
+// normalise checks the quantity against a parameter, fills in the default
+// unit and spells the unit the canonical way. It returns the value in the
+// base unit of the dimension.
+func (q *Quantity) normalise(parameter ExperimentParameter) (float64, error) {
+	if q.Unit == "" {
+		q.Unit = parameter.DefaultUnit
+	}
+
+	u, ok := lookupUnit(parameter.Dimension, q.Unit)
+	if !ok {
+		return 0, pkg.Errorf(pkg.INVALID_ERROR, "invalid unit %q for %s, must be one of %s", q.Unit, parameter.Name, strings.Join(dimensionUnits(parameter.Dimension), ", "))
+	}
+	q.Unit = u.name
+
+	if q.Value < 0 {
+		return 0, pkg.Errorf(pkg.INVALID_ERROR, "%s cannot be negative", parameter.Name)
+	}
+
+	return q.Value * u.factor, nil
+}
+
+// PARAMETER FILTERS
+// A parameter filter compares a numeric parameter with a quantity, for
+// example co2Mass>=5kg. Value is in the base unit of the parameter, and is
+// compared with the tolerance of sameBaseValue so that cement=1100g matches
+// a stored 1.1 kg.
+type ParameterFilter struct {
+	Parameter string
+	Operator  string
+	Value     float64
+}
+
+var parameterFilterPattern = regexp.MustCompile(`^\s*([A-Za-z0-9]+)\s*(>=|<=|>|<|=)\s*(.+)$`)
+
+// ParseParameterFilter reads a filter such as co2Mass>=5kg or
+// waterCementRatio<0.5.
+func ParseParameterFilter(expression string) (*ParameterFilter, error) {
+	match := parameterFilterPattern.FindStringSubmatch(expression)
+	if match == nil {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "invalid filter %q, expected a parameter, one of >=, <=, >, <, = and a value such as co2Mass>=5kg", expression)
+	}
+
+	parameter, ok := lookupExperimentParameter(match[1])
+	if !ok {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "unknown parameter %q in filter %q", match[1], expression)
+	}
+
+	quantity, err := ParseQuantity(match[3])
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := quantity.normalise(parameter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParameterFilter{
+		Parameter: parameter.Name,
+		Operator:  match[2],
+		Value:     value,
+	}, nil
+}
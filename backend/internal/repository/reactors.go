@@ -7,22 +7,26 @@ import (
 	"github.com/Edwin9301/Zen/backend/pkg"
 )
 
+// Reactor documents are uploaded files referenced by PdfFileID. PdfUrl is
+// the link of a document attached before files were stored by the API and
+// can no longer be set. Updating PdfFileID to 0 detaches the document.
 type Reactor struct {
 	ID        uint32     `json:"id"`
 	Name      string     `json:"name"`
 	Status    string     `json:"status"`
 	Pathway   string     `json:"pathway"`
-	PdfUrl    string     `json:"pdfUrl"`
+	PdfFileID *uint32    `json:"pdfFileId"`
+	PdfUrl    string     `json:"pdfUrl,omitempty"`
 	DeletedAt *time.Time `json:"deletedAt"`
 	CreatedAt time.Time  `json:"createdAt"`
 }
 
 type UpdateReactor struct {
-	ID      uint32  `json:"id"`
-	Name    *string `json:"name"`
-	Status  *string `json:"status"`
-	Pathway *string `json:"pathway"`
-	PdfUrl  *string `json:"pdfUrl"`
+	ID        uint32  `json:"id"`
+	Name      *string `json:"name"`
+	Status    *string `json:"status"`
+	Pathway   *string `json:"pathway"`
+	PdfFileID *uint32 `json:"pdfFileId"`
 }
 
 type FilterReactors struct {
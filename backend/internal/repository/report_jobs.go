@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// REPORT JOBS
+// A report job builds a workbook or PDF in the background. Its state lives in
+// Postgres so queued and half-done jobs are picked up again after a restart.
+type ReportJob struct {
+	ID          uint32          `json:"id"`
+	Kind        string          `json:"kind"`
+	Params      json.RawMessage `json:"params"`
+	Status      string          `json:"status"`
+	Progress    int32           `json:"progress"`
+	Error       string          `json:"error,omitempty"`
+	Attempts    int32           `json:"attempts"`
+	CreatedBy   uint32          `json:"createdBy"`
+	NotifyEmail string          `json:"notifyEmail,omitempty"`
+	FileName    string          `json:"fileName,omitempty"`
+	ContentType string          `json:"contentType,omitempty"`
+	FileSize    int64           `json:"fileSize,omitempty"`
+	StartedAt   *time.Time      `json:"startedAt"`
+	FinishedAt  *time.Time      `json:"finishedAt"`
+	ExpiresAt   *time.Time      `json:"expiresAt"`
+	CreatedAt   time.Time       `json:"createdAt"`
+
+	// DownloadToken authorises the download link and is never listed.
+	DownloadToken string `json:"-"`
+	// DownloadURL is filled in by the API once the file is ready.
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+const (
+	ReportJobKindReadingsXLSX  = "readings_xlsx"
+	ReportJobKindExperimentPDF = "experiment_pdf"
+)
+
+const (
+	ReportJobStatusQueued    = "queued"
+	ReportJobStatusRunning   = "running"
+	ReportJobStatusSucceeded = "succeeded"
+	ReportJobStatusFailed    = "failed"
+)
+
+// ReportJobFile is the output of a finished job.
+type ReportJobFile struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+type ReportJobRepository interface {
+	CreateReportJob(ctx context.Context, job *ReportJob) (*ReportJob, error)
+	GetReportJobByID(ctx context.Context, id uint32) (*ReportJob, error)
+	ListReportJobsByUser(ctx context.Context, userID uint32, limit int32) ([]*ReportJob, error)
+
+	// ClaimReportJob marks the next job as running for lease and returns
+	// it, or nil when nothing is waiting. Jobs whose lease ran out are
+	// claimed again.
+	ClaimReportJob(ctx context.Context, lease time.Duration) (*ReportJob, error)
+	// The calls below only apply while the job is still running the attempt
+	// the worker claimed. RenewReportJobLease, CompleteReportJob and
+	// FailReportJob return a not found error once the lease is lost, that is
+	// once another worker has claimed the job again.
+
+	// RenewReportJobLease keeps the job claimed for another lease.
+	RenewReportJobLease(ctx context.Context, id uint32, attempts int32, lease time.Duration) error
+	UpdateReportJobProgress(ctx context.Context, id uint32, attempts int32, progress int32) error
+	// CompleteReportJob stores the file and keeps it downloadable for ttl.
+	CompleteReportJob(ctx context.Context, id uint32, attempts int32, file *ReportJobFile, ttl time.Duration) (*ReportJob, error)
+	// FailReportJob records the error and keeps the failed job listed for
+	// ttl, after which it is deleted like a finished one.
+	FailReportJob(ctx context.Context, id uint32, attempts int32, message string, ttl time.Duration) (*ReportJob, error)
+
+	GetReportJobFile(ctx context.Context, id uint32, token string) (*ReportJobFile, error)
+	DeleteExpiredReportJobs(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/robfig/cron/v3"
+)
+
+// REPORT SCHEDULES
+// A report schedule emails a report on a cron schedule. Each run covers the
+// window up to its scheduled time, for example the last 24 hours at 06:00.
+// The scope is a reactor, a list of devices, or every device when both are
+// empty.
+type ReportSchedule struct {
+	ID             uint32     `json:"id"`
+	Name           string     `json:"name"`
+	CronExpression string     `json:"cronExpression"`
+	Timezone       string     `json:"timezone"`
+	Kind           string     `json:"kind"`
+	ReactorID      *uint32    `json:"reactorId"`
+	DeviceIDs      []uint32   `json:"deviceIds"`
+	WindowSeconds  int64      `json:"windowSeconds"`
+	Recipients     []string   `json:"recipients"`
+	Enabled        bool       `json:"enabled"`
+	NextRunAt      time.Time  `json:"nextRunAt"`
+	LastRunAt      *time.Time `json:"lastRunAt"`
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedBy      uint32     `json:"createdBy"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+const (
+	ReportScheduleKindReadingsXLSX = "readings_xlsx"
+	ReportScheduleKindReadingsCSV  = "readings_csv"
+)
+
+// maxReportScheduleWindow bounds how far back one scheduled report reaches.
+const maxReportScheduleWindow = 366 * 24 * time.Hour
+
+func (s *ReportSchedule) Window() time.Duration {
+	return time.Duration(s.WindowSeconds) * time.Second
+}
+
+// Next returns the first run after the given time, evaluating the cron
+// expression in the schedule's timezone.
+func (s *ReportSchedule) Next(after time.Time) (time.Time, error) {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "unknown timezone %q", s.Timezone)
+	}
+
+	schedule, err := cron.ParseStandard(s.CronExpression)
+	if err != nil {
+		return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "invalid cron expression %q: %v", s.CronExpression, err)
+	}
+
+	next := schedule.Next(after.In(location))
+	if next.IsZero() {
+		return time.Time{}, pkg.Errorf(pkg.INVALID_ERROR, "cron expression %q never runs", s.CronExpression)
+	}
+
+	return next.UTC(), nil
+}
+
+func (s *ReportSchedule) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return pkg.Errorf(pkg.INVALID_ERROR, "name is required")
+	}
+
+	if s.Kind != ReportScheduleKindReadingsXLSX && s.Kind != ReportScheduleKindReadingsCSV {
+		return pkg.Errorf(pkg.INVALID_ERROR, "kind must be %q or %q", ReportScheduleKindReadingsXLSX, ReportScheduleKindReadingsCSV)
+	}
+
+	if s.ReactorID != nil && len(s.DeviceIDs) > 0 {
+		return pkg.Errorf(pkg.INVALID_ERROR, "use either reactorId or deviceIds, not both")
+	}
+
+	if s.WindowSeconds <= 0 || s.Window() > maxReportScheduleWindow {
+		return pkg.Errorf(pkg.INVALID_ERROR, "window must be between 1 second and %d days", int(maxReportScheduleWindow.Hours()/24))
+	}
+
+	if len(s.Recipients) == 0 {
+		return pkg.Errorf(pkg.INVALID_ERROR, "at least one recipient is required")
+	}
+
+	for _, recipient := range s.Recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return pkg.Errorf(pkg.INVALID_ERROR, "invalid recipient %q", recipient)
+		}
+	}
+
+	_, err := s.Next(time.Now())
+
+	return err
+}
+
+type ReportScheduleRepository interface {
+	CreateReportSchedule(ctx context.Context, schedule *ReportSchedule) (*ReportSchedule, error)
+	GetReportScheduleByID(ctx context.Context, id uint32) (*ReportSchedule, error)
+	ListReportSchedules(ctx context.Context) ([]*ReportSchedule, error)
+	UpdateReportSchedule(ctx context.Context, schedule *ReportSchedule) (*ReportSchedule, error)
+	DeleteReportSchedule(ctx context.Context, id uint32) error
+
+	// ClaimDueReportSchedule moves the next run of a due schedule forward
+	// while holding its row lock, so only one replica runs it. It returns
+	// the schedule as it was before, whose NextRunAt is the claimed run, or
+	// nil when nothing is due. Due schedules whose next run cannot be
+	// computed are disabled along the way.
+	ClaimDueReportSchedule(ctx context.Context, now time.Time) (*ReportSchedule, error)
+	// SetReportScheduleError records the outcome of the last run; an empty
+	// message clears it.
+	SetReportScheduleError(ctx context.Context, id uint32, message string) error
+}
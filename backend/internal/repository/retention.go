@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// RETENTION POLICIES
+// Readings are kept at three resolutions: raw, 1-minute rollups and hourly
+// rollups. A policy sets how many days each tier is kept, nil meaning
+// forever. The global policy has no device; a device policy replaces it
+// entirely for that device.
+type RetentionPolicy struct {
+	ID                        uint32    `json:"id"`
+	DeviceID                  *uint32   `json:"deviceId"`
+	RawRetentionDays          *int32    `json:"rawRetentionDays"`
+	MinuteRollupRetentionDays *int32    `json:"minuteRollupRetentionDays"`
+	HourRollupRetentionDays   *int32    `json:"hourRollupRetentionDays"`
+	CreatedAt                 time.Time `json:"createdAt"`
+	UpdatedAt                 time.Time `json:"updatedAt"`
+}
+
+// Validate checks that every tier is kept at least as long as the finer tier
+// it is built from, so data is never dropped before it has been rolled up.
+func (p *RetentionPolicy) Validate() error {
+	tiers := []struct {
+		name string
+		days *int32
+	}{
+		{"raw", p.RawRetentionDays},
+		{"minute rollup", p.MinuteRollupRetentionDays},
+		{"hour rollup", p.HourRollupRetentionDays},
+	}
+
+	for i, tier := range tiers {
+		if tier.days == nil {
+			continue
+		}
+
+		if *tier.days <= 0 {
+			return pkg.Errorf(pkg.INVALID_ERROR, "%s retention must be a positive number of days", tier.name)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		finer := tiers[i-1]
+		if finer.days == nil || *finer.days > *tier.days {
+			return pkg.Errorf(pkg.INVALID_ERROR, "%s retention must not be shorter than %s retention", tier.name, finer.name)
+		}
+	}
+
+	return nil
+}
+
+// Reading resolutions, from finest to coarsest.
+const (
+	ResolutionRaw    = "raw"
+	ResolutionMinute = "1m"
+	ResolutionHour   = "1h"
+)
+
+// RetentionResult counts the rows a retention run deleted per tier.
+type RetentionResult struct {
+	Readings      int64
+	MinuteRollups int64
+	HourRollups   int64
+}
+
+type RetentionRepository interface {
+	ListRetentionPolicies(ctx context.Context) ([]*RetentionPolicy, error)
+	UpdateGlobalRetentionPolicy(ctx context.Context, policy *RetentionPolicy) (*RetentionPolicy, error)
+	UpsertDeviceRetentionPolicy(ctx context.Context, policy *RetentionPolicy) (*RetentionPolicy, error)
+	DeleteDeviceRetentionPolicy(ctx context.Context, deviceID uint32) error
+
+	// used by the rollup job
+	RollupReadings(ctx context.Context, lag, window time.Duration) error
+	ApplyRetention(ctx context.Context, batchSize int) (*RetentionResult, error)
+}
@@ -40,9 +40,13 @@ type DashboardStats struct {
 	TotalDevices                     uint32  `json:"totalDevices"`
 	ActiveDevices                    uint32  `json:"activeDevices"`
 	InactiveDevices                  uint32  `json:"inactiveDevices"`
+	OnlineDevices                    uint32  `json:"onlineDevices"`
+	StaleDevices                     uint32  `json:"staleDevices"`
+	OfflineDevices                   uint32  `json:"offlineDevices"`
 	TotalReactors                    uint32  `json:"totalReactors"`
 	ActiveReactors                   uint32  `json:"activeReactors"`
 	InactiveReactors                 uint32  `json:"inactiveReactors"`
+	RunningExperiments               uint32  `json:"runningExperiments"`
 	ExperimentsRunToday              uint32  `json:"experimentsRunToday"`
 	ExperimentsRunThisWeek           uint32  `json:"experimentsRunThisWeek"`
 	AverageExperimentDurationSeconds float64 `json:"averageExperimentDurationSeconds"`
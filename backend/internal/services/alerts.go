@@ -0,0 +1,11 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+)
+
+type AlertService interface {
+	EvaluateReading(ctx context.Context, reading *repository.Reading) error
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+)
+
+// FileStorage keeps the content of uploaded files under keys chosen by the
+// caller. Metadata such as the file name and checksum is stored separately
+// by the FileRepository.
+type FileStorage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns a NOT_FOUND_ERROR when nothing is stored under key.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a link that downloads the file without a session
+	// until it expires.
+	SignedURL(ctx context.Context, file *repository.File, expiry time.Duration) (string, error)
+}
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+)
+
+type IngestService interface {
+	IngestReading(ctx context.Context, deviceID uint32, payload any) (*repository.Reading, error)
+	IngestReadings(ctx context.Context, deviceID uint32, readings []*repository.Reading) (*repository.ReadingBatchResult, error)
+}
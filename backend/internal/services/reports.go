@@ -2,9 +2,85 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
 	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
 )
 
+// ReadingExportOptions selects the readings to export and how to write them.
+type ReadingExportOptions struct {
+	DeviceID uint32
+	Start    time.Time
+	End      time.Time
+	Format   string
+	Columns  []string       // flattened payload keys, defaults to the device channels
+	Location *time.Location // timezone for timestamps, UTC when nil
+}
+
+// ReadingReportOptions selects the devices of a workbook report, either all
+// devices of a reactor or an explicit list. Charts is optional. The options
+// are stored as the params of report jobs, except Progress which is called
+// after each device sheet.
+type ReadingReportOptions struct {
+	ReactorID *uint32               `json:"reactorId,omitempty"`
+	DeviceIDs []uint32              `json:"deviceIds,omitempty"`
+	Start     time.Time             `json:"start"`
+	End       time.Time             `json:"end"`
+	Charts    *ReadingChartOptions  `json:"charts,omitempty"`
+	Progress  func(done, total int) `json:"-"`
+}
+
+// ReadingChartOptions adds line charts of payload channels over time to every
+// device sheet. With SharedAxis the channels are drawn on one chart, otherwise
+// each channel gets a chart and value axis of its own.
+type ReadingChartOptions struct {
+	Channels   []string `json:"channels"`
+	SharedAxis bool     `json:"sharedAxis"`
+}
+
+// ExperimentReportOptions are the params of an experiment PDF report job.
+type ExperimentReportOptions struct {
+	ExperimentID uint32 `json:"experimentId"`
+}
+
+// ExperimentComparisonOptions selects the experiments to compare, in the
+// order they are shown, and optionally the payload keys of their curves.
+// Without keys every numeric key is resampled.
+type ExperimentComparisonOptions struct {
+	ExperimentIDs []uint32 `json:"experimentIds"`
+	Keys          []string `json:"keys,omitempty"`
+}
+
 type ReportService interface {
-	GenerateReadingsReport(ctx context.Context, deviceID uint32, startDate, endDate time.Time) ([]byte, error)
+	GenerateReadingsReport(ctx context.Context, options ReadingReportOptions) ([]byte, error)
+	ExportReadings(ctx context.Context, w io.Writer, options ReadingExportOptions) error
+	GenerateExperimentReport(ctx context.Context, experimentID uint32) ([]byte, error)
+	// RunReportJob builds the file of a background report job, reporting
+	// progress as a percentage.
+	RunReportJob(ctx context.Context, job *repository.ReportJob, progress func(percent int32)) (*repository.ReportJobFile, error)
+	// RenderScheduledReport builds the attachments of one run of a
+	// schedule covering start to end.
+	RenderScheduledReport(ctx context.Context, schedule *repository.ReportSchedule, start, end time.Time) ([]*repository.ReportJobFile, error)
+	// ExperimentRunStats returns the run statistics of an experiment,
+	// computing and storing them when missing or when refresh is set.
+	ExperimentRunStats(ctx context.Context, experimentID uint32, refresh bool) (*repository.ExperimentRunStats, error)
+	// CompareExperiments lines up the parameters, analytical tests and
+	// sensor curves of several experiments, and
+	// GenerateExperimentComparisonReport writes the same as a workbook.
+	CompareExperiments(ctx context.Context, options ExperimentComparisonOptions) (*repository.ExperimentComparison, error)
+	GenerateExperimentComparisonReport(ctx context.Context, options ExperimentComparisonOptions) ([]byte, error)
+}
+
+// ReportJobDownloadURL is the link a finished job is downloaded from. The
+// token in it stands in for a session so it can be sent by email.
+func ReportJobDownloadURL(baseURL string, job *repository.ReportJob) string {
+	return fmt.Sprintf("%s/api/v1/report-jobs/%d/download?token=%s", strings.TrimSuffix(baseURL, "/"), job.ID, job.DownloadToken)
 }
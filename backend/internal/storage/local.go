@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+var _ services.FileStorage = (*LocalStorage)(nil)
+
+// LocalStorage keeps files in a directory on disk. Signed links point back at
+// the API, which checks the signature and streams the file.
+type LocalStorage struct {
+	root    string
+	baseURL string
+	secret  string
+}
+
+func NewLocalStorage(root, baseURL, secret string) (*LocalStorage, error) {
+	if root == "" {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "local storage path cannot be empty")
+	}
+
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create storage directory: %v", err)
+	}
+
+	return &LocalStorage{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  secret,
+	}, nil
+}
+
+// path resolves a key inside the storage directory.
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", pkg.Errorf(pkg.INVALID_ERROR, "invalid storage key %q", key)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so a failed upload never leaves a
+// partial file under key.
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create storage directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to write file: %v", err)
+	}
+	if written != size {
+		return pkg.Errorf(pkg.INVALID_ERROR, "file is %d bytes, expected %d", written, size)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to store file: %v", err)
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "stored file %q not found", key)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to open file: %v", err)
+	}
+
+	return file, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete file: %v", err)
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) SignedURL(ctx context.Context, file *repository.File, expiry time.Duration) (string, error) {
+	expires := time.Now().Add(expiry)
+
+	return fmt.Sprintf("%s/api/v1/files/%d/signed-download?expires=%d&signature=%s",
+		s.baseURL, file.ID, expires.Unix(), SignDownload(s.secret, file.ID, expires)), nil
+}
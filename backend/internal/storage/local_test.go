@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+func newTestLocalStorage(t *testing.T) (*LocalStorage, string) {
+	t.Helper()
+
+	root := filepath.Join(t.TempDir(), "files")
+	s, err := NewLocalStorage(root, "https://zen.example.com/", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	return s, root
+}
+
+func readStored(t *testing.T, s *LocalStorage, key string) string {
+	t.Helper()
+
+	r, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading %q: %v", key, err)
+	}
+
+	return string(data)
+}
+
+func TestLocalStoragePutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestLocalStorage(t)
+
+	key := "files/2026/10/abc123"
+	content := "cube strength results"
+	if err := s.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if got := readStored(t, s, key); got != content {
+		t.Errorf("Get() = %q, want %q", got, content)
+	}
+
+	// putting the same key again replaces the file
+	replaced := "replaced"
+	if err := s.Put(ctx, key, strings.NewReader(replaced), int64(len(replaced)), "text/plain"); err != nil {
+		t.Fatalf("Put again: %v", err)
+	}
+	if got := readStored(t, s, key); got != replaced {
+		t.Errorf("Get() after replace = %q, want %q", got, replaced)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := s.Get(ctx, key); pkg.ErrorCode(err) != pkg.NOT_FOUND_ERROR {
+		t.Errorf("Get() after delete = %v, want a not found error", err)
+	}
+
+	// deleting a missing file is not an error
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing file = %v, want nil", err)
+	}
+}
+
+func TestLocalStorageRejectsKeysOutsideRoot(t *testing.T) {
+	ctx := context.Background()
+	s, root := newTestLocalStorage(t)
+
+	// a file next to the storage directory that no key may reach
+	outside := filepath.Join(filepath.Dir(root), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", outside, err)
+	}
+
+	keys := []string{
+		"",
+		"../secret.txt",
+		"files/../../secret.txt",
+		"/etc/passwd",
+		outside,
+	}
+
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			if err := s.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); pkg.ErrorCode(err) != pkg.INVALID_ERROR {
+				t.Errorf("Put(%q) = %v, want an invalid error", key, err)
+			}
+
+			if r, err := s.Get(ctx, key); pkg.ErrorCode(err) != pkg.INVALID_ERROR {
+				if r != nil {
+					r.Close()
+				}
+				t.Errorf("Get(%q) = %v, want an invalid error", key, err)
+			}
+
+			if err := s.Delete(ctx, key); pkg.ErrorCode(err) != pkg.INVALID_ERROR {
+				t.Errorf("Delete(%q) = %v, want an invalid error", key, err)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(outside)
+	if err != nil || string(data) != "secret" {
+		t.Errorf("file outside the storage directory was changed: %q, %v", data, err)
+	}
+}
+
+func TestLocalStoragePutSizeMismatch(t *testing.T) {
+	ctx := context.Background()
+	s, root := newTestLocalStorage(t)
+
+	tests := []struct {
+		name    string
+		content string
+		size    int64
+	}{
+		{name: "shorter than declared", content: "short", size: 10},
+		{name: "longer than declared", content: "longer than declared", size: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "files/2026/10/mismatch"
+
+			err := s.Put(ctx, key, strings.NewReader(tt.content), tt.size, "text/plain")
+			if pkg.ErrorCode(err) != pkg.INVALID_ERROR {
+				t.Fatalf("Put() = %v, want an invalid error", err)
+			}
+
+			if _, err := s.Get(ctx, key); pkg.ErrorCode(err) != pkg.NOT_FOUND_ERROR {
+				t.Errorf("Get() after a failed put = %v, want a not found error", err)
+			}
+		})
+	}
+
+	// the temporary files of failed uploads are removed
+	entries, err := os.ReadDir(filepath.Join(root, "files", "2026", "10"))
+	if err != nil {
+		t.Fatalf("reading the storage directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("storage directory holds %d entries after failed puts, want none", len(entries))
+	}
+}
+
+func TestLocalStorageSignedURL(t *testing.T) {
+	s, _ := newTestLocalStorage(t)
+
+	link, err := s.SignedURL(context.Background(), testFile(), time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", link, err)
+	}
+
+	if want := "https://zen.example.com/api/v1/files/42/signed-download"; u.Scheme+"://"+u.Host+u.Path != want {
+		t.Errorf("SignedURL() = %q, want a link to %s", link, want)
+	}
+
+	expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid expires in %q: %v", link, err)
+	}
+
+	if err := VerifyDownload("secret", 42, expires, u.Query().Get("signature")); err != nil {
+		t.Errorf("VerifyDownload() of a signed link = %v, want nil", err)
+	}
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ services.FileStorage = (*S3Storage)(nil)
+
+// S3Storage keeps files in a bucket of an S3-compatible service such as AWS
+// S3 or MinIO. Signed links are presigned by the service and download
+// straight from the bucket.
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Storage connects to the configured endpoint and creates the bucket
+// when it does not exist yet.
+func NewS3Storage(config pkg.Config) (*S3Storage, error) {
+	if config.STORAGE_S3_ENDPOINT == "" || config.STORAGE_S3_BUCKET == "" {
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "s3 storage endpoint and bucket cannot be empty")
+	}
+
+	client, err := minio.New(config.STORAGE_S3_ENDPOINT, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.STORAGE_S3_ACCESS_KEY, config.STORAGE_S3_SECRET_KEY, ""),
+		Secure: config.STORAGE_S3_USE_SSL,
+		Region: config.STORAGE_S3_REGION,
+	})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create s3 client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, config.STORAGE_S3_BUCKET)
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to check s3 bucket: %v", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, config.STORAGE_S3_BUCKET, minio.MakeBucketOptions{Region: config.STORAGE_S3_REGION}); err != nil {
+			return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to create s3 bucket: %v", err)
+		}
+	}
+
+	return &S3Storage{
+		client: client,
+		bucket: config.STORAGE_S3_BUCKET,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to upload file: %v", err)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get file: %v", err)
+	}
+
+	// GetObject is lazy; stat it so a missing object fails here rather than
+	// halfway through the response
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, pkg.Errorf(pkg.NOT_FOUND_ERROR, "stored file %q not found", key)
+		}
+		return nil, pkg.Errorf(pkg.INTERNAL_ERROR, "failed to get file: %v", err)
+	}
+
+	return object, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return pkg.Errorf(pkg.INTERNAL_ERROR, "failed to delete file: %v", err)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) SignedURL(ctx context.Context, file *repository.File, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("response-content-type", file.ContentType)
+	params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+
+	signed, err := s.client.PresignedGetObject(ctx, s.bucket, file.StorageKey, expiry, params)
+	if err != nil {
+		return "", pkg.Errorf(pkg.INTERNAL_ERROR, "failed to sign download url: %v", err)
+	}
+
+	return signed.String(), nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+// newTestS3Storage connects to the MinIO server at ZEN_TEST_S3_ENDPOINT, such
+// as localhost:9000 after make createMinio, and skips the test when it is not
+// set. Each test gets a fresh bucket.
+func newTestS3Storage(t *testing.T) *S3Storage {
+	t.Helper()
+
+	endpoint := os.Getenv("ZEN_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("ZEN_TEST_S3_ENDPOINT is not set")
+	}
+
+	envOr := func(key, fallback string) string {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	s, err := NewS3Storage(pkg.Config{
+		STORAGE_S3_ENDPOINT:   endpoint,
+		STORAGE_S3_BUCKET:     fmt.Sprintf("zen-test-%d", time.Now().UnixNano()),
+		STORAGE_S3_ACCESS_KEY: envOr("ZEN_TEST_S3_ACCESS_KEY", "minioadmin"),
+		STORAGE_S3_SECRET_KEY: envOr("ZEN_TEST_S3_SECRET_KEY", "minioadmin"),
+		STORAGE_S3_USE_SSL:    os.Getenv("ZEN_TEST_S3_USE_SSL") == "true",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := s.client.RemoveBucket(context.Background(), s.bucket); err != nil {
+			t.Logf("failed to remove test bucket %s: %v", s.bucket, err)
+		}
+	})
+
+	return s
+}
+
+func TestS3StoragePutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s := newTestS3Storage(t)
+
+	file := testFile()
+	content := "cube strength results"
+	if err := s.Put(ctx, file.StorageKey, strings.NewReader(content), int64(len(content)), file.ContentType); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	r, err := s.Get(ctx, file.StorageKey)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("reading the object: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("Get() = %q, want %q", data, content)
+	}
+
+	link, err := s.SignedURL(ctx, file, time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+
+	resp, err := http.Get(link)
+	if err != nil {
+		t.Fatalf("downloading the signed link: %v", err)
+	}
+	data, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("reading the signed download: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(data) != content {
+		t.Errorf("signed download = %d %q, want 200 %q", resp.StatusCode, data, content)
+	}
+	if got := resp.Header.Get("Content-Type"); got != file.ContentType {
+		t.Errorf("signed download Content-Type = %q, want %q", got, file.ContentType)
+	}
+
+	if err := s.Delete(ctx, file.StorageKey); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := s.Get(ctx, file.StorageKey); pkg.ErrorCode(err) != pkg.NOT_FOUND_ERROR {
+		t.Errorf("Get() after delete = %v, want a not found error", err)
+	}
+
+	// deleting a missing object is not an error
+	if err := s.Delete(ctx, file.StorageKey); err != nil {
+		t.Errorf("Delete() of a missing object = %v, want nil", err)
+	}
+}
+
+func TestS3StoragePutSizeMismatch(t *testing.T) {
+	ctx := context.Background()
+	s := newTestS3Storage(t)
+
+	key := "files/2026/10/mismatch"
+	if err := s.Put(ctx, key, strings.NewReader("short"), 10, "text/plain"); err == nil {
+		t.Fatal("Put() of fewer bytes than declared = nil, want an error")
+	}
+
+	if _, err := s.Get(ctx, key); pkg.ErrorCode(err) != pkg.NOT_FOUND_ERROR {
+		t.Errorf("Get() after a failed put = %v, want a not found error", err)
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/services"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+
+	// keyBytes is the entropy of generated storage keys.
+	keyBytes = 16
+)
+
+// NewFileStorage opens the backend selected by STORAGE_BACKEND.
+func NewFileStorage(config pkg.Config) (services.FileStorage, error) {
+	switch strings.ToLower(config.STORAGE_BACKEND) {
+	case BackendLocal, "":
+		return NewLocalStorage(config.STORAGE_LOCAL_PATH, config.STORAGE_DOWNLOAD_BASE_URL, config.TOKEN_SYMMETRIC_KEY)
+	case BackendS3:
+		return NewS3Storage(config)
+	default:
+		return nil, pkg.Errorf(pkg.INVALID_ERROR, "unknown storage backend %q, must be local or s3", config.STORAGE_BACKEND)
+	}
+}
+
+// NewKey returns a storage key for a new upload. Keys are random so file
+// names chosen by users never reach the backend.
+func NewKey(now time.Time) (string, error) {
+	token, err := pkg.GenerateToken(keyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("files/%s/%s", now.UTC().Format("2006/01"), token), nil
+}
+
+// SignDownload signs the download of a file until expires. Signed links are
+// served by the API, so they only need a secret of the API itself.
+func SignDownload(secret string, fileID uint32, expires time.Time) string {
+	mac := hmac.New(sha256.New, []byte("file-download:"+secret))
+	mac.Write([]byte(strconv.FormatUint(uint64(fileID), 10) + ":" + strconv.FormatInt(expires.Unix(), 10)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyDownload checks a signature made by SignDownload and that it has not
+// expired.
+func VerifyDownload(secret string, fileID uint32, expires int64, signature string) error {
+	expiresAt := time.Unix(expires, 0)
+	if time.Now().After(expiresAt) {
+		return pkg.Errorf(pkg.FORBIDDEN_ERROR, "download link has expired")
+	}
+
+	expected := SignDownload(secret, fileID, expiresAt)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return pkg.Errorf(pkg.FORBIDDEN_ERROR, "invalid download signature")
+	}
+
+	return nil
+}
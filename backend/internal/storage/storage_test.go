@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+	"github.com/Edwin9301/Zen/backend/pkg"
+)
+
+func testFile() *repository.File {
+	return &repository.File{
+		ID:          42,
+		StorageKey:  "files/2026/10/abc123",
+		FileName:    "results.pdf",
+		ContentType: repository.FileContentTypePDF,
+		Size:        21,
+	}
+}
+
+func TestVerifyDownload(t *testing.T) {
+	const secret = "secret"
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	signature := SignDownload(secret, 42, expires)
+
+	tests := []struct {
+		name      string
+		secret    string
+		fileID    uint32
+		expires   int64
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			secret:    secret,
+			fileID:    42,
+			expires:   expires.Unix(),
+			signature: signature,
+		},
+		{
+			name:      "upper case signature",
+			secret:    secret,
+			fileID:    42,
+			expires:   expires.Unix(),
+			signature: strings.ToUpper(signature),
+		},
+		{
+			name:      "expired",
+			secret:    secret,
+			fileID:    42,
+			expires:   time.Now().Add(-time.Minute).Unix(),
+			signature: SignDownload(secret, 42, time.Now().Add(-time.Minute)),
+			wantErr:   true,
+		},
+		{
+			name:      "expiry pushed back",
+			secret:    secret,
+			fileID:    42,
+			expires:   expires.Add(24 * time.Hour).Unix(),
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "another file",
+			secret:    secret,
+			fileID:    43,
+			expires:   expires.Unix(),
+			signature: signature,
+			wantErr:   true,
+		},
+		{
+			name:      "tampered signature",
+			secret:    secret,
+			fileID:    42,
+			expires:   expires.Unix(),
+			signature: tamper(signature),
+			wantErr:   true,
+		},
+		{
+			name:      "truncated signature",
+			secret:    secret,
+			fileID:    42,
+			expires:   expires.Unix(),
+			signature: signature[:len(signature)/2],
+			wantErr:   true,
+		},
+		{
+			name:      "empty signature",
+			secret:    secret,
+			fileID:    42,
+			expires:   expires.Unix(),
+			signature: "",
+			wantErr:   true,
+		},
+		{
+			name:      "other secret",
+			secret:    "rotated",
+			fileID:    42,
+			expires:   expires.Unix(),
+			signature: signature,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyDownload(tt.secret, tt.fileID, tt.expires, tt.signature)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("VerifyDownload() = %v, want nil", err)
+				}
+				return
+			}
+
+			if pkg.ErrorCode(err) != pkg.FORBIDDEN_ERROR {
+				t.Errorf("VerifyDownload() = %v, want a forbidden error", err)
+			}
+		})
+	}
+}
+
+// tamper flips the last hex digit of a signature.
+func tamper(signature string) string {
+	last := signature[len(signature)-1]
+	flipped := byte('0')
+	if last == '0' {
+		flipped = '1'
+	}
+
+	return signature[:len(signature)-1] + string(flipped)
+}
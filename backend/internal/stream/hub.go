@@ -0,0 +1,110 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/Edwin9301/Zen/backend/internal/repository"
+)
+
+// subscriptionBuffer is how many readings a subscriber may fall behind before
+// it is dropped.
+const subscriptionBuffer = 256
+
+// Hub fans new readings out to live subscribers within this process.
+// Publishing never blocks: a subscriber whose buffer is full is closed so a
+// slow client cannot hold up delivery to everyone else.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uint32]map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C <-chan *repository.Reading
+
+	ch        chan *repository.Reading
+	deviceIDs []uint32
+	closeOnce sync.Once
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: map[uint32]map[*Subscription]struct{}{},
+	}
+}
+
+// Subscribe registers a subscriber for readings of the given devices.
+// The subscription channel is closed on Unsubscribe or when the subscriber
+// falls too far behind.
+func (h *Hub) Subscribe(deviceIDs []uint32) *Subscription {
+	ch := make(chan *repository.Reading, subscriptionBuffer)
+	sub := &Subscription{
+		C:         ch,
+		ch:        ch,
+		deviceIDs: deviceIDs,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, deviceID := range deviceIDs {
+		if h.subscribers[deviceID] == nil {
+			h.subscribers[deviceID] = map[*Subscription]struct{}{}
+		}
+		h.subscribers[deviceID][sub] = struct{}{}
+	}
+
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub)
+}
+
+// HasSubscribers reports whether anyone is listening for a device, so callers
+// can skip loading readings nobody will receive.
+func (h *Hub) HasSubscribers(deviceID uint32) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.subscribers[deviceID]) > 0
+}
+
+func (h *Hub) Publish(reading *repository.Reading) {
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for sub := range h.subscribers[reading.DeviceID] {
+		select {
+		case sub.ch <- reading:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(slow) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, sub := range slow {
+		h.remove(sub)
+	}
+}
+
+// remove must be called with the write lock held.
+func (h *Hub) remove(sub *Subscription) {
+	for _, deviceID := range sub.deviceIDs {
+		delete(h.subscribers[deviceID], sub)
+		if len(h.subscribers[deviceID]) == 0 {
+			delete(h.subscribers, deviceID)
+		}
+	}
+
+	sub.closeOnce.Do(func() { close(sub.ch) })
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	apiKeyPrefix      = "zen_"
+	apiKeyRandomBytes = 32
+	apiKeyPrefixLen   = 12
+)
+
+// GenerateAPIKey returns a new random device API key and the short prefix
+// that is safe to store and display for identifying it later.
+func GenerateAPIKey() (string, string, error) {
+	bytes := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", Errorf(INTERNAL_ERROR, "failed to generate api key: %s", err.Error())
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(bytes)
+
+	return key, key[:apiKeyPrefixLen], nil
+}
+
+// HashAPIKey returns the hex encoded sha256 hash of an API key. Keys are
+// high entropy so a fast hash is enough and keeps lookups indexable.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateToken returns a random hex token of n bytes for links that must be
+// hard to guess, such as report downloads.
+func GenerateToken(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", Errorf(INTERNAL_ERROR, "failed to generate token: %s", err.Error())
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
@@ -23,6 +23,63 @@ type Config struct {
 	EMAIL_SENDER_NAME       string        `mapstructure:"EMAIL_SENDER_NAME"`
 	EMAIL_SENDER_ADDRESS    string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
 	EMAIL_SENDER_PASSWORD   string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+
+	// Device clock skew handling for reading timestamps
+	READING_CLOCK_SKEW_POLICY string        `mapstructure:"READING_CLOCK_SKEW_POLICY"`
+	READING_MAX_CLOCK_SKEW    time.Duration `mapstructure:"READING_MAX_CLOCK_SKEW"`
+	READING_MAX_BACKFILL_AGE  time.Duration `mapstructure:"READING_MAX_BACKFILL_AGE"`
+
+	// Device connectivity, measured in multiples of each device's expected
+	// reporting interval
+	DEVICE_CONNECTIVITY_CHECK_INTERVAL time.Duration `mapstructure:"DEVICE_CONNECTIVITY_CHECK_INTERVAL"`
+	DEVICE_STALE_AFTER_INTERVALS       float64       `mapstructure:"DEVICE_STALE_AFTER_INTERVALS"`
+	DEVICE_OFFLINE_AFTER_INTERVALS     float64       `mapstructure:"DEVICE_OFFLINE_AFTER_INTERVALS"`
+
+	// Monthly sensor_readings partitions created ahead of time
+	READING_PARTITION_MONTHS_AHEAD   int           `mapstructure:"READING_PARTITION_MONTHS_AHEAD"`
+	READING_PARTITION_CHECK_INTERVAL time.Duration `mapstructure:"READING_PARTITION_CHECK_INTERVAL"`
+
+	// Reading rollups and retention
+	READING_ROLLUP_INTERVAL      time.Duration `mapstructure:"READING_ROLLUP_INTERVAL"`
+	READING_ROLLUP_LAG           time.Duration `mapstructure:"READING_ROLLUP_LAG"`
+	READING_ROLLUP_WINDOW        time.Duration `mapstructure:"READING_ROLLUP_WINDOW"`
+	READING_RETENTION_BATCH_SIZE int           `mapstructure:"READING_RETENTION_BATCH_SIZE"`
+
+	// Background report jobs
+	REPORT_JOB_WORKERS       int           `mapstructure:"REPORT_JOB_WORKERS"`
+	REPORT_JOB_POLL_INTERVAL time.Duration `mapstructure:"REPORT_JOB_POLL_INTERVAL"`
+	REPORT_JOB_LEASE         time.Duration `mapstructure:"REPORT_JOB_LEASE"`
+	REPORT_JOB_MAX_ATTEMPTS  int           `mapstructure:"REPORT_JOB_MAX_ATTEMPTS"`
+	REPORT_JOB_TTL           time.Duration `mapstructure:"REPORT_JOB_TTL"`
+	REPORT_DOWNLOAD_BASE_URL string        `mapstructure:"REPORT_DOWNLOAD_BASE_URL"`
+
+	// Scheduled reports are checked for due runs on this interval
+	REPORT_SCHEDULE_CHECK_INTERVAL time.Duration `mapstructure:"REPORT_SCHEDULE_CHECK_INTERVAL"`
+
+	// Optional MQTT ingestion bridge
+	MQTT_ENABLED       bool          `mapstructure:"MQTT_ENABLED"`
+	MQTT_BROKER_URL    string        `mapstructure:"MQTT_BROKER_URL"`
+	MQTT_CLIENT_ID     string        `mapstructure:"MQTT_CLIENT_ID"`
+	MQTT_USERNAME      string        `mapstructure:"MQTT_USERNAME"`
+	MQTT_PASSWORD      string        `mapstructure:"MQTT_PASSWORD"`
+	MQTT_TOPIC_PATTERN string        `mapstructure:"MQTT_TOPIC_PATTERN"`
+	MQTT_QOS           byte          `mapstructure:"MQTT_QOS"`
+	MQTT_DEDUP_TTL     time.Duration `mapstructure:"MQTT_DEDUP_TTL"`
+
+	// File storage for uploaded documents, on local disk or an S3-compatible
+	// bucket
+	STORAGE_BACKEND               string        `mapstructure:"STORAGE_BACKEND"`
+	STORAGE_LOCAL_PATH            string        `mapstructure:"STORAGE_LOCAL_PATH"`
+	STORAGE_S3_ENDPOINT           string        `mapstructure:"STORAGE_S3_ENDPOINT"`
+	STORAGE_S3_REGION             string        `mapstructure:"STORAGE_S3_REGION"`
+	STORAGE_S3_BUCKET             string        `mapstructure:"STORAGE_S3_BUCKET"`
+	STORAGE_S3_ACCESS_KEY         string        `mapstructure:"STORAGE_S3_ACCESS_KEY"`
+	STORAGE_S3_SECRET_KEY         string        `mapstructure:"STORAGE_S3_SECRET_KEY"`
+	STORAGE_S3_USE_SSL            bool          `mapstructure:"STORAGE_S3_USE_SSL"`
+	STORAGE_MAX_UPLOAD_SIZE       int64         `mapstructure:"STORAGE_MAX_UPLOAD_SIZE"`
+	STORAGE_ALLOWED_CONTENT_TYPES []string      `mapstructure:"STORAGE_ALLOWED_CONTENT_TYPES"`
+	STORAGE_SIGNED_URL_TTL        time.Duration `mapstructure:"STORAGE_SIGNED_URL_TTL"`
+	STORAGE_DOWNLOAD_BASE_URL     string        `mapstructure:"STORAGE_DOWNLOAD_BASE_URL"`
 }
 
 func LoadConfig(path string) (Config, error) {
@@ -42,8 +99,18 @@ func LoadConfig(path string) (Config, error) {
 	}
 
 	var config Config
+	if err := viper.Unmarshal(&config); err != nil {
+		return Config{}, err
+	}
+
+	// the values of repository.ClockSkewPolicy
+	switch config.READING_CLOCK_SKEW_POLICY {
+	case "accept", "clamp", "reject":
+	default:
+		return Config{}, Errorf(INVALID_ERROR, "unknown READING_CLOCK_SKEW_POLICY %q, must be accept, clamp or reject", config.READING_CLOCK_SKEW_POLICY)
+	}
 
-	return config, viper.Unmarshal(&config)
+	return config, nil
 }
 
 func setDefaults() {
@@ -62,4 +129,43 @@ func setDefaults() {
 	viper.SetDefault("EMAIL_SENDER_NAME", "")
 	viper.SetDefault("EMAIL_SENDER_ADDRESS", "")
 	viper.SetDefault("EMAIL_SENDER_PASSWORD", "")
+	viper.SetDefault("READING_CLOCK_SKEW_POLICY", "clamp")
+	viper.SetDefault("READING_MAX_CLOCK_SKEW", 5*time.Minute)
+	viper.SetDefault("READING_MAX_BACKFILL_AGE", 0)
+	viper.SetDefault("DEVICE_CONNECTIVITY_CHECK_INTERVAL", 30*time.Second)
+	viper.SetDefault("DEVICE_STALE_AFTER_INTERVALS", 2)
+	viper.SetDefault("DEVICE_OFFLINE_AFTER_INTERVALS", 5)
+	viper.SetDefault("READING_PARTITION_MONTHS_AHEAD", 3)
+	viper.SetDefault("READING_PARTITION_CHECK_INTERVAL", 12*time.Hour)
+	viper.SetDefault("READING_ROLLUP_INTERVAL", 5*time.Minute)
+	viper.SetDefault("READING_ROLLUP_LAG", time.Minute)
+	viper.SetDefault("READING_ROLLUP_WINDOW", 6*time.Hour)
+	viper.SetDefault("READING_RETENTION_BATCH_SIZE", 10000)
+	viper.SetDefault("REPORT_JOB_WORKERS", 2)
+	viper.SetDefault("REPORT_JOB_POLL_INTERVAL", 5*time.Second)
+	viper.SetDefault("REPORT_JOB_LEASE", 10*time.Minute)
+	viper.SetDefault("REPORT_JOB_MAX_ATTEMPTS", 3)
+	viper.SetDefault("REPORT_JOB_TTL", 7*24*time.Hour)
+	viper.SetDefault("REPORT_DOWNLOAD_BASE_URL", "")
+	viper.SetDefault("REPORT_SCHEDULE_CHECK_INTERVAL", time.Minute)
+	viper.SetDefault("MQTT_ENABLED", false)
+	viper.SetDefault("MQTT_BROKER_URL", "tcp://localhost:1883")
+	viper.SetDefault("MQTT_CLIENT_ID", "zen-backend")
+	viper.SetDefault("MQTT_USERNAME", "")
+	viper.SetDefault("MQTT_PASSWORD", "")
+	viper.SetDefault("MQTT_TOPIC_PATTERN", "zen/devices/{id}/readings")
+	viper.SetDefault("MQTT_QOS", 1)
+	viper.SetDefault("MQTT_DEDUP_TTL", 10*time.Minute)
+	viper.SetDefault("STORAGE_BACKEND", "local")
+	viper.SetDefault("STORAGE_LOCAL_PATH", "./data/files")
+	viper.SetDefault("STORAGE_S3_ENDPOINT", "")
+	viper.SetDefault("STORAGE_S3_REGION", "")
+	viper.SetDefault("STORAGE_S3_BUCKET", "")
+	viper.SetDefault("STORAGE_S3_ACCESS_KEY", "")
+	viper.SetDefault("STORAGE_S3_SECRET_KEY", "")
+	viper.SetDefault("STORAGE_S3_USE_SSL", true)
+	viper.SetDefault("STORAGE_MAX_UPLOAD_SIZE", 20<<20)
+	viper.SetDefault("STORAGE_ALLOWED_CONTENT_TYPES", []string{"application/pdf", "image/png", "image/jpeg"})
+	viper.SetDefault("STORAGE_SIGNED_URL_TTL", 15*time.Minute)
+	viper.SetDefault("STORAGE_DOWNLOAD_BASE_URL", "")
 }
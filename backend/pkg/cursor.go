@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Cursor marks a position in a list ordered by (timestamp, id). It is handed
+// to clients as an opaque string.
+type Cursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        int64     `json:"i"`
+	Backward  bool      `json:"b,omitempty"` // page towards newer items
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, Errorf(INVALID_ERROR, "invalid cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, Errorf(INVALID_ERROR, "invalid cursor")
+	}
+
+	return &cursor, nil
+}
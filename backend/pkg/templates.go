@@ -55,6 +55,51 @@ const (
 			<p style="font-size:14px; color:#888;">This invitation was sent to <strong>{{.Email}}</strong>. If you were not expecting this, you can safely ignore this email.</p>
 		</div>
 	`
+	AlertIncidentTemplate = `
+		<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: auto; padding: 20px; border: 1px solid #eaeaea; border-radius: 10px;">
+			<h2 style="color: {{if .Resolved}}#28A745{{else}}#DC3545{{end}};">{{.Title}}</h2>
+			<p>Alert rule <strong>{{.RuleName}}</strong> on device <strong>{{.DeviceID}}</strong> {{if .Resolved}}is back within range{{else}}was triggered{{end}}.</p>
+
+			<table style="width: 100%; border-collapse: collapse; font-size: 15px;">
+				<tr><td style="padding: 6px 0; color: #888;">Severity</td><td><strong>{{.Severity}}</strong></td></tr>
+				<tr><td style="padding: 6px 0; color: #888;">Condition</td><td>{{.Key}} {{.Comparator}} {{.Threshold}}</td></tr>
+				<tr><td style="padding: 6px 0; color: #888;">Value</td><td>{{.Value}}</td></tr>
+				<tr><td style="padding: 6px 0; color: #888;">Time</td><td>{{.Time}}</td></tr>
+			</table>
+
+			<hr style="margin: 30px 0; border:none; border-top:1px solid #eaeaea;">
+			<p style="font-size:14px; color:#888;">You are receiving this email because you are a recipient of this alert rule in Zen App.</p>
+		</div>
+	`
+	ReportJobTemplate = `
+		<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: auto; padding: 20px; border: 1px solid #eaeaea; border-radius: 10px;">
+			<h2 style="color: {{if .Failed}}#DC3545{{else}}#007BFF{{end}};">{{.Title}}</h2>
+			{{if .Failed}}
+			<p>Your report <strong>{{.Report}}</strong> could not be generated:</p>
+			<p style="color: #555;">{{.Error}}</p>
+			{{else}}
+			<p>Your report <strong>{{.Report}}</strong> is ready to download.</p>
+
+			<p style="text-align: center; margin: 30px 0;">
+			<a href="{{.Link}}" style="display:inline-block; padding:12px 24px; background-color:#007BFF; color:#fff; font-size:16px; text-decoration:none; border-radius:6px;">Download Report</a>
+			</p>
+
+			<p style="font-size:14px; color:#555;">The link is valid until <strong>{{.Expires}}</strong>.</p>
+			{{end}}
+
+			<hr style="margin: 30px 0; border:none; border-top:1px solid #eaeaea;">
+			<p style="font-size:14px; color:#888;">You are receiving this email because you requested this report in Zen App.</p>
+		</div>
+	`
+	ScheduledReportTemplate = `
+		<div style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: auto; padding: 20px; border: 1px solid #eaeaea; border-radius: 10px;">
+			<h2 style="color: #007BFF;">{{.Name}}</h2>
+			<p>Attached {{if eq .Files 1}}is the report{{else}}are the {{.Files}} report files{{end}} for <strong>{{.Start}}</strong> to <strong>{{.End}}</strong>.</p>
+
+			<hr style="margin: 30px 0; border:none; border-top:1px solid #eaeaea;">
+			<p style="font-size:14px; color:#888;">You are receiving this email because you are a recipient of the report schedule <strong>{{.Name}}</strong> in Zen App.</p>
+		</div>
+	`
 )
 
 func GenerateText(title, templateTxt string, payload any) (string, error) {
@@ -29,7 +29,8 @@ export interface Reactor {
   id: number;
   name: string;
   status: 'Active' | 'Inactive';
-  pdfUrl: string;
+  pdfFileId: number | null;
+  pdfUrl?: string;
   pathway: 'Gaseous' | 'Carbonated' | 'Liquid';
 }
 
@@ -49,31 +50,47 @@ export interface LoginResponse {
   user: User;
 }
 
+export interface Quantity {
+  value: number;
+  unit: string;
+}
+
 export interface MaterialFeedstock {
   mixDesign: string;
-  cement: string;
-  fineAggregate: string;
-  coarseAggregate: string;
-  water: string;
-  waterCementRatio: string;
-  blockSizeLength: string;
-  blockSizeWidth: string;
-  blockSizeHeight: string;
+  cement: Quantity | null;
+  fineAggregate: Quantity | null;
+  coarseAggregate: Quantity | null;
+  water: Quantity | null;
+  waterCementRatio: Quantity | null;
+  blockSizeLength: Quantity | null;
+  blockSizeWidth: Quantity | null;
+  blockSizeHeight: Quantity | null;
 }
 
 export interface ExposureConditions {
   co2Form: 'liquid' | 'gas' | 'carbonated';
-  co2Mass: string;
-  injectionPressure: string;
-  headSpace: string;
-  reactionTime: string;
+  co2Mass: Quantity | null;
+  injectionPressure: Quantity | null;
+  headSpace: Quantity | null;
+  reactionTime: Quantity | null;
 }
 
 export interface AnalyticalTest {
   name: string;
   sampleId: string;
   date: Date;
-  pdfUrl: string;
+  fileId: number | null;
+  pdfUrl?: string;
+}
+
+export interface StoredFile {
+  id: number;
+  fileName: string;
+  contentType: string;
+  size: number;
+  checksum: string;
+  createdBy: number;
+  createdAt: string;
 }
 
 export interface BatchExperiment {
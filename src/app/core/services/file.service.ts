@@ -0,0 +1,42 @@
+import { HttpClient } from '@angular/common/http';
+import { inject, Injectable } from '@angular/core';
+import { firstValueFrom, map } from 'rxjs';
+import { StoredFile } from '../models/models';
+
+@Injectable({
+  providedIn: 'root',
+})
+export class FileService {
+  private apiUrl = import.meta.env.NG_APP_APIURL;
+  private http = inject(HttpClient);
+
+  uploadFile = (file: File): Promise<StoredFile> => {
+    const formData = new FormData();
+    formData.append('file', file);
+
+    return firstValueFrom(
+      this.http
+        .post<{ data: StoredFile }>(`${this.apiUrl}/files`, formData)
+        .pipe(map((response) => response.data))
+    );
+  };
+
+  // Opens a stored file through a short-lived signed link, as a new tab
+  // cannot send the session token.
+  openFile = (fileId: number): void => {
+    const tab = window.open('', '_blank');
+
+    this.http
+      .get<{ data: { url: string } }>(`${this.apiUrl}/files/${fileId}/url`)
+      .pipe(map((response) => response.data.url))
+      .subscribe({
+        next: (url) => {
+          if (tab) tab.location.href = url;
+        },
+        error: (error) => {
+          tab?.close();
+          console.error('Error opening file:', error);
+        },
+      });
+  };
+}
@@ -25,13 +25,28 @@ import { FileUploadModule } from 'primeng/fileupload';
 import { ButtonModule } from 'primeng/button';
 import { SelectModule } from 'primeng/select';
 import { BatchExperimentService } from '../batch-experiment.service';
-import { BatchExperiment } from '../../../../core/models/models';
+import { BatchExperiment, Quantity } from '../../../../core/models/models';
 import { MessageService } from 'primeng/api';
 import { finalize } from 'rxjs';
 import { reactorQuery } from '../../reactor/reactor.query';
-import { FirebaseService } from '../../../../core/services/firebase.service';
+import { FileService } from '../../../../core/services/file.service';
 import { ProgressSpinner } from 'primeng/progressspinner';
 
+const QUANTITY_FIELDS = [
+  'cement',
+  'fineAggregate',
+  'coarseAggregate',
+  'water',
+  'waterCementRatio',
+  'blockSizeLength',
+  'blockSizeWidth',
+  'blockSizeHeight',
+  'co2Mass',
+  'injectionPressure',
+  'headSpace',
+  'reactionTime',
+];
+
 @Component({
   selector: 'app-batch-experiment-modal',
   imports: [
@@ -69,7 +84,7 @@ export class BatchExperimentModalComponent {
   selectedFile = signal<File | null>(null);
   uploadingFiles = signal<Map<number, boolean>>(new Map());
 
-  private firebaseService = inject(FirebaseService);
+  private fileService = inject(FileService);
 
   constructor() {
     this.initializeForm();
@@ -139,6 +154,7 @@ export class BatchExperimentModalComponent {
       name: ['', Validators.required],
       sampleId: ['', Validators.required],
       date: [null],
+      fileId: [null],
       pdfUrl: [null],
     });
   }
@@ -156,8 +172,12 @@ export class BatchExperimentModalComponent {
     });
   }
 
-  removeFile(url: string, index: number) {
-    this.analyticalTests.at(index).patchValue({ pdfUrl: null });
+  removeFile(index: number) {
+    this.analyticalTests.at(index).patchValue({ fileId: null, pdfUrl: null });
+  }
+
+  openFile(fileId: number) {
+    this.fileService.openFile(fileId);
   }
 
   populateForm() {
@@ -173,28 +193,28 @@ export class BatchExperimentModalComponent {
       timeEnd: this.timeStringToDate(this.batchExperimentData()?.timeEnd),
 
       mixDesign: this.batchExperimentData()?.materialFeedstock?.mixDesign,
-      cement: this.batchExperimentData()?.materialFeedstock?.cement,
+      cement: this.batchExperimentData()?.materialFeedstock?.cement?.value,
       fineAggregate:
-        this.batchExperimentData()?.materialFeedstock?.fineAggregate,
+        this.batchExperimentData()?.materialFeedstock?.fineAggregate?.value,
       coarseAggregate:
-        this.batchExperimentData()?.materialFeedstock?.coarseAggregate,
-      water: this.batchExperimentData()?.materialFeedstock?.water,
+        this.batchExperimentData()?.materialFeedstock?.coarseAggregate?.value,
+      water: this.batchExperimentData()?.materialFeedstock?.water?.value,
       waterCementRatio:
-        this.batchExperimentData()?.materialFeedstock?.waterCementRatio,
+        this.batchExperimentData()?.materialFeedstock?.waterCementRatio?.value,
       blockSizeLength:
-        this.batchExperimentData()?.materialFeedstock?.blockSizeLength,
+        this.batchExperimentData()?.materialFeedstock?.blockSizeLength?.value,
       blockSizeWidth:
-        this.batchExperimentData()?.materialFeedstock?.blockSizeWidth,
+        this.batchExperimentData()?.materialFeedstock?.blockSizeWidth?.value,
       blockSizeHeight:
-        this.batchExperimentData()?.materialFeedstock?.blockSizeHeight,
+        this.batchExperimentData()?.materialFeedstock?.blockSizeHeight?.value,
 
       co2Form: this.batchExperimentData()?.exposureConditions?.co2Form,
-      co2Mass: this.batchExperimentData()?.exposureConditions?.co2Mass,
+      co2Mass: this.batchExperimentData()?.exposureConditions?.co2Mass?.value,
       injectionPressure:
-        this.batchExperimentData()?.exposureConditions?.injectionPressure,
-      headSpace: this.batchExperimentData()?.exposureConditions?.headSpace,
+        this.batchExperimentData()?.exposureConditions?.injectionPressure?.value,
+      headSpace: this.batchExperimentData()?.exposureConditions?.headSpace?.value,
       reactionTime:
-        this.batchExperimentData()?.exposureConditions?.reactionTime,
+        this.batchExperimentData()?.exposureConditions?.reactionTime?.value,
     });
     this.populateAnalyticalTests(
       this.batchExperimentData()?.analyticalTests || []
@@ -212,6 +232,7 @@ export class BatchExperimentModalComponent {
           name: [test.name ?? '', Validators.required],
           sampleId: [test.sampleId ?? '', Validators.required],
           date: [test.date ? new Date(test.date) : null, Validators.required],
+          fileId: [test.fileId ?? null],
           pdfUrl: [test.pdfUrl ?? ''],
         })
       );
@@ -229,9 +250,9 @@ export class BatchExperimentModalComponent {
       });
 
       this.uploadFileEvent()
-        .then((fileUrl) => {
-          if (fileUrl) {
-            this.analyticalTests.at(index).patchValue({ pdfUrl: fileUrl });
+        .then((fileId) => {
+          if (fileId) {
+            this.analyticalTests.at(index).patchValue({ fileId, pdfUrl: null });
           }
 
           this.uploadingFiles.update((map) => {
@@ -254,11 +275,9 @@ export class BatchExperimentModalComponent {
   async uploadFileEvent() {
     if (this.selectedFile()) {
       try {
-        const fileUrl = await this.firebaseService.uploadImage(
-          this.selectedFile()!
-        );
+        const file = await this.fileService.uploadFile(this.selectedFile()!);
 
-        return fileUrl;
+        return file.id;
       } catch (error) {
         this.mutationStatus.emit({
           status: false,
@@ -278,6 +297,7 @@ export class BatchExperimentModalComponent {
       payload.timeStart = this.formatTime(payload.timeStart) ?? '';
       payload.timeEnd = this.formatTime(payload.timeEnd) ?? '';
       payload.date = this.formatDate(new Date(payload.date));
+      this.keepQuantityUnits(payload as any);
     }
 
     this.loading.set(true);
@@ -343,6 +363,24 @@ export class BatchExperimentModalComponent {
     this.analyticalTests.clear();
   }
 
+  // Quantities are edited as plain numbers; send them back in the unit they
+  // were recorded in, new values fall back to the default unit of the field.
+  private keepQuantityUnits(payload: Record<string, any>) {
+    const experiment = this.batchExperimentData();
+    const recorded: Record<string, Quantity | null | undefined> = {
+      ...(experiment?.materialFeedstock ?? {}),
+      ...(experiment?.exposureConditions ?? {}),
+    } as any;
+
+    QUANTITY_FIELDS.forEach((field) => {
+      const value = payload[field];
+      payload[field] =
+        value === null || value === undefined || value === ''
+          ? null
+          : { value, unit: recorded[field]?.unit ?? '' };
+    });
+  }
+
   private formatTime(date: any): string | null {
     if (!date) return null;
 
@@ -24,7 +24,7 @@ import { Reactor } from '../../../../core/models/models';
 import { ReactorService } from '../reactor.service';
 import { finalize } from 'rxjs';
 import { Chip } from 'primeng/chip';
-import { FirebaseService } from '../../../../core/services/firebase.service';
+import { FileService } from '../../../../core/services/file.service';
 import { TagModule } from 'primeng/tag';
 
 @Component({
@@ -57,7 +57,7 @@ export class ReactorModalComponent {
 
   private fb = inject(FormBuilder);
   private reactorService = inject(ReactorService);
-  private firebaseService = inject(FirebaseService);
+  private fileService = inject(FileService);
   mutationStatus = output<Record<string, boolean | string>>();
 
   statusOptions = signal([
@@ -82,7 +82,7 @@ export class ReactorModalComponent {
       name: ['', Validators.required],
       status: ['', Validators.required],
       pathway: ['', Validators.required],
-      pdfUrl: [''],
+      pdfFileId: [null],
     });
   }
 
@@ -98,7 +98,7 @@ export class ReactorModalComponent {
     this.reactorForm.patchValue({
       name: this.reactorData?.name,
       pathway: this.reactorData?.pathway,
-      pdfUrl: this.reactorData?.pdfUrl,
+      pdfFileId: this.reactorData?.pdfFileId ?? null,
       status: this.reactorData?.status,
     });
   }
@@ -113,10 +113,8 @@ export class ReactorModalComponent {
 
     try {
       if (this.selectedFile()) {
-        const imageUrl = await this.firebaseService.uploadImage(
-          this.selectedFile()!
-        );
-        reactor.pdfUrl = imageUrl;
+        const file = await this.fileService.uploadFile(this.selectedFile()!);
+        reactor.pdfFileId = file.id;
       }
     } catch (error) {
       this.loading.set(false);
@@ -196,10 +194,22 @@ export class ReactorModalComponent {
     reader.readAsDataURL(file);
   }
 
+  openFile() {
+    if (this.reactorData?.pdfFileId) {
+      this.fileService.openFile(this.reactorData.pdfFileId);
+    }
+  }
+
+  // Detaches the document; a file id of 0 also clears a legacy link.
   removeFile() {
+    if (!this.reactorData) {
+      this.selectedFile.set(null);
+      return;
+    }
+
     this.removeLoading.set(true);
-    this.firebaseService
-      .removeImage(this.reactorData?.pdfUrl!)
+    this.reactorService
+      .updateReactor(this.reactorData.id, { pdfFileId: 0 })
       .pipe(
         finalize(() => {
           this.removeLoading.set(false);
@@ -208,11 +218,9 @@ export class ReactorModalComponent {
       .subscribe({
         next: () => {
           if (this.reactorData) {
+            this.reactorData.pdfFileId = null;
             this.reactorData.pdfUrl = '';
-            this.reactorForm.patchValue({ pdfUrl: '' });
-            this.reactorService
-              .updateReactor(this.reactorData.id, this.reactorData)
-              .subscribe();
+            this.reactorForm.patchValue({ pdfFileId: null });
           }
           this.selectedFile.set(null);
         },
@@ -225,6 +233,7 @@ export class ReactorModalComponent {
       });
   }
 
+
   ngOnDestroy() {
     this.reactorForm.reset();
   }